@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+const (
+	auditLoginSucceeded  = "auth.login_succeeded"
+	auditLoginFailed     = "auth.login_failed"
+	auditTokenRefreshed  = "auth.token_refreshed"
+	auditTokenRevoked    = "auth.token_revoked"
+	auditPasswordChanged = "user.password_changed"
+	auditUserUpgraded    = "user.upgraded"
+	auditChirpDeleted    = "chirp.deleted"
+	auditReportResolved  = "admin.report_resolved"
+	auditRoleChanged     = "admin.role_changed"
+	auditUserSuspended   = "admin.user_suspended"
+	auditUserUnsuspended = "admin.user_unsuspended"
+	auditDataReset       = "admin.data_reset"
+	auditLoginUnlocked   = "admin.login_unlocked"
+)
+
+// auditEvent is one entry for the audit log. ActorID is who did it and
+// SubjectID the account it happened to, uuid.Nil when unknown.
+type auditEvent struct {
+	Type       string
+	ActorID    uuid.UUID
+	SubjectID  uuid.UUID
+	TargetType string
+	TargetID   uuid.UUID
+	Details    map[string]any
+}
+
+type AuditEvent struct {
+	ID         uuid.UUID       `json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	EventType  string          `json:"event_type"`
+	ActorID    *uuid.UUID      `json:"actor_id,omitempty"`
+	SubjectID  *uuid.UUID      `json:"subject_id,omitempty"`
+	TargetType string          `json:"target_type,omitempty"`
+	TargetID   *uuid.UUID      `json:"target_id,omitempty"`
+	IP         string          `json:"ip"`
+	UserAgent  string          `json:"user_agent"`
+	RequestID  string          `json:"request_id"`
+	Details    json.RawMessage `json:"details"`
+}
+
+func toAuditEvent(e database.AuditEvent) AuditEvent {
+	resp := AuditEvent{
+		ID:         e.ID,
+		CreatedAt:  e.CreatedAt,
+		EventType:  e.EventType,
+		TargetType: e.TargetType.String,
+		IP:         e.IP,
+		UserAgent:  e.UserAgent,
+		RequestID:  e.RequestID,
+		Details:    e.Details,
+	}
+	if e.ActorID.Valid {
+		resp.ActorID = &e.ActorID.UUID
+	}
+	if e.SubjectID.Valid {
+		resp.SubjectID = &e.SubjectID.UUID
+	}
+	if e.TargetID.Valid {
+		resp.TargetID = &e.TargetID.UUID
+	}
+	return resp
+}
+
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
+
+// caller is who sent a request, as the audit log and the login throttle
+// see them. HTTP and gRPC requests both have one.
+type caller struct {
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+func callerFromRequest(r *http.Request) caller {
+	return caller{
+		IP:        clientIP(r),
+		UserAgent: r.UserAgent(),
+		RequestID: requestIDFromContext(r.Context()),
+	}
+}
+
+// audit writes e to the audit log. A failed write is logged but doesn't fail
+// the request, the action it describes has already happened.
+func (cfg *apiConf) audit(r *http.Request, e auditEvent) {
+	cfg.auditCaller(r.Context(), callerFromRequest(r), e)
+}
+
+// auditCaller is audit for requests that didn't come over HTTP.
+func (cfg *apiConf) auditCaller(ctx context.Context, c caller, e auditEvent) {
+	details := []byte("{}")
+	if len(e.Details) > 0 {
+		b, err := json.Marshal(e.Details)
+		if err != nil {
+			log.Printf("audit %s: %v", e.Type, err)
+		} else {
+			details = b
+		}
+	}
+
+	// the client going away must not drop the entry
+	ctx = context.WithoutCancel(ctx)
+	if err := cfg.db.CreateAuditEvent(ctx, database.CreateAuditEventParams{
+		EventType:  e.Type,
+		ActorID:    nullUUID(e.ActorID),
+		SubjectID:  nullUUID(e.SubjectID),
+		TargetType: sql.NullString{String: e.TargetType, Valid: e.TargetType != ""},
+		TargetID:   nullUUID(e.TargetID),
+		IP:         c.IP,
+		UserAgent:  c.UserAgent,
+		RequestID:  c.RequestID,
+		Details:    details,
+	}); err != nil {
+		log.Printf("audit %s: %v", e.Type, err)
+	}
+}
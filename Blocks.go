@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+// blockRefresh is how often live streams reload the viewer's blocks.
+const blockRefresh = 30 * time.Second
+
+// blockSet holds the users hidden from one viewer's live streams: everyone
+// the viewer blocked or was blocked by. Stream filters run while the broker
+// publishes and can't query the database, so a stream loads the set when it
+// starts and keeps it fresh with watch. The nil set of an anonymous viewer
+// hides no one.
+type blockSet struct {
+	viewer uuid.UUID
+
+	mu  sync.RWMutex
+	ids map[uuid.UUID]struct{}
+}
+
+func (cfg *apiConf) loadBlockSet(ctx context.Context, viewer uuid.UUID) (*blockSet, error) {
+	if viewer == uuid.Nil {
+		return nil, nil
+	}
+	b := &blockSet{viewer: viewer}
+	if err := b.reload(ctx, cfg.db); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *blockSet) hides(userID uuid.UUID) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, ok := b.ids[userID]
+	return ok
+}
+
+func (b *blockSet) reload(ctx context.Context, db *database.Queries) error {
+	others, err := db.ListBlockRelations(ctx, b.viewer)
+	if err != nil {
+		return err
+	}
+
+	ids := make(map[uuid.UUID]struct{}, len(others))
+	for _, id := range others {
+		ids[id] = struct{}{}
+	}
+
+	b.mu.Lock()
+	b.ids = ids
+	b.mu.Unlock()
+	return nil
+}
+
+// watch reloads the set every blockRefresh until ctx is done, so blocks
+// made while a stream is open apply to it too.
+func (b *blockSet) watch(ctx context.Context, db *database.Queries) {
+	if b == nil {
+		return
+	}
+	ticker := time.NewTicker(blockRefresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := b.reload(ctx, db); err != nil && ctx.Err() == nil {
+			log.Println("reload blocks:", err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testETag = `"abc"`
+
+func TestETagListMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		list   string
+		strong bool
+		want   bool
+	}{
+		{name: "same tag", list: `"abc"`, want: true},
+		{name: "same tag strong", list: `"abc"`, strong: true, want: true},
+		{name: "other tag", list: `"xyz"`, want: false},
+		{name: "weak tag weak comparison", list: `W/"abc"`, want: true},
+		{name: "weak tag strong comparison", list: `W/"abc"`, strong: true, want: false},
+		{name: "star", list: `*`, want: true},
+		{name: "star strong", list: `*`, strong: true, want: true},
+		{name: "in a list", list: `"xyz", "abc"`, want: true},
+		{name: "list without spaces", list: `"xyz","abc"`, want: true},
+		{name: "weak in a list strong", list: `"xyz", W/"abc"`, strong: true, want: false},
+		{name: "not in a list", list: `"xyz", "uvw"`, want: false},
+		{name: "unquoted", list: `abc`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etagListMatches(tt.list, testETag, tt.strong); got != tt.want {
+				t.Errorf("etagListMatches(%q, strong=%v) = %v, want %v", tt.list, tt.strong, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2025, 1, 1, 12, 0, 0, 500_000_000, time.UTC)
+	at := modified.Format(http.TimeFormat)
+	before := modified.Add(-time.Minute).Format(http.TimeFormat)
+	after := modified.Add(time.Minute).Format(http.TimeFormat)
+
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		lastModified time.Time
+		want         bool
+	}{
+		{name: "no headers", lastModified: modified, want: false},
+		{name: "matching etag", headers: map[string]string{"If-None-Match": testETag}, want: true},
+		{name: "weak matching etag", headers: map[string]string{"If-None-Match": `W/"abc"`}, want: true},
+		{name: "other etag", headers: map[string]string{"If-None-Match": `"xyz"`}, want: false},
+		{name: "star", headers: map[string]string{"If-None-Match": "*"}, want: true},
+		{name: "same second", headers: map[string]string{"If-Modified-Since": at}, lastModified: modified, want: true},
+		{name: "later date", headers: map[string]string{"If-Modified-Since": after}, lastModified: modified, want: true},
+		{name: "earlier date", headers: map[string]string{"If-Modified-Since": before}, lastModified: modified, want: false},
+		{name: "no Last-Modified", headers: map[string]string{"If-Modified-Since": after}, want: false},
+		{name: "bad date", headers: map[string]string{"If-Modified-Since": "yesterday"}, lastModified: modified, want: false},
+		{
+			name:         "If-None-Match mismatch wins over a current date",
+			headers:      map[string]string{"If-None-Match": `"xyz"`, "If-Modified-Since": after},
+			lastModified: modified,
+			want:         false,
+		},
+		{
+			name:         "If-None-Match match wins over an old date",
+			headers:      map[string]string{"If-None-Match": testETag, "If-Modified-Since": before},
+			lastModified: modified,
+			want:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/chirps", nil)
+			for name, v := range tt.headers {
+				r.Header.Set(name, v)
+			}
+			if got := notModified(r, testETag, tt.lastModified); got != tt.want {
+				t.Errorf("notModified() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    bool
+	}{
+		{name: "no header", want: true},
+		{name: "matching etag", ifMatch: testETag, want: true},
+		{name: "other etag", ifMatch: `"xyz"`, want: false},
+		{name: "weak etag", ifMatch: `W/"abc"`, want: false},
+		{name: "star", ifMatch: "*", want: true},
+		{name: "in a list", ifMatch: `"xyz", "abc"`, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodDelete, "/api/chirps/1", nil)
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+			if got := ifMatch(r, testETag); got != tt.want {
+				t.Errorf("ifMatch(%q) = %v, want %v", tt.ifMatch, got, tt.want)
+			}
+		})
+	}
+}
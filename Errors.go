@@ -0,0 +1,200 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+// Error codes are part of the API: clients switch on them, so existing ones
+// must never change meaning. Messages (detail) are for humans and may.
+const (
+	codeBadRequest           = "bad_request"
+	codeInvalidJSON          = "invalid_json"
+	codeInvalidID            = "invalid_id"
+	codeValidationFailed     = "validation_failed"
+	codeMissingToken         = "missing_token"
+	codeInvalidToken         = "invalid_token"
+	codeUnauthorized         = "unauthorized"
+	codeAccountSuspended     = "account_suspended"
+	codeForbidden            = "forbidden"
+	codeNotFound             = "not_found"
+	codeAlreadyExists        = "already_exists"
+	codeConflict             = "conflict"
+	codePreconditionFailed   = "precondition_failed"
+	codePayloadTooLarge      = "payload_too_large"
+	codeUnsupportedMediaType = "unsupported_media_type"
+	codeUnprocessable        = "unprocessable_entity"
+	codeTooManyRequests      = "too_many_requests"
+	codeFailedDependency     = "failed_dependency"
+	codeInternal             = "internal_error"
+
+	// field level codes
+	codeFieldRequired = "required"
+	codeFieldInvalid  = "invalid"
+	codeFieldTooLong  = "too_long"
+	codeFieldRange    = "out_of_range"
+)
+
+// statusCodes is the code used for a status when a handler doesn't pick a
+// more specific one.
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            codeBadRequest,
+	http.StatusUnauthorized:          codeUnauthorized,
+	http.StatusForbidden:             codeForbidden,
+	http.StatusNotFound:              codeNotFound,
+	http.StatusConflict:              codeConflict,
+	http.StatusPreconditionFailed:    codePreconditionFailed,
+	http.StatusRequestEntityTooLarge: codePayloadTooLarge,
+	http.StatusUnsupportedMediaType:  codeUnsupportedMediaType,
+	http.StatusUnprocessableEntity:   codeUnprocessable,
+	http.StatusFailedDependency:      codeFailedDependency,
+	http.StatusTooManyRequests:       codeTooManyRequests,
+}
+
+// Problem is an RFC 7807 problem details object, the body of every error
+// response.
+type Problem struct {
+	Type      string       `json:"type" example:"about:blank"`
+	Title     string       `json:"title" example:"Bad Request"`
+	Status    int          `json:"status" example:"400"`
+	Detail    string       `json:"detail,omitempty" example:"chirp is too long"`
+	Code      string       `json:"code" example:"validation_failed"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// FieldError says which part of the request failed validation.
+type FieldError struct {
+	Field   string `json:"field" example:"body"`
+	Code    string `json:"code" example:"too_long"`
+	Message string `json:"message" example:"must be at most 140 characters"`
+}
+
+// apiError is an error that knows how it is reported to the client. Err is
+// the underlying cause, it is logged but never sent.
+type apiError struct {
+	Status int
+	Code   string
+	Detail string
+	Fields []FieldError
+	Err    error
+}
+
+func (e *apiError) Error() string {
+	if e.Err != nil {
+		return e.Detail + ": " + e.Err.Error()
+	}
+	return e.Detail
+}
+
+func (e *apiError) Unwrap() error {
+	return e.Err
+}
+
+// statusError is an apiError with the code derived from the status, see
+// respondWithError.
+func statusError(status int, detail string, err error) *apiError {
+	code, ok := statusCodes[status]
+	if !ok {
+		code = codeInternal
+	}
+	return &apiError{Status: status, Code: code, Detail: detail, Err: err}
+}
+
+func errInvalidJSON(err error) *apiError {
+	return &apiError{Status: http.StatusBadRequest, Code: codeInvalidJSON, Detail: "request body is not valid JSON", Err: err}
+}
+
+func errInvalidID(field string, err error) *apiError {
+	return &apiError{
+		Status: http.StatusBadRequest,
+		Code:   codeInvalidID,
+		Detail: field + " is not a valid UUID",
+		Fields: []FieldError{{Field: field, Code: codeFieldInvalid, Message: "must be a UUID"}},
+		Err:    err,
+	}
+}
+
+func errMissingToken(err error) *apiError {
+	return &apiError{Status: http.StatusUnauthorized, Code: codeMissingToken, Detail: "missing bearer token", Err: err}
+}
+
+// errInvalidToken reports a rejected access token. Suspended users have a
+// valid token and get a 403 telling them why.
+func errInvalidToken(err error) *apiError {
+	if errors.Is(err, errAccountSuspended) {
+		return &apiError{Status: http.StatusForbidden, Code: codeAccountSuspended, Detail: "account suspended", Err: err}
+	}
+	return &apiError{Status: http.StatusUnauthorized, Code: codeInvalidToken, Detail: "invalid or expired token", Err: err}
+}
+
+func errValidation(fields ...FieldError) *apiError {
+	detail := "request failed validation"
+	if len(fields) == 1 {
+		detail = fields[0].Field + " " + fields[0].Message
+	}
+	return &apiError{Status: http.StatusBadRequest, Code: codeValidationFailed, Detail: detail, Fields: fields}
+}
+
+// dbError maps a database error to the status it deserves: missing rows
+// are 404s, constraint violations are the client's fault, anything else is
+// ours.
+func dbError(err error, notFound string) *apiError {
+	if errors.Is(err, sql.ErrNoRows) {
+		return &apiError{Status: http.StatusNotFound, Code: codeNotFound, Detail: notFound, Err: err}
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505": // unique_violation
+			return &apiError{Status: http.StatusConflict, Code: codeAlreadyExists, Detail: "resource already exists", Err: err}
+		case "23503": // foreign_key_violation
+			return &apiError{Status: http.StatusConflict, Code: codeConflict, Detail: "referenced resource does not exist", Err: err}
+		case "23514", "22P02", "22001": // check_violation, invalid_text_representation, string_data_right_truncation
+			return &apiError{Status: http.StatusBadRequest, Code: codeBadRequest, Detail: "invalid value", Err: err}
+		}
+	}
+
+	return &apiError{Status: http.StatusInternalServerError, Code: codeInternal, Detail: "internal server error", Err: err}
+}
+
+func respondWithDBError(w http.ResponseWriter, err error, notFound string) {
+	respondWithAPIError(w, dbError(err, notFound))
+}
+
+// respondWithAPIError writes err as problem+json. Errors that aren't
+// apiErrors are internal errors and their text is not sent.
+func respondWithAPIError(w http.ResponseWriter, err error) {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		apiErr = &apiError{Status: http.StatusInternalServerError, Code: codeInternal, Detail: "internal server error", Err: err}
+	}
+
+	if apiErr.Err != nil {
+		log.Println(apiErr.Err)
+	}
+	if apiErr.Status > 499 {
+		log.Printf("Responding with 5XX error: %s", apiErr.Detail)
+	}
+
+	respondWithProblem(w, Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(apiErr.Status),
+		Status: apiErr.Status,
+		Detail: apiErr.Detail,
+		Code:   apiErr.Code,
+		Errors: apiErr.Fields,
+	})
+}
+
+func respondWithProblem(w http.ResponseWriter, p Problem) {
+	// middlewareRequestID sets the header before any handler runs
+	p.RequestID = w.Header().Get("X-Request-ID")
+	w.Header().Set("Content-Type", "application/problem+json")
+	writeJSON(w, p.Status, p)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/database"
+	"github.com/tsironi93/WebServer/internal/rpc"
+	chirpyv1 "github.com/tsironi93/WebServer/proto/chirpy/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// errorDomain is the domain of the ErrorInfo details of gRPC errors, whose
+// reason is the same code a problem+json error has.
+const errorDomain = "chirpy"
+
+// newGRPCServer serves ChirpService, UserService and AuthService from
+// proto/chirpy/v1 on top of the same queries and helpers as the HTTP
+// handlers.
+func (cfg *apiConf) newGRPCServer() *grpc.Server {
+	srv := grpc.NewServer(rpc.ServerOptions(cfg.grpcAuthenticate, cfg.grpcMetrics)...)
+	chirpyv1.RegisterAuthServiceServer(srv, authService{cfg: cfg})
+	chirpyv1.RegisterUserServiceServer(srv, userService{cfg: cfg})
+	chirpyv1.RegisterChirpServiceServer(srv, chirpService{cfg: cfg})
+	return srv
+}
+
+// grpcAuthenticate validates access tokens for the auth interceptor, with
+// the same suspension check as the HTTP handlers.
+func (cfg *apiConf) grpcAuthenticate(token string) (uuid.UUID, error) {
+	userID, err := cfg.validateJWT(token)
+	if err != nil {
+		return uuid.Nil, grpcError(errInvalidToken(err))
+	}
+	return userID, nil
+}
+
+// grpcCodes is the gRPC code for the status of an apiError.
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:            codes.InvalidArgument,
+	http.StatusUnauthorized:          codes.Unauthenticated,
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.NotFound,
+	http.StatusConflict:              codes.FailedPrecondition,
+	http.StatusPreconditionFailed:    codes.FailedPrecondition,
+	http.StatusRequestEntityTooLarge: codes.InvalidArgument,
+	http.StatusUnsupportedMediaType:  codes.InvalidArgument,
+	http.StatusUnprocessableEntity:   codes.InvalidArgument,
+	http.StatusTooManyRequests:       codes.ResourceExhausted,
+}
+
+// grpcError is respondWithAPIError for gRPC: the status carries the detail,
+// and the code and field errors as ErrorInfo and BadRequest details. Errors
+// that aren't apiErrors are internal errors and their text is not sent.
+func grpcError(err error) error {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		apiErr = &apiError{Status: http.StatusInternalServerError, Code: codeInternal, Detail: "internal server error", Err: err}
+	}
+	if apiErr.Err != nil {
+		log.Println(apiErr.Err)
+	}
+
+	code, ok := grpcCodes[apiErr.Status]
+	if !ok {
+		code = codes.Internal
+	}
+	if apiErr.Code == codeAlreadyExists {
+		code = codes.AlreadyExists
+	}
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: apiErr.Code, Domain: errorDomain}}
+	if len(apiErr.Fields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(apiErr.Fields))
+		for i, f := range apiErr.Fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message, Reason: f.Code}
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+	var locked *loginLockedError
+	if errors.As(err, &locked) {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(locked.wait)})
+	}
+
+	st := status.New(code, apiErr.Detail)
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// callerFromContext is callerFromRequest for gRPC calls.
+func callerFromContext(ctx context.Context) caller {
+	c := caller{RequestID: rpc.RequestIDFrom(ctx)}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+		c.IP = host
+	}
+	if ua := metadata.ValueFromIncomingContext(ctx, "user-agent"); len(ua) > 0 {
+		c.UserAgent = ua[0]
+	}
+	return c
+}
+
+// parseGRPCID parses the UUID in a request field.
+func parseGRPCID(field, s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, grpcError(errInvalidID(field, err))
+	}
+	return id, nil
+}
+
+func toUserPB(u database.User, self bool) *chirpyv1.User {
+	user := &chirpyv1.User{
+		Id:          u.ID.String(),
+		CreatedAt:   timestamppb.New(u.CreatedAt),
+		UpdatedAt:   timestamppb.New(u.UpdatedAt),
+		IsChirpyRed: u.IsChirpyRed,
+	}
+	if self {
+		user.Email = u.Email
+	}
+	return user
+}
+
+func toChirpPB(c Chirp) *chirpyv1.Chirp {
+	chirp := &chirpyv1.Chirp{
+		Id:        c.ID.String(),
+		CreatedAt: timestamppb.New(c.CreatedAt),
+		UpdatedAt: timestamppb.New(c.UpdatedAt),
+		Body:      c.Body,
+		UserId:    c.UserID.String(),
+		Pinned:    c.Pinned,
+	}
+	if c.QuotedChirpID != nil {
+		chirp.QuotedChirpId = c.QuotedChirpID.String()
+	}
+	if c.QuotedChirp != nil {
+		chirp.QuotedChirp = toChirpPB(*c.QuotedChirp)
+	}
+	if c.ReplyToID != nil {
+		chirp.ReplyToId = c.ReplyToID.String()
+	}
+	if c.ThreadID != nil {
+		chirp.ThreadId = c.ThreadID.String()
+	}
+	if c.Poll != nil {
+		chirp.Poll = toPollPB(*c.Poll)
+	}
+	for _, p := range c.LinkPreviews {
+		chirp.LinkPreviews = append(chirp.LinkPreviews, &chirpyv1.LinkPreview{
+			Url:         p.URL,
+			Title:       p.Title,
+			Description: p.Description,
+			ImageUrl:    p.ImageURL,
+			SiteName:    p.SiteName,
+		})
+	}
+	return chirp
+}
+
+func toPollPB(p Poll) *chirpyv1.Poll {
+	poll := &chirpyv1.Poll{
+		Id:         p.ID.String(),
+		ExpiresAt:  timestamppb.New(p.ExpiresAt),
+		Closed:     p.Closed,
+		TotalVotes: int32(p.TotalVotes),
+	}
+	if p.VotedOptionID != nil {
+		poll.VotedOptionId = p.VotedOptionID.String()
+	}
+	for _, o := range p.Options {
+		poll.Options = append(poll.Options, &chirpyv1.PollOption{
+			Id:    o.ID.String(),
+			Label: o.Label,
+			Votes: int32(o.Votes),
+		})
+	}
+	return poll
+}
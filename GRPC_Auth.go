@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+
+	chirpyv1 "github.com/tsironi93/WebServer/proto/chirpy/v1"
+)
+
+// authService is AuthService, the gRPC twin of /api/login, /api/refresh and
+// /api/revoke. Failed logins count against the same throttle.
+type authService struct {
+	chirpyv1.UnimplementedAuthServiceServer
+	cfg *apiConf
+}
+
+func (s authService) Login(ctx context.Context, req *chirpyv1.LoginRequest) (*chirpyv1.LoginResponse, error) {
+	login, err := s.cfg.login(ctx, callerFromContext(ctx), req.GetEmail(), req.GetPassword())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &chirpyv1.LoginResponse{
+		User:         toUserPB(login.user, true),
+		Token:        login.token,
+		RefreshToken: login.refreshToken,
+	}, nil
+}
+
+func (s authService) Refresh(ctx context.Context, req *chirpyv1.RefreshRequest) (*chirpyv1.RefreshResponse, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, grpcError(errMissingToken(nil))
+	}
+	token, err := s.cfg.refreshAccessToken(ctx, callerFromContext(ctx), req.GetRefreshToken())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &chirpyv1.RefreshResponse{Token: token}, nil
+}
+
+func (s authService) Revoke(ctx context.Context, req *chirpyv1.RevokeRequest) (*chirpyv1.RevokeResponse, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, grpcError(errMissingToken(nil))
+	}
+	if err := s.cfg.revokeRefreshToken(ctx, callerFromContext(ctx), req.GetRefreshToken()); err != nil {
+		return nil, grpcError(err)
+	}
+	return &chirpyv1.RevokeResponse{}, nil
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/database"
+	"github.com/tsironi93/WebServer/internal/pubsub"
+	"github.com/tsironi93/WebServer/internal/rpc"
+	chirpyv1 "github.com/tsironi93/WebServer/proto/chirpy/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// chirpService is ChirpService. Chirps are filtered for the caller like on
+// the HTTP API.
+type chirpService struct {
+	chirpyv1.UnimplementedChirpServiceServer
+	cfg *apiConf
+}
+
+func (s chirpService) GetChirp(ctx context.Context, req *chirpyv1.GetChirpRequest) (*chirpyv1.GetChirpResponse, error) {
+	id, err := parseGRPCID("id", req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	viewer, _ := rpc.UserFrom(ctx)
+
+	chirp, err := s.cfg.db.GetVisibleChirp(ctx, database.GetVisibleChirpParams{
+		ID:       id,
+		ViewerID: viewer,
+	})
+	if err != nil {
+		return nil, grpcError(dbError(err, "chirp not in the database"))
+	}
+
+	resp := []Chirp{toChirp(chirp)}
+	if err := s.cfg.enrichChirps(ctx, resp, viewer); err != nil {
+		return nil, grpcError(err)
+	}
+	return &chirpyv1.GetChirpResponse{Chirp: toChirpPB(resp[0])}, nil
+}
+
+func (s chirpService) ListChirps(ctx context.Context, req *chirpyv1.ListChirpsRequest) (*chirpyv1.ListChirpsResponse, error) {
+	authorID := uuid.Nil
+	if req.GetAuthorId() != "" {
+		id, err := parseGRPCID("author_id", req.GetAuthorId())
+		if err != nil {
+			return nil, err
+		}
+		authorID = id
+	}
+
+	limit, offset := int(req.GetLimit()), int(req.GetOffset())
+	if limit == 0 {
+		limit = defaultPageLimit
+	}
+	if limit < 1 || limit > maxPageLimit {
+		return nil, grpcError(errValidation(FieldError{Field: "limit", Code: codeFieldRange, Message: fmt.Sprintf("must be between 1 and %d", maxPageLimit)}))
+	}
+	if offset < 0 {
+		return nil, grpcError(errValidation(FieldError{Field: "offset", Code: codeFieldRange, Message: "must be a positive number"}))
+	}
+
+	viewer, _ := rpc.UserFrom(ctx)
+	chirps, err := s.cfg.listChirps(ctx, authorID, viewer, req.GetSort() == chirpyv1.SortOrder_SORT_ORDER_DESC)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	chirps = chirps[min(offset, len(chirps)):min(offset+limit, len(chirps))]
+	if err := s.cfg.enrichChirps(ctx, chirps, viewer); err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := &chirpyv1.ListChirpsResponse{Chirps: make([]*chirpyv1.Chirp, len(chirps))}
+	for i, c := range chirps {
+		resp.Chirps[i] = toChirpPB(c)
+	}
+	return resp, nil
+}
+
+// CreateChirp publishes a chirp right away. Drafts, scheduling and polls
+// are only on the HTTP API.
+func (s chirpService) CreateChirp(ctx context.Context, req *chirpyv1.CreateChirpRequest) (*chirpyv1.CreateChirpResponse, error) {
+	userID, err := rpc.RequireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cleaned, err := validateChirp(req.GetBody())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	params := database.CreateChirpParams{
+		Body:   cleaned,
+		UserID: userID,
+	}
+	if req.GetQuotedChirpId() != "" {
+		quotedID, err := parseGRPCID("quoted_chirp_id", req.GetQuotedChirpId())
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.cfg.db.GetVisibleChirp(ctx, database.GetVisibleChirpParams{
+			ID:       quotedID,
+			ViewerID: userID,
+		}); err != nil {
+			return nil, grpcError(statusError(http.StatusBadRequest, "quoted chirp not found", err))
+		}
+		params.QuotedChirpID = uuid.NullUUID{UUID: quotedID, Valid: true}
+	}
+	if req.GetReplyToId() != "" {
+		replyToID, err := parseGRPCID("reply_to_id", req.GetReplyToId())
+		if err != nil {
+			return nil, err
+		}
+		if err := s.cfg.setReplyTo(ctx, &params, replyToID); err != nil {
+			return nil, grpcError(err)
+		}
+	}
+
+	chirp, err := s.cfg.db.CreateChirp(ctx, params)
+	if err != nil {
+		return nil, grpcError(statusError(http.StatusInternalServerError, "Couldn't create chirp", err))
+	}
+
+	resp := []Chirp{s.cfg.chirpCreated(ctx, chirp)}
+	if err := s.cfg.attachQuotes(ctx, resp, userID); err != nil {
+		log.Println("couldn't load quoted chirp:", err)
+	}
+	return &chirpyv1.CreateChirpResponse{Chirp: toChirpPB(resp[0])}, nil
+}
+
+func (s chirpService) DeleteChirp(ctx context.Context, req *chirpyv1.DeleteChirpRequest) (*chirpyv1.DeleteChirpResponse, error) {
+	userID, err := rpc.RequireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseGRPCID("id", req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	chirp, err := s.cfg.db.GetSingleChirp(ctx, id)
+	if err != nil {
+		return nil, grpcError(dbError(err, "chirp not in the database"))
+	}
+	if chirp.UserID != userID {
+		return nil, grpcError(statusError(http.StatusForbidden, "not your chirp", nil))
+	}
+
+	if err := s.cfg.trashChirp(ctx, callerFromContext(ctx), chirp); err != nil {
+		return nil, grpcError(err)
+	}
+	return &chirpyv1.DeleteChirpResponse{}, nil
+}
+
+// StreamChirps sends the same events as /api/chirps/stream, without the
+// chirps of users the caller blocked or was blocked by. When the client
+// falls behind the broker drops it, and the stream ends with Unavailable so
+// it resumes with last_event_id.
+func (s chirpService) StreamChirps(req *chirpyv1.StreamChirpsRequest, stream grpc.ServerStreamingServer[chirpyv1.StreamChirpsResponse]) error {
+	authorID := uuid.Nil
+	if req.GetAuthorId() != "" {
+		id, err := parseGRPCID("author_id", req.GetAuthorId())
+		if err != nil {
+			return err
+		}
+		authorID = id
+	}
+
+	ctx := stream.Context()
+	viewer, _ := rpc.UserFrom(ctx)
+	blocks, err := s.cfg.loadBlockSet(ctx, viewer)
+	if err != nil {
+		return grpcError(err)
+	}
+	go blocks.watch(ctx, s.cfg.db)
+
+	filter := func(e pubsub.Event) bool {
+		return isChirpEvent(e) && (authorID == uuid.Nil || e.AuthorID == authorID) && !blocks.hides(e.AuthorID)
+	}
+
+	sub, missed := s.cfg.broker.Subscribe(req.GetLastEventId(), filter)
+	defer sub.Close()
+
+	for _, e := range missed {
+		if err := sendChirpEvent(stream, e); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case e, ok := <-sub.C:
+			if !ok {
+				return status.Error(codes.Unavailable, "stream fell behind, resume with last_event_id")
+			}
+			if err := sendChirpEvent(stream, e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func sendChirpEvent(stream grpc.ServerStreamingServer[chirpyv1.StreamChirpsResponse], e pubsub.Event) error {
+	var chirp Chirp
+	if err := json.Unmarshal(e.Data, &chirp); err != nil {
+		log.Printf("couldn't decode chirp event %d: %v", e.ID, err)
+		return nil
+	}
+	return stream.Send(&chirpyv1.StreamChirpsResponse{
+		Id:    e.ID,
+		Type:  e.Type,
+		Chirp: toChirpPB(chirp),
+	})
+}
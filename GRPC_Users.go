@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/rpc"
+	chirpyv1 "github.com/tsironi93/WebServer/proto/chirpy/v1"
+)
+
+// userService is UserService. Emails are only returned to their owner.
+type userService struct {
+	chirpyv1.UnimplementedUserServiceServer
+	cfg *apiConf
+}
+
+func (s userService) GetUser(ctx context.Context, req *chirpyv1.GetUserRequest) (*chirpyv1.GetUserResponse, error) {
+	id, err := parseGRPCID("id", req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.cfg.db.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, grpcError(dbError(err, "user not found"))
+	}
+	viewer, _ := rpc.UserFrom(ctx)
+	return &chirpyv1.GetUserResponse{User: toUserPB(user, user.ID == viewer)}, nil
+}
+
+func (s userService) BatchGetUsers(ctx context.Context, req *chirpyv1.BatchGetUsersRequest) (*chirpyv1.BatchGetUsersResponse, error) {
+	if len(req.GetIds()) > maxPageLimit {
+		return nil, grpcError(errValidation(FieldError{Field: "ids", Code: codeFieldRange, Message: fmt.Sprintf("must have at most %d ids", maxPageLimit)}))
+	}
+	ids := make([]uuid.UUID, len(req.GetIds()))
+	for i, s := range req.GetIds() {
+		id, err := parseGRPCID(fmt.Sprintf("ids[%d]", i), s)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+
+	users, err := s.cfg.db.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	viewer, _ := rpc.UserFrom(ctx)
+	resp := &chirpyv1.BatchGetUsersResponse{Users: make([]*chirpyv1.User, len(users))}
+	for i, u := range users {
+		resp.Users[i] = toUserPB(u, u.ID == viewer)
+	}
+	return resp, nil
+}
+
+func (s userService) GetMe(ctx context.Context, _ *chirpyv1.GetMeRequest) (*chirpyv1.GetMeResponse, error) {
+	viewer, err := rpc.RequireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.cfg.db.GetUserByID(ctx, viewer)
+	if err != nil {
+		return nil, grpcError(dbError(err, "user not found"))
+	}
+	return &chirpyv1.GetMeResponse{User: toUserPB(user, true)}, nil
+}
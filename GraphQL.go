@@ -0,0 +1,552 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/database"
+	"github.com/tsironi93/WebServer/internal/graphql"
+	"github.com/tsironi93/WebServer/internal/unfurl"
+)
+
+//go:embed schema.graphql
+var graphQLSchema string
+
+const (
+	graphQLMaxDepth      = 8
+	graphQLMaxComplexity = 5000
+)
+
+// loader fetches values by key for one GraphQL request and remembers them,
+// so a user or chirp that shows up at several places in a query is loaded
+// once. The executor resolves a field for a whole list at once, so loaders
+// are called with all the keys of a level. Not safe for concurrent use.
+type loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+	cache map[K]V
+	// missing are keys fetched before that had no value
+	missing map[K]bool
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, cache: make(map[K]V), missing: make(map[K]bool)}
+}
+
+// loadMany returns the values of keys that exist, fetching the ones it
+// hasn't seen yet in a single call.
+func (l *loader[K, V]) loadMany(ctx context.Context, keys []K) (map[K]V, error) {
+	var todo []K
+	queued := make(map[K]bool)
+	for _, k := range keys {
+		_, cached := l.cache[k]
+		if !cached && !l.missing[k] && !queued[k] {
+			todo = append(todo, k)
+			queued[k] = true
+		}
+	}
+
+	if len(todo) > 0 {
+		fetched, err := l.fetch(ctx, todo)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range todo {
+			if v, ok := fetched[k]; ok {
+				l.cache[k] = v
+			} else {
+				l.missing[k] = true
+			}
+		}
+	}
+
+	out := make(map[K]V, len(keys))
+	for _, k := range keys {
+		if v, ok := l.cache[k]; ok {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+// graphQLRequest is the per request state resolvers work with.
+type graphQLRequest struct {
+	cfg    *apiConf
+	viewer uuid.UUID
+
+	users *loader[uuid.UUID, database.User]
+	// chirps are single chirps the viewer may see, by ID
+	chirps *loader[uuid.UUID, Chirp]
+	// pins are the pinned chirp IDs of a user, most recently pinned first
+	pins  *loader[uuid.UUID, []uuid.UUID]
+	polls *loader[uuid.UUID, *Poll]
+}
+
+type graphQLRequestKey struct{}
+
+func (cfg *apiConf) newGraphQLRequest(viewer uuid.UUID) *graphQLRequest {
+	q := &graphQLRequest{cfg: cfg, viewer: viewer}
+
+	q.users = newLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]database.User, error) {
+		users, err := cfg.db.GetUsersByIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[uuid.UUID]database.User, len(users))
+		for _, u := range users {
+			byID[u.ID] = u
+		}
+		return byID, nil
+	})
+
+	q.chirps = newLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]Chirp, error) {
+		chirps, err := cfg.db.GetVisibleChirpsByIDs(ctx, database.GetVisibleChirpsByIDsParams{
+			Ids:      ids,
+			ViewerID: viewer,
+		})
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[uuid.UUID]Chirp, len(chirps))
+		for _, c := range chirps {
+			byID[c.ID] = toChirp(c)
+		}
+		return byID, nil
+	})
+
+	q.pins = newLoader(func(ctx context.Context, users []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+		pins, err := cfg.db.GetPinsByUsers(ctx, users)
+		if err != nil {
+			return nil, err
+		}
+		byUser := make(map[uuid.UUID][]uuid.UUID, len(users))
+		for _, u := range users {
+			byUser[u] = nil
+		}
+		for _, p := range pins {
+			byUser[p.UserID] = append(byUser[p.UserID], p.ChirpID)
+		}
+		return byUser, nil
+	})
+
+	q.polls = newLoader(func(ctx context.Context, chirpIDs []uuid.UUID) (map[uuid.UUID]*Poll, error) {
+		return cfg.loadPolls(ctx, chirpIDs, viewer)
+	})
+
+	return q
+}
+
+func graphQLRequestFrom(ctx context.Context) *graphQLRequest {
+	return ctx.Value(graphQLRequestKey{}).(*graphQLRequest)
+}
+
+// newGraphQLSchema parses schema.graphql and wires up its resolvers. Every
+// field that needs the database is resolved in batches through the loaders
+// of the request.
+func newGraphQLSchema() (*graphql.Schema, error) {
+	s, err := graphql.NewSchema(graphQLSchema)
+	if err != nil {
+		return nil, err
+	}
+	s.MaxDepth = graphQLMaxDepth
+	s.MaxComplexity = graphQLMaxComplexity
+	// list fields without a limit argument are poll options and link
+	// previews, of which a chirp has at most pollMaxOptions
+	s.ListSize = pollMaxOptions
+
+	s.Resolve("Query", "me", graphql.Each(func(ctx context.Context, _ any, _ graphql.Args) (any, error) {
+		q := graphQLRequestFrom(ctx)
+		if q.viewer == uuid.Nil {
+			return nil, nil
+		}
+		return loadOne(ctx, q.users, q.viewer)
+	}))
+	s.Resolve("Query", "user", graphql.Each(func(ctx context.Context, _ any, args graphql.Args) (any, error) {
+		id, err := graphQLID(args, "id")
+		if err != nil {
+			return nil, err
+		}
+		q := graphQLRequestFrom(ctx)
+		return loadOne(ctx, q.users, id)
+	}))
+	s.Resolve("Query", "chirp", graphql.Each(func(ctx context.Context, _ any, args graphql.Args) (any, error) {
+		id, err := graphQLID(args, "id")
+		if err != nil {
+			return nil, err
+		}
+		q := graphQLRequestFrom(ctx)
+		return loadOne(ctx, q.chirps, id)
+	}))
+	s.Resolve("Query", "chirps", graphql.Each(func(ctx context.Context, _ any, args graphql.Args) (any, error) {
+		q := graphQLRequestFrom(ctx)
+		if args.String("authorId") != "" {
+			authorID, err := graphQLID(args, "authorId")
+			if err != nil {
+				return nil, err
+			}
+			chirps, err := q.authorChirps(ctx, []uuid.UUID{authorID}, args)
+			if err != nil {
+				return nil, err
+			}
+			return chirps[0], nil
+		}
+
+		if err := checkGraphQLPage(args); err != nil {
+			return nil, err
+		}
+		rows, err := q.cfg.db.GetChirpsPage(ctx, database.GetChirpsPageParams{
+			ViewerID:   q.viewer,
+			Descending: args.String("sort") == "DESC",
+			RowOffset:  int32(args.Int("offset")),
+			RowLimit:   int32(args.Int("limit")),
+		})
+		if err != nil {
+			return nil, err
+		}
+		chirps := make([]Chirp, len(rows))
+		for i, c := range rows {
+			chirps[i] = toChirp(c)
+		}
+		return chirps, nil
+	}))
+	s.Resolve("Query", "thread", graphql.Each(func(ctx context.Context, _ any, args graphql.Args) (any, error) {
+		id, err := graphQLID(args, "id")
+		if err != nil {
+			return nil, err
+		}
+		if err := checkGraphQLPage(args); err != nil {
+			return nil, err
+		}
+		q := graphQLRequestFrom(ctx)
+		chirps, err := q.chirps.loadMany(ctx, []uuid.UUID{id})
+		if err != nil {
+			return nil, err
+		}
+		chirp, ok := chirps[id]
+		if !ok {
+			return nil, nil
+		}
+		threadID := chirp.ID
+		if chirp.ThreadID != nil {
+			threadID = *chirp.ThreadID
+		}
+		rows, err := q.cfg.db.GetThread(ctx, database.GetThreadParams{
+			ThreadID:  threadID,
+			ViewerID:  q.viewer,
+			RowOffset: int32(args.Int("offset")),
+			RowLimit:  sql.NullInt32{Int32: int32(args.Int("limit")), Valid: true},
+		})
+		if err != nil {
+			return nil, err
+		}
+		thread := make([]Chirp, len(rows))
+		for i, c := range rows {
+			thread[i] = toChirp(c)
+		}
+		return thread, nil
+	}))
+
+	s.Resolve("User", "id", userField(func(u database.User) any { return u.ID }))
+	s.Resolve("User", "createdAt", userField(func(u database.User) any { return u.CreatedAt }))
+	s.Resolve("User", "updatedAt", userField(func(u database.User) any { return u.UpdatedAt }))
+	s.Resolve("User", "isChirpyRed", userField(func(u database.User) any { return u.IsChirpyRed }))
+	s.Resolve("User", "email", graphql.Each(func(ctx context.Context, u database.User, _ graphql.Args) (any, error) {
+		if u.ID != graphQLRequestFrom(ctx).viewer {
+			return nil, nil
+		}
+		return u.Email, nil
+	}))
+	s.Resolve("User", "chirps", graphql.Batch(func(ctx context.Context, users []database.User, args graphql.Args) ([]any, error) {
+		ids := make([]uuid.UUID, len(users))
+		for i, u := range users {
+			ids[i] = u.ID
+		}
+		return graphQLRequestFrom(ctx).authorChirps(ctx, ids, args)
+	}))
+	s.Resolve("User", "followers", graphql.Batch(func(ctx context.Context, users []database.User, args graphql.Args) ([]any, error) {
+		q := graphQLRequestFrom(ctx)
+		return q.followUsers(ctx, users, args, func(ids []uuid.UUID, offset, limit int64) (map[uuid.UUID][]uuid.UUID, error) {
+			rows, err := q.cfg.db.GetFollowersByUsers(ctx, database.GetFollowersByUsersParams{
+				UserIds:   ids,
+				RowOffset: offset,
+				RowLimit:  limit,
+			})
+			byUser := make(map[uuid.UUID][]uuid.UUID)
+			for _, f := range rows {
+				byUser[f.FollowedID] = append(byUser[f.FollowedID], f.FollowerID)
+			}
+			return byUser, err
+		})
+	}))
+	s.Resolve("User", "following", graphql.Batch(func(ctx context.Context, users []database.User, args graphql.Args) ([]any, error) {
+		q := graphQLRequestFrom(ctx)
+		return q.followUsers(ctx, users, args, func(ids []uuid.UUID, offset, limit int64) (map[uuid.UUID][]uuid.UUID, error) {
+			rows, err := q.cfg.db.GetFollowingByUsers(ctx, database.GetFollowingByUsersParams{
+				UserIds:   ids,
+				RowOffset: offset,
+				RowLimit:  limit,
+			})
+			byUser := make(map[uuid.UUID][]uuid.UUID)
+			for _, f := range rows {
+				byUser[f.FollowerID] = append(byUser[f.FollowerID], f.FollowedID)
+			}
+			return byUser, err
+		})
+	}))
+
+	s.Resolve("Chirp", "author", graphql.Batch(func(ctx context.Context, chirps []Chirp, _ graphql.Args) ([]any, error) {
+		ids := make([]uuid.UUID, len(chirps))
+		for i, c := range chirps {
+			ids[i] = c.UserID
+		}
+		users, err := graphQLRequestFrom(ctx).users.loadMany(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]any, len(chirps))
+		for i, c := range chirps {
+			if u, ok := users[c.UserID]; ok {
+				out[i] = u
+			}
+		}
+		return out, nil
+	}))
+	s.Resolve("Chirp", "pinned", graphql.Batch(func(ctx context.Context, chirps []Chirp, _ graphql.Args) ([]any, error) {
+		authors := make([]uuid.UUID, len(chirps))
+		for i, c := range chirps {
+			authors[i] = c.UserID
+		}
+		pins, err := graphQLRequestFrom(ctx).pins.loadMany(ctx, authors)
+		if err != nil {
+			return nil, err
+		}
+		pinned := make(map[uuid.UUID]bool)
+		for _, ids := range pins {
+			for _, id := range ids {
+				pinned[id] = true
+			}
+		}
+		out := make([]any, len(chirps))
+		for i, c := range chirps {
+			out[i] = pinned[c.ID]
+		}
+		return out, nil
+	}))
+	s.Resolve("Chirp", "quotedChirp", graphql.Batch(func(ctx context.Context, chirps []Chirp, _ graphql.Args) ([]any, error) {
+		return graphQLRequestFrom(ctx).linkedChirps(ctx, chirps, func(c Chirp) *uuid.UUID { return c.QuotedChirpID })
+	}))
+	s.Resolve("Chirp", "replyTo", graphql.Batch(func(ctx context.Context, chirps []Chirp, _ graphql.Args) ([]any, error) {
+		return graphQLRequestFrom(ctx).linkedChirps(ctx, chirps, func(c Chirp) *uuid.UUID { return c.ReplyToID })
+	}))
+	s.Resolve("Chirp", "replies", graphql.Batch(func(ctx context.Context, chirps []Chirp, args graphql.Args) ([]any, error) {
+		if err := checkGraphQLPage(args); err != nil {
+			return nil, err
+		}
+		q := graphQLRequestFrom(ctx)
+		ids := make([]uuid.UUID, len(chirps))
+		for i, c := range chirps {
+			ids[i] = c.ID
+		}
+		rows, err := q.cfg.db.GetRepliesByChirps(ctx, database.GetRepliesByChirpsParams{
+			ChirpIds:  ids,
+			ViewerID:  q.viewer,
+			RowOffset: int64(args.Int("offset")),
+			RowLimit:  int64(args.Int("limit")),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		byParent := make(map[uuid.UUID][]Chirp, len(chirps))
+		for _, c := range rows {
+			byParent[c.ReplyToID.UUID] = append(byParent[c.ReplyToID.UUID], toChirp(c))
+		}
+		out := make([]any, len(chirps))
+		for i, c := range chirps {
+			replies := byParent[c.ID]
+			if replies == nil {
+				replies = []Chirp{}
+			}
+			out[i] = replies
+		}
+		return out, nil
+	}))
+	s.Resolve("Chirp", "poll", graphql.Batch(func(ctx context.Context, chirps []Chirp, _ graphql.Args) ([]any, error) {
+		ids := make([]uuid.UUID, len(chirps))
+		for i, c := range chirps {
+			ids[i] = c.ID
+		}
+		polls, err := graphQLRequestFrom(ctx).polls.loadMany(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]any, len(chirps))
+		for i, c := range chirps {
+			out[i] = polls[c.ID]
+		}
+		return out, nil
+	}))
+	s.Resolve("Chirp", "linkPreviews", graphql.Batch(func(ctx context.Context, chirps []Chirp, _ graphql.Args) ([]any, error) {
+		withPreviews := append([]Chirp(nil), chirps...)
+		if err := graphQLRequestFrom(ctx).cfg.attachLinkPreviews(ctx, withPreviews); err != nil {
+			return nil, err
+		}
+		out := make([]any, len(chirps))
+		for i, c := range withPreviews {
+			out[i] = c.LinkPreviews
+		}
+		return out, nil
+	}))
+
+	s.Resolve("LinkPreview", "description", previewField(func(p unfurl.Preview) string { return p.Description }))
+	s.Resolve("LinkPreview", "imageUrl", previewField(func(p unfurl.Preview) string { return p.ImageURL }))
+	s.Resolve("LinkPreview", "siteName", previewField(func(p unfurl.Preview) string { return p.SiteName }))
+
+	return s, nil
+}
+
+// authorChirps resolves a chirps(sort, limit, offset) field for each of
+// authors, pinned chirps first like GET /api/chirps?author_id=. The page of
+// every author comes from one query.
+func (q *graphQLRequest) authorChirps(ctx context.Context, authors []uuid.UUID, args graphql.Args) ([]any, error) {
+	if err := checkGraphQLPage(args); err != nil {
+		return nil, err
+	}
+	rows, err := q.cfg.db.GetChirpsByAuthors(ctx, database.GetChirpsByAuthorsParams{
+		Descending: args.String("sort") == "DESC",
+		AuthorIds:  authors,
+		ViewerID:   q.viewer,
+		RowOffset:  int64(args.Int("offset")),
+		RowLimit:   int64(args.Int("limit")),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byAuthor := make(map[uuid.UUID][]Chirp, len(authors))
+	for _, c := range rows {
+		byAuthor[c.UserID] = append(byAuthor[c.UserID], toChirp(c))
+	}
+	out := make([]any, len(authors))
+	for i, a := range authors {
+		chirps := byAuthor[a]
+		if chirps == nil {
+			chirps = []Chirp{}
+		}
+		out[i] = chirps
+	}
+	return out, nil
+}
+
+// followUsers resolves a followers or following field for each of users.
+// fetch returns the page of related user IDs of every user, which are then
+// loaded in one go.
+func (q *graphQLRequest) followUsers(ctx context.Context, users []database.User, args graphql.Args, fetch func(ids []uuid.UUID, offset, limit int64) (map[uuid.UUID][]uuid.UUID, error)) ([]any, error) {
+	if err := checkGraphQLPage(args); err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	related, err := fetch(ids, int64(args.Int("offset")), int64(args.Int("limit")))
+	if err != nil {
+		return nil, err
+	}
+
+	var relatedIDs []uuid.UUID
+	for _, r := range related {
+		relatedIDs = append(relatedIDs, r...)
+	}
+	loaded, err := q.users.loadMany(ctx, relatedIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]any, len(users))
+	for i, u := range users {
+		list := []database.User{}
+		for _, id := range related[u.ID] {
+			if user, ok := loaded[id]; ok {
+				list = append(list, user)
+			}
+		}
+		out[i] = list
+	}
+	return out, nil
+}
+
+// linkedChirps resolves a field holding the chirp that ref points at for
+// each of chirps, null when there is none or the viewer may not see it.
+func (q *graphQLRequest) linkedChirps(ctx context.Context, chirps []Chirp, ref func(Chirp) *uuid.UUID) ([]any, error) {
+	var ids []uuid.UUID
+	for _, c := range chirps {
+		if id := ref(c); id != nil {
+			ids = append(ids, *id)
+		}
+	}
+	linked, err := q.chirps.loadMany(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]any, len(chirps))
+	for i, c := range chirps {
+		id := ref(c)
+		if id == nil {
+			continue
+		}
+		if l, ok := linked[*id]; ok {
+			out[i] = l
+		}
+	}
+	return out, nil
+}
+
+func checkGraphQLPage(args graphql.Args) error {
+	if limit := args.Int("limit"); limit < 1 || limit > maxPageLimit {
+		return graphql.Errorf(codeValidationFailed, "limit must be between 1 and %d", maxPageLimit)
+	}
+	if args.Int("offset") < 0 {
+		return graphql.Errorf(codeValidationFailed, "offset must be a positive number")
+	}
+	return nil
+}
+
+func graphQLID(args graphql.Args, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(args.String(name))
+	if err != nil {
+		return uuid.Nil, graphql.Errorf(codeInvalidID, "%s is not a valid UUID", name)
+	}
+	return id, nil
+}
+
+// loadOne loads a single value, nil when there is none.
+func loadOne[V any](ctx context.Context, l *loader[uuid.UUID, V], id uuid.UUID) (any, error) {
+	values, err := l.loadMany(ctx, []uuid.UUID{id})
+	if err != nil {
+		return nil, err
+	}
+	v, ok := values[id]
+	if !ok {
+		return nil, nil
+	}
+	return v, nil
+}
+
+func userField(f func(database.User) any) graphql.FieldFunc {
+	return graphql.Each(func(_ context.Context, u database.User, _ graphql.Args) (any, error) {
+		return f(u), nil
+	})
+}
+
+// previewField resolves an optional link preview field to null when it is
+// empty, where the REST API leaves it out.
+func previewField(f func(unfurl.Preview) string) graphql.FieldFunc {
+	return graphql.Each(func(_ context.Context, p unfurl.Preview, _ graphql.Args) (any, error) {
+		if v := f(p); v != "" {
+			return v, nil
+		}
+		return nil, nil
+	})
+}
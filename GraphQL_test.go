@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+// TestGraphQLThreadsAndFollows runs queries against the schema with the
+// stub driver of OpenAPI_test.go, so each case only needs the rows of the
+// queries its fields end up calling.
+func TestGraphQLThreadsAndFollows(t *testing.T) {
+	s, err := newGraphQLSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authorID, fanID := uuid.New(), uuid.New()
+	rootID, replyID := uuid.New(), uuid.New()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rootRow := []driver.Value{rootID.String(), now, now, "hello", authorID.String(), nil, nil, nil, nil, nil}
+	replyRow := []driver.Value{replyID.String(), now, now, "hi back", fanID.String(), nil, nil, nil, rootID.String(), rootID.String()}
+	userRow := func(id uuid.UUID) []driver.Value {
+		return []driver.Value{id.String(), now, now, "user@example.com", "hash", false, "user"}
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		rows      stubDB
+		want      string
+		wantError bool
+	}{
+		{
+			name:  "thread of a reply",
+			query: fmt.Sprintf(`{ thread(id: %q) { id replyTo { id } } }`, replyID),
+			rows: stubDB{
+				"GetVisibleChirpsByIDs": {rootRow, replyRow},
+				"GetThread":             {rootRow, replyRow},
+			},
+			want: fmt.Sprintf(`{"thread":[{"id":%q,"replyTo":null},{"id":%q,"replyTo":{"id":%q}}]}`, rootID, replyID, rootID),
+		},
+		{
+			name:  "thread of a hidden chirp",
+			query: fmt.Sprintf(`{ thread(id: %q) { id } }`, replyID),
+			want:  `{"thread":null}`,
+		},
+		{
+			name:      "thread limit out of range",
+			query:     fmt.Sprintf(`{ thread(id: %q, limit: 0) { id } }`, replyID),
+			rows:      stubDB{"GetVisibleChirpsByIDs": {replyRow}},
+			wantError: true,
+		},
+		{
+			name:  "replies",
+			query: fmt.Sprintf(`{ chirp(id: %q) { id replies(limit: 5) { id } } }`, rootID),
+			rows: stubDB{
+				"GetVisibleChirpsByIDs": {rootRow},
+				"GetRepliesByChirps":    {replyRow},
+			},
+			want: fmt.Sprintf(`{"chirp":{"id":%q,"replies":[{"id":%q}]}}`, rootID, replyID),
+		},
+		{
+			name:  "followers and following",
+			query: fmt.Sprintf(`{ user(id: %q) { followers { id } following { id } } }`, authorID),
+			rows: stubDB{
+				"GetUsersByIDs":       {userRow(authorID), userRow(fanID)},
+				"GetFollowersByUsers": {{authorID.String(), fanID.String()}},
+			},
+			want: fmt.Sprintf(`{"user":{"followers":[{"id":%q}],"following":[]}}`, fanID),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &apiConf{db: database.New(sql.OpenDB(tt.rows))}
+			doc, errs := s.Parse(tt.query)
+			if errs != nil {
+				t.Fatal(errs)
+			}
+			ctx := context.WithValue(context.Background(), graphQLRequestKey{}, cfg.newGraphQLRequest(uuid.Nil))
+			resp := s.Exec(ctx, doc, "", nil)
+
+			if tt.wantError {
+				if len(resp.Errors) == 0 {
+					t.Fatalf("no errors, data %s", resp.Data)
+				}
+				return
+			}
+			if len(resp.Errors) > 0 {
+				t.Fatal(resp.Errors)
+			}
+			if got := string(resp.Data); got != tt.want {
+				t.Errorf("data %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
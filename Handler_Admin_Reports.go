@@ -0,0 +1,284 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/database"
+	"github.com/tsironi93/WebServer/internal/pubsub"
+)
+
+const (
+	moderationDismiss     = "dismiss"
+	moderationHideChirp   = "hide_chirp"
+	moderationSuspendUser = "suspend_user"
+
+	maxModerationNoteLength = 1000
+)
+
+// ModerationAction is an entry of the append-only moderation log.
+type ModerationAction struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ModeratorID uuid.UUID  `json:"moderator_id"`
+	ReportID    *uuid.UUID `json:"report_id,omitempty"`
+	Action      string     `json:"action"`
+	TargetType  string     `json:"target_type"`
+	TargetID    uuid.UUID  `json:"target_id"`
+	Note        string     `json:"note"`
+}
+
+type resolveReport struct {
+	Action string `json:"action"`
+	Note   string `json:"note"`
+	// SuspendUntil is only used by suspend_user, null suspends for good.
+	SuspendUntil *time.Time `json:"suspend_until"`
+}
+
+func toModerationAction(a database.ModerationAction) ModerationAction {
+	resp := ModerationAction{
+		ID:          a.ID,
+		CreatedAt:   a.CreatedAt,
+		ModeratorID: a.ModeratorID,
+		Action:      a.Action,
+		TargetType:  a.TargetType,
+		TargetID:    a.TargetID,
+		Note:        a.Note,
+	}
+	if a.ReportID.Valid {
+		resp.ReportID = &a.ReportID.UUID
+	}
+	return resp
+}
+
+// HandlerAdminReportsList godoc
+// @Summary Moderation queue
+// @Description Lists reports, oldest first. Moderators and admins only.
+// @Tags moderation
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param status query string false "open (default) or resolved"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param offset query int false "Number of reports to skip"
+// @Success 200 {array} Report
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/reports [get]
+func (cfg *apiConf) HandlerAdminReportsList(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "open"
+	}
+	if status != "open" && status != "resolved" {
+		respondWithError(w, http.StatusBadRequest, "status must be open or resolved", nil)
+		return
+	}
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	reports, err := cfg.db.ListReports(r.Context(), database.ListReportsParams{
+		Status:    status,
+		RowLimit:  limit,
+		RowOffset: offset,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting reports", err)
+		return
+	}
+
+	resp := make([]Report, len(reports))
+	for i, rep := range reports {
+		resp[i] = toReport(rep)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// HandlerAdminReportsResolve godoc
+// @Summary Resolve a report
+// @Description Applies a moderation action (dismiss, hide_chirp or suspend_user) and resolves every open report about the same target. The action is written to the moderation log. Moderators and admins only, suspend_user is admin only.
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param reportID path string true "Report UUID"
+// @Param resolution body resolveReport true "Action to take"
+// @Success 200 {object} ModerationAction
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/reports/{reportID}/resolve [post]
+func (cfg *apiConf) HandlerAdminReportsResolve(w http.ResponseWriter, r *http.Request) {
+	moderator := userFromContext(r.Context())
+
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("reportID", err))
+		return
+	}
+
+	var params resolveReport
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithAPIError(w, errInvalidJSON(err))
+		return
+	}
+
+	switch params.Action {
+	case moderationDismiss, moderationHideChirp, moderationSuspendUser:
+	default:
+		respondWithError(w, http.StatusBadRequest, "action must be dismiss, hide_chirp or suspend_user", nil)
+		return
+	}
+	if len(params.Note) > maxModerationNoteLength {
+		respondWithAPIError(w, errValidation(FieldError{Field: "note", Code: codeFieldTooLong, Message: fmt.Sprintf("must be at most %d characters", maxModerationNoteLength)}))
+		return
+	}
+	if params.Action == moderationSuspendUser && !roleHas(moderator.Role, permSuspendUsers) {
+		respondWithError(w, http.StatusForbidden, "only admins can suspend users", nil)
+		return
+	}
+	if params.SuspendUntil != nil && !params.SuspendUntil.After(time.Now()) {
+		respondWithError(w, http.StatusBadRequest, "suspend_until must be in the future", nil)
+		return
+	}
+
+	tx, err := cfg.beginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't resolve report", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx.Tx)
+
+	// the row lock makes two moderators resolving the same report at once
+	// wait for each other instead of both acting
+	report, err := qtx.GetOpenReportForUpdate(r.Context(), reportID)
+	if err != nil {
+		respondWithDBError(w, err, "open report not found")
+		return
+	}
+
+	var hidden *database.Chirp
+	targetType, targetID := report.TargetType, report.TargetID
+	switch params.Action {
+	case moderationHideChirp:
+		if report.TargetType != reportTargetChirp {
+			respondWithError(w, http.StatusBadRequest, "only chirp reports can hide a chirp", nil)
+			return
+		}
+		chirp, err := qtx.HideChirp(r.Context(), report.TargetID)
+		if err == nil {
+			hidden = &chirp
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			// no rows means it is already hidden or gone, that's fine
+			respondWithError(w, http.StatusInternalServerError, "couldn't hide chirp", err)
+			return
+		}
+	case moderationSuspendUser:
+		if _, err := suspendUser(r.Context(), qtx, database.CreateUserSuspensionParams{
+			UserID:        report.ReportedUserID,
+			SuspendedBy:   moderator.ID,
+			Reason:        params.Note,
+			ExpiresInSecs: secondsUntil(params.SuspendUntil),
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "couldn't suspend user", err)
+			return
+		}
+		targetType, targetID = reportTargetUser, report.ReportedUserID
+	}
+
+	action, err := qtx.CreateModerationAction(r.Context(), database.CreateModerationActionParams{
+		ModeratorID: moderator.ID,
+		ReportID:    uuid.NullUUID{UUID: report.ID, Valid: true},
+		Action:      params.Action,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Note:        params.Note,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't log moderation action", err)
+		return
+	}
+
+	if _, err := qtx.ResolveReports(r.Context(), database.ResolveReportsParams{
+		ResolvedBy: moderator.ID,
+		Resolution: params.Action,
+		TargetType: report.TargetType,
+		TargetID:   report.TargetID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't resolve report", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't resolve report", err)
+		return
+	}
+
+	cfg.audit(r, auditEvent{
+		Type:       auditReportResolved,
+		ActorID:    moderator.ID,
+		SubjectID:  report.ReportedUserID,
+		TargetType: "report",
+		TargetID:   report.ID,
+		Details:    map[string]any{"action": params.Action},
+	})
+	if params.Action == moderationSuspendUser {
+		afterCommit(r.Context(), func() { cfg.suspensions.add(report.ReportedUserID) })
+	}
+	if hidden != nil {
+		// live timelines drop hidden chirps like deleted ones
+		cfg.publishChirpEvent(r.Context(), pubsub.EventChirpDeleted, toChirp(*hidden))
+	}
+
+	respondWithJSON(w, http.StatusOK, toModerationAction(action))
+}
+
+// HandlerAdminModerationLog godoc
+// @Summary Moderation log
+// @Description Lists moderation actions, newest first. The log is append-only. Moderators and admins only.
+// @Tags moderation
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param offset query int false "Number of entries to skip"
+// @Success 200 {array} ModerationAction
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/moderation-log [get]
+func (cfg *apiConf) HandlerAdminModerationLog(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	actions, err := cfg.db.ListModerationActions(r.Context(), database.ListModerationActionsParams{
+		RowLimit:  limit,
+		RowOffset: offset,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting moderation log", err)
+		return
+	}
+
+	resp := make([]ModerationAction, len(actions))
+	for i, a := range actions {
+		resp[i] = toModerationAction(a)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+const moderationUnsuspendUser = "unsuspend_user"
+
+type Suspension struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UserID      uuid.UUID  `json:"user_id"`
+	SuspendedBy uuid.UUID  `json:"suspended_by"`
+	Reason      string     `json:"reason"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	HideChirps  bool       `json:"hide_chirps"`
+	LiftedAt    *time.Time `json:"lifted_at,omitempty"`
+	LiftedBy    *uuid.UUID `json:"lifted_by,omitempty"`
+}
+
+type suspendUserParams struct {
+	Reason string `json:"reason"`
+	// Until is when the suspension ends, null suspends for good.
+	Until      *time.Time `json:"until"`
+	HideChirps bool       `json:"hide_chirps"`
+}
+
+func toSuspension(s database.UserSuspension) Suspension {
+	resp := Suspension{
+		ID:          s.ID,
+		CreatedAt:   s.CreatedAt,
+		UserID:      s.UserID,
+		SuspendedBy: s.SuspendedBy,
+		Reason:      s.Reason,
+		HideChirps:  s.HideChirps,
+	}
+	if s.ExpiresAt.Valid {
+		resp.ExpiresAt = &s.ExpiresAt.Time
+	}
+	if s.LiftedAt.Valid {
+		resp.LiftedAt = &s.LiftedAt.Time
+	}
+	if s.LiftedBy.Valid {
+		resp.LiftedBy = &s.LiftedBy.UUID
+	}
+	return resp
+}
+
+// HandlerAdminUserSuspend godoc
+// @Summary Suspend a user
+// @Description Suspends the user until the given time, or permanently when until is null. The user can't log in or refresh tokens, their refresh tokens are revoked and their JWTs stop working. With hide_chirps their chirps are hidden while the suspension lasts. Admins only.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param userID path string true "User UUID"
+// @Param suspension body suspendUserParams true "Reason, end time and whether to hide chirps"
+// @Success 201 {object} Suspension
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/users/{userID}/suspend [post]
+func (cfg *apiConf) HandlerAdminUserSuspend(w http.ResponseWriter, r *http.Request) {
+	admin := userFromContext(r.Context())
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("userID", err))
+		return
+	}
+
+	var params suspendUserParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithAPIError(w, errInvalidJSON(err))
+		return
+	}
+
+	if params.Reason == "" {
+		respondWithError(w, http.StatusBadRequest, "reason is required", nil)
+		return
+	}
+	if len(params.Reason) > maxModerationNoteLength {
+		respondWithError(w, http.StatusBadRequest, "reason is too long", nil)
+		return
+	}
+	if params.Until != nil && !params.Until.After(time.Now()) {
+		respondWithError(w, http.StatusBadRequest, "until must be in the future", nil)
+		return
+	}
+	if userID == admin.ID {
+		respondWithError(w, http.StatusBadRequest, "can't suspend yourself", nil)
+		return
+	}
+
+	if _, err := cfg.db.GetUserByID(r.Context(), userID); err != nil {
+		respondWithDBError(w, err, "user not found")
+		return
+	}
+
+	tx, err := cfg.beginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't suspend user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx.Tx)
+
+	suspension, err := suspendUser(r.Context(), qtx, database.CreateUserSuspensionParams{
+		UserID:        userID,
+		SuspendedBy:   admin.ID,
+		Reason:        params.Reason,
+		ExpiresInSecs: secondsUntil(params.Until),
+		HideChirps:    params.HideChirps,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't suspend user", err)
+		return
+	}
+
+	if _, err := qtx.CreateModerationAction(r.Context(), database.CreateModerationActionParams{
+		ModeratorID: admin.ID,
+		Action:      moderationSuspendUser,
+		TargetType:  reportTargetUser,
+		TargetID:    userID,
+		Note:        params.Reason,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't log moderation action", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't suspend user", err)
+		return
+	}
+	afterCommit(r.Context(), func() { cfg.suspensions.add(userID) })
+	cfg.audit(r, auditEvent{
+		Type:      auditUserSuspended,
+		ActorID:   admin.ID,
+		SubjectID: userID,
+		Details: map[string]any{
+			"reason":      params.Reason,
+			"until":       params.Until,
+			"hide_chirps": params.HideChirps,
+		},
+	})
+
+	respondWithJSON(w, http.StatusCreated, toSuspension(suspension))
+}
+
+// HandlerAdminUserUnsuspend godoc
+// @Summary Lift a suspension
+// @Description Lifts every active suspension of the user. Revoked refresh tokens stay revoked, the user has to log in again. Admins only.
+// @Tags admin
+// @Accept json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param userID path string true "User UUID"
+// @Param note body object false "{\"note\": \"appeal accepted\"}"
+// @Success 204
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/users/{userID}/unsuspend [post]
+func (cfg *apiConf) HandlerAdminUserUnsuspend(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Note string `json:"note"`
+	}
+
+	admin := userFromContext(r.Context())
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("userID", err))
+		return
+	}
+
+	// the note is optional, so an empty body is fine
+	var params parameters
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			respondWithAPIError(w, errInvalidJSON(err))
+			return
+		}
+	}
+	if len(params.Note) > maxModerationNoteLength {
+		respondWithAPIError(w, errValidation(FieldError{Field: "note", Code: codeFieldTooLong, Message: fmt.Sprintf("must be at most %d characters", maxModerationNoteLength)}))
+		return
+	}
+
+	tx, err := cfg.beginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't lift suspension", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx.Tx)
+
+	n, err := qtx.LiftUserSuspensions(r.Context(), database.LiftUserSuspensionsParams{
+		UserID:   userID,
+		LiftedBy: uuid.NullUUID{UUID: admin.ID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't lift suspension", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "user is not suspended", nil)
+		return
+	}
+
+	if _, err := qtx.CreateModerationAction(r.Context(), database.CreateModerationActionParams{
+		ModeratorID: admin.ID,
+		Action:      moderationUnsuspendUser,
+		TargetType:  reportTargetUser,
+		TargetID:    userID,
+		Note:        params.Note,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't log moderation action", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't lift suspension", err)
+		return
+	}
+	afterCommit(r.Context(), func() { cfg.suspensions.remove(userID) })
+	cfg.audit(r, auditEvent{
+		Type:      auditUserUnsuspended,
+		ActorID:   admin.ID,
+		SubjectID: userID,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerAdminUserSuspensions godoc
+// @Summary Suspension history
+// @Description Lists every suspension of the user, newest first, including expired and lifted ones. Admins only.
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param userID path string true "User UUID"
+// @Success 200 {array} Suspension
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/users/{userID}/suspensions [get]
+func (cfg *apiConf) HandlerAdminUserSuspensions(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("userID", err))
+		return
+	}
+
+	suspensions, err := cfg.db.ListUserSuspensions(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting suspensions", err)
+		return
+	}
+
+	resp := make([]Suspension, len(suspensions))
+	for i, s := range suspensions {
+		resp[i] = toSuspension(s)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+type UserRole struct {
+	UserID uuid.UUID `json:"user_id"`
+	Email  string    `json:"email"`
+	Role   string    `json:"role"`
+}
+
+// HandlerAdminUserSetRole godoc
+// @Summary Change a user's role
+// @Description Sets the role to user, moderator or admin. The last admin can't be demoted. Admins only.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param userID path string true "User UUID"
+// @Param role body object true "{\"role\": \"moderator\"}"
+// @Success 200 {object} UserRole
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/users/{userID}/role [put]
+func (cfg *apiConf) HandlerAdminUserSetRole(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Role string `json:"role"`
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("userID", err))
+		return
+	}
+
+	var params parameters
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithAPIError(w, errInvalidJSON(err))
+		return
+	}
+
+	if !slices.Contains(roles, params.Role) {
+		respondWithError(w, http.StatusBadRequest, "role must be user, moderator or admin", nil)
+		return
+	}
+
+	tx, err := cfg.beginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't change role", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx.Tx)
+
+	// Concurrent demotions wait for each other here, so they can't both
+	// count the other admin and leave none.
+	if err := qtx.LockAdmins(r.Context()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't change role", err)
+		return
+	}
+
+	target, err := qtx.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithDBError(w, err, "user not found")
+		return
+	}
+
+	if target.Role == roleAdmin && params.Role != roleAdmin {
+		admins, err := qtx.CountAdmins(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "couldn't change role", err)
+			return
+		}
+		if admins <= 1 {
+			respondWithError(w, http.StatusConflict, "can't demote the last admin", nil)
+			return
+		}
+	}
+
+	user, err := qtx.SetUserRole(r.Context(), database.SetUserRoleParams{
+		ID:   userID,
+		Role: params.Role,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't change role", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't change role", err)
+		return
+	}
+
+	cfg.audit(r, auditEvent{
+		Type:      auditRoleChanged,
+		ActorID:   userFromContext(r.Context()).ID,
+		SubjectID: user.ID,
+		Details:   map[string]any{"from": target.Role, "to": user.Role},
+	})
+
+	respondWithJSON(w, http.StatusOK, UserRole{
+		UserID: user.ID,
+		Email:  user.Email,
+		Role:   user.Role,
+	})
+}
+
+// HandlerAdminUserUnlock godoc
+// @Summary Unlock a user's login
+// @Description Clears the failed login attempts of the user's email, lifting a brute-force lockout. Lockouts of the client IP are not touched. Moderators and admins only.
+// @Tags admin
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param userID path string true "User UUID"
+// @Success 204
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/users/{userID}/unlock [post]
+func (cfg *apiConf) HandlerAdminUserUnlock(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("userID", err))
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithDBError(w, err, "user not found")
+		return
+	}
+
+	if err := cfg.clearLoginFailures(r.Context(), user.Email); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't unlock login", err)
+		return
+	}
+
+	cfg.audit(r, auditEvent{
+		Type:      auditLoginUnlocked,
+		ActorID:   userFromContext(r.Context()).ID,
+		SubjectID: user.ID,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
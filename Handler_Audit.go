@@ -0,0 +1,138 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+// HandlerAdminAudit godoc
+// @Summary Audit log
+// @Description Lists security-relevant events, newest first. All filters are optional. Admins only.
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param event_type query string false "e.g. auth.login_failed"
+// @Param actor_id query string false "User UUID of whoever did it"
+// @Param subject_id query string false "User UUID of the affected account"
+// @Param since query string false "RFC 3339 time, inclusive"
+// @Param until query string false "RFC 3339 time, exclusive"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param offset query int false "Number of events to skip"
+// @Success 200 {array} AuditEvent
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/audit [get]
+func (cfg *apiConf) HandlerAdminAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	params := database.ListAuditEventsParams{
+		EventType: q.Get("event_type"),
+		Until:     time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC),
+		RowLimit:  limit,
+		RowOffset: offset,
+	}
+
+	for name, dst := range map[string]*uuid.UUID{
+		"actor_id":   &params.ActorID,
+		"subject_id": &params.SubjectID,
+	} {
+		if s := q.Get(name); s != "" {
+			id, err := uuid.Parse(s)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, name+" must be a uuid", err)
+				return
+			}
+			*dst = id
+		}
+	}
+
+	for name, dst := range map[string]*time.Time{
+		"since": &params.Since,
+		"until": &params.Until,
+	} {
+		if s := q.Get(name); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, name+" must be an RFC 3339 time", err)
+				return
+			}
+			*dst = t.UTC()
+		}
+	}
+
+	events, err := cfg.db.ListAuditEvents(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting audit log", err)
+		return
+	}
+
+	resp := make([]AuditEvent, len(events))
+	for i, e := range events {
+		resp[i] = toAuditEvent(e)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// HandlerUserSecurityLog godoc
+// @Summary Own security log
+// @Description Lists the security events of the authenticated user's account (logins, token use, password changes, moderation), newest first. Staff members acting on the account are not named.
+// @Tags users, auth
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param offset query int false "Number of events to skip"
+// @Success 200 {array} AuditEvent
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/users/me/security-log [get]
+func (cfg *apiConf) HandlerUserSecurityLog(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	events, err := cfg.db.ListUserSecurityEvents(r.Context(), database.ListUserSecurityEventsParams{
+		SubjectID: user,
+		RowLimit:  limit,
+		RowOffset: offset,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting security log", err)
+		return
+	}
+
+	resp := make([]AuditEvent, len(events))
+	for i, e := range events {
+		resp[i] = toAuditEvent(e)
+		if resp[i].ActorID != nil && *resp[i].ActorID != user {
+			resp[i].ActorID = nil
+		}
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
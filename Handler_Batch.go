@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxBatchRequests  = 20
+	maxBatchBodyBytes = 1 << 20
+)
+
+// batchMethods are the methods a sub-request may use.
+var batchMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodDelete: true,
+}
+
+// batchExcluded routes can't be part of a batch: streams never end, and
+// batches don't nest.
+var batchExcluded = map[string]bool{
+	"GET /api/chirps/stream": true,
+	"GET /api/ws":            true,
+	"POST /api/batch":        true,
+}
+
+type batchParams struct {
+	Requests []batchRequest `json:"requests"`
+	// Atomic runs the requests in one transaction that is rolled back when
+	// one of them fails.
+	Atomic bool `json:"atomic,omitempty"`
+}
+
+type batchRequest struct {
+	Method string `json:"method" example:"GET"`
+	// Path is the path and query, e.g. /api/chirps?author_id=...
+	Path    string            `json:"path" example:"/api/chirps"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty" swaggertype:"object"`
+}
+
+// BatchResponse is the response to one sub-request. JSON bodies are
+// embedded as is, other bodies as a string.
+type BatchResponse struct {
+	Status  int               `json:"status" example:"200"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty" swaggertype:"object"`
+}
+
+// HandlerBatch godoc
+// @Summary Run several requests in one round trip
+// @Description Runs up to 20 sub-requests in order through the same routes and middleware as separate requests, and returns their responses in the same order. Sub-requests inherit the Authorization, User-Agent and Accept headers unless they set their own. With "atomic": true the database changes of all sub-requests are made in one transaction: if one fails (status 400 or above), everything is rolled back, and the other responses are replaced with 424 failed_dependency. Events, notifications and link previews of an atomic batch only go out once it commits. Streams and nested batches are not allowed.
+// @Tags batch
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "Bearer <JWT token>"
+// @Param batch body batchParams true "Sub-requests"
+// @Success 200 {array} BatchResponse
+// @Failure 400 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/batch [post]
+func (cfg *apiConf) HandlerBatch(w http.ResponseWriter, r *http.Request) {
+	var params batchParams
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)).Decode(&params); err != nil {
+		respondWithAPIError(w, errInvalidJSON(err))
+		return
+	}
+
+	subs, err := cfg.batchSubRequests(r, params.Requests)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	requestID := requestIDFromContext(r.Context())
+	responses := make([]BatchResponse, len(subs))
+	if !params.Atomic {
+		for i, sub := range subs {
+			responses[i] = cfg.dispatchBatch(sub, batchRequestID(requestID, i))
+		}
+		respondWithJSON(w, http.StatusOK, responses)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't start the batch", err)
+		return
+	}
+	defer tx.Rollback()
+	btx := &batchTx{tx: tx}
+
+	failed := -1
+	for i, sub := range subs {
+		responses[i] = cfg.dispatchBatch(sub.WithContext(withBatchTx(sub.Context(), btx)), batchRequestID(requestID, i))
+		if responses[i].Status >= 400 {
+			failed = i
+			break
+		}
+	}
+
+	if failed >= 0 {
+		for i := range responses {
+			switch {
+			case i < failed:
+				responses[i] = failedDependency(batchRequestID(requestID, i), fmt.Sprintf("rolled back because requests[%d] failed", failed))
+			case i > failed:
+				responses[i] = failedDependency(batchRequestID(requestID, i), fmt.Sprintf("not run because requests[%d] failed", failed))
+			}
+		}
+		respondWithJSON(w, http.StatusOK, responses)
+		return
+	}
+
+	if err := btx.commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't commit the batch", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, responses)
+}
+
+// batchSubRequests checks the sub-requests and turns them into requests
+// that look like they came from the client of r.
+func (cfg *apiConf) batchSubRequests(r *http.Request, reqs []batchRequest) ([]*http.Request, error) {
+	if len(reqs) == 0 || len(reqs) > maxBatchRequests {
+		return nil, errValidation(FieldError{Field: "requests", Code: codeFieldRange, Message: fmt.Sprintf("must have 1 to %d items", maxBatchRequests)})
+	}
+
+	subs := make([]*http.Request, len(reqs))
+	for i, req := range reqs {
+		field := fmt.Sprintf("requests[%d]", i)
+		if !batchMethods[req.Method] {
+			return nil, errValidation(FieldError{Field: field + ".method", Code: codeFieldInvalid, Message: "must be GET, POST, PUT or DELETE"})
+		}
+		u, err := url.ParseRequestURI(req.Path)
+		if err != nil || !strings.HasPrefix(req.Path, "/") || u.Host != "" {
+			return nil, errValidation(FieldError{Field: field + ".path", Code: codeFieldInvalid, Message: "must be a path like /api/chirps"})
+		}
+
+		var body io.Reader = http.NoBody
+		if len(req.Body) > 0 && string(req.Body) != "null" {
+			body = bytes.NewReader(req.Body)
+		}
+		sub, err := http.NewRequestWithContext(r.Context(), req.Method, req.Path, body)
+		if err != nil {
+			return nil, errValidation(FieldError{Field: field, Code: codeFieldInvalid, Message: err.Error()})
+		}
+		sub.Host = r.Host
+		sub.RemoteAddr = r.RemoteAddr
+		sub.RequestURI = req.Path
+		for _, name := range []string{"Authorization", "User-Agent", "Accept"} {
+			if v := r.Header.Get(name); v != "" {
+				sub.Header.Set(name, v)
+			}
+		}
+		for name, v := range req.Headers {
+			sub.Header.Set(name, v)
+		}
+		if body != http.NoBody && sub.Header.Get("Content-Type") == "" {
+			sub.Header.Set("Content-Type", "application/json")
+		}
+
+		if batchExcluded[cfg.batchPattern(sub)] {
+			return nil, errValidation(FieldError{Field: field + ".path", Code: codeFieldInvalid, Message: "can't be part of a batch"})
+		}
+		subs[i] = sub
+	}
+	return subs, nil
+}
+
+// batchPattern is the route that serves sub, whatever API version its path
+// asks for.
+func (cfg *apiConf) batchPattern(sub *http.Request) string {
+	path := sub.URL.Path
+	for _, prefix := range []string{"/api/v1/", "/api/v2/"} {
+		if rest, ok := strings.CutPrefix(path, prefix); ok {
+			path = "/api/" + rest
+		}
+	}
+	_, pattern := cfg.mux.Handler(&http.Request{Method: sub.Method, URL: &url.URL{Path: path}, Host: sub.Host, Header: sub.Header})
+	return v1Pattern(pattern)
+}
+
+// dispatchBatch serves one sub-request through the middleware and the mux
+// and records its response.
+func (cfg *apiConf) dispatchBatch(sub *http.Request, requestID string) BatchResponse {
+	rec := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+	rec.header.Set("X-Request-ID", requestID)
+	cfg.dispatch.ServeHTTP(rec, sub.WithContext(context.WithValue(sub.Context(), requestIDKey{}, requestID)))
+
+	resp := BatchResponse{Status: rec.status, Headers: make(map[string]string, len(rec.header))}
+	for name, values := range rec.header {
+		resp.Headers[name] = strings.Join(values, ", ")
+	}
+
+	body := rec.body.Bytes()
+	switch {
+	case len(body) == 0:
+	case isJSON(rec.header.Get("Content-Type")) && json.Valid(body):
+		resp.Body = body
+	default:
+		resp.Body, _ = json.Marshal(string(body))
+	}
+	return resp
+}
+
+func failedDependency(requestID, detail string) BatchResponse {
+	body, _ := json.Marshal(Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(http.StatusFailedDependency),
+		Status:    http.StatusFailedDependency,
+		Detail:    detail,
+		Code:      codeFailedDependency,
+		RequestID: requestID,
+	})
+	return BatchResponse{
+		Status:  http.StatusFailedDependency,
+		Headers: map[string]string{"Content-Type": "application/problem+json", "X-Request-Id": requestID},
+		Body:    body,
+	}
+}
+
+// batchRequestID is the ID of requests[i], derived from the batch's so the
+// logs of a batch can be found together.
+func batchRequestID(batchID string, i int) string {
+	return batchID + "-" + strconv.Itoa(i+1)
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+// BookmarkedChirp is a chirp in the user's private bookmark list.
+type BookmarkedChirp struct {
+	Chirp
+	BookmarkedAt time.Time `json:"bookmarked_at"`
+}
+
+// HandlerChirpsBookmark godoc
+// @Summary Bookmark a chirp
+// @Description Bookmarks are private, the author is not notified. Bookmarking twice is a no-op.
+// @Tags chirps
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param chirpID path string true "Chirp UUID"
+// @Success 204
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/chirps/{chirpID}/bookmark [post]
+func (cfg *apiConf) HandlerChirpsBookmark(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("chirpID", err))
+		return
+	}
+
+	if _, err := cfg.db.GetVisibleChirp(r.Context(), database.GetVisibleChirpParams{
+		ID:       chirpID,
+		ViewerID: user,
+	}); err != nil {
+		respondWithDBError(w, err, "chirp not in the database")
+		return
+	}
+
+	if err := cfg.db.CreateBookmark(r.Context(), database.CreateBookmarkParams{
+		UserID:  user,
+		ChirpID: chirpID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't bookmark chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerChirpsUnbookmark godoc
+// @Summary Remove a bookmark
+// @Tags chirps
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param chirpID path string true "Chirp UUID"
+// @Success 204
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/chirps/{chirpID}/bookmark [delete]
+func (cfg *apiConf) HandlerChirpsUnbookmark(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("chirpID", err))
+		return
+	}
+
+	if err := cfg.db.DeleteBookmark(r.Context(), database.DeleteBookmarkParams{
+		UserID:  user,
+		ChirpID: chirpID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't remove bookmark", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerUserBookmarksList godoc
+// @Summary List bookmarked chirps
+// @Description Returns the authenticated user's bookmarks, most recent first.
+// @Tags chirps
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param offset query int false "Number of bookmarks to skip"
+// @Success 200 {array} BookmarkedChirp
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/users/me/bookmarks [get]
+func (cfg *apiConf) HandlerUserBookmarksList(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	rows, err := cfg.db.ListBookmarkedChirps(r.Context(), database.ListBookmarkedChirpsParams{
+		UserID:    user,
+		RowLimit:  limit,
+		RowOffset: offset,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting bookmarks", err)
+		return
+	}
+
+	chirps := make([]Chirp, len(rows))
+	for i, c := range rows {
+		chirps[i] = toChirp(database.Chirp{
+			ID:            c.ID,
+			CreatedAt:     c.CreatedAt,
+			UpdatedAt:     c.UpdatedAt,
+			Body:          c.Body,
+			UserID:        c.UserID,
+			DeletedAt:     c.DeletedAt,
+			QuotedChirpID: c.QuotedChirpID,
+			ReplyToID:     c.ReplyToID,
+			ThreadID:      c.ThreadID,
+		})
+	}
+	if err := cfg.enrichChirps(r.Context(), chirps, user); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting bookmarks", err)
+		return
+	}
+
+	resp := make([]BookmarkedChirp, len(rows))
+	for i, c := range rows {
+		resp[i] = BookmarkedChirp{
+			Chirp:        chirps[i],
+			BookmarkedAt: c.BookmarkedAt,
+		}
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
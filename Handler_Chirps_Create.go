@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -10,6 +12,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/tsironi93/WebServer/internal/auth"
 	"github.com/tsironi93/WebServer/internal/database"
+	"github.com/tsironi93/WebServer/internal/pubsub"
+	"github.com/tsironi93/WebServer/internal/unfurl"
 )
 
 type Chirp struct {
@@ -18,80 +22,219 @@ type Chirp struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	Body      string    `json:"body"`
 	UserID    uuid.UUID `json:"user_id"`
+	Poll      *Poll     `json:"poll,omitempty"`
+	Pinned    bool      `json:"pinned,omitempty"`
+	// QuotedChirp is left out when the quoted chirp was deleted or its
+	// author blocked the viewer, QuotedChirpID is always kept.
+	QuotedChirpID *uuid.UUID       `json:"quoted_chirp_id,omitempty"`
+	QuotedChirp   *Chirp           `json:"quoted_chirp,omitempty"`
+	LinkPreviews  []unfurl.Preview `json:"link_previews,omitempty"`
+	// ReplyToID is the chirp this one answers, ThreadID the first chirp of
+	// the thread. Both are unset for chirps that aren't replies.
+	ReplyToID *uuid.UUID `json:"reply_to_id,omitempty"`
+	ThreadID  *uuid.UUID `json:"thread_id,omitempty"`
 }
 
 type Params struct {
-	Body   string `json:"body"`
-	UserID string `json:"user_id"`
+	Body      string      `json:"body"`
+	UserID    string      `json:"user_id"`
+	PublishAt *time.Time  `json:"publish_at,omitempty"`
+	Draft     bool        `json:"draft,omitempty"`
+	Poll      *pollParams `json:"poll,omitempty"`
+	// QuotedChirpID turns the chirp into a quote of another chirp.
+	QuotedChirpID *uuid.UUID `json:"quoted_chirp_id,omitempty"`
+	// ReplyToID turns the chirp into a reply in the other chirp's thread.
+	ReplyToID *uuid.UUID `json:"reply_to_id,omitempty"`
 }
 
 // HandlerCreateChirp godoc
 // @Summary Create a new chirp
-// @Description Creates a new chirp for the authenticated user. Requires a valid Bearer JWT token.
+// @Description Creates a new chirp for the authenticated user. Requires a valid Bearer JWT token. With "draft": true the chirp is saved as a draft, with a future "publish_at" it is scheduled; both return 202 with the draft. A "poll" with 2-4 options and an expires_at, a "quoted_chirp_id" or a "reply_to_id" can be attached to chirps that are published right away. Links in the body get preview cards once they have been fetched.
 // @Tags chirps
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer <JWT token>"
 // @Param chirp body Params true "Chirp payload"
+// @Param Idempotency-Key header string false "Unique key to make retries safe"
 // @Success 201 {object} Chirp
-// @Failure 400 {object} map[string]string "Bad request (invalid body)"
-// @Failure 401 {object} map[string]string "Unauthorized (missing or invalid token)"
-// @Failure 500 {object} map[string]string "Internal server error"
+// @Success 202 {object} ChirpDraft "Saved as draft or scheduled"
+// @Failure 400 {object} Problem "Bad request (invalid body)"
+// @Failure 401 {object} Problem "Unauthorized (missing or invalid token)"
+// @Failure 500 {object} Problem "Internal server error"
 // @Router /api/chirps [post]
 func (cfg *apiConf) HandlerChirpsCreate(w http.ResponseWriter, r *http.Request) {
 	tokenStr, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "missing or invalid token", err)
+		respondWithAPIError(w, errMissingToken(err))
 		return
 	}
 
-	userID, err := auth.ValidateJWT(tokenStr, cfg.JWTSecret)
+	userID, err := cfg.validateJWT(tokenStr)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+		respondWithAPIError(w, errInvalidToken(err))
 		return
 	}
 
 	var p Params
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		respondWithAPIError(w, errInvalidJSON(err))
 		return
 	}
 
 	cleaned, err := validateChirp(p.Body)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Chirp is too long", err)
+		respondWithAPIError(w, err)
 		return
 	}
 
-	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
+	if (p.Poll != nil || p.QuotedChirpID != nil || p.ReplyToID != nil) && (p.Draft || p.PublishAt != nil) {
+		respondWithError(w, http.StatusBadRequest, "polls, quotes and replies can't be attached to drafts or scheduled chirps", nil)
+		return
+	}
+
+	if p.Draft || p.PublishAt != nil {
+		if p.PublishAt != nil && !p.PublishAt.After(time.Now()) {
+			respondWithAPIError(w, errValidation(FieldError{Field: "publish_at", Code: codeFieldRange, Message: "must be in the future"}))
+			return
+		}
+
+		draft, err := cfg.db.CreateChirpDraft(r.Context(), database.CreateChirpDraftParams{
+			UserID:        userID,
+			Body:          cleaned,
+			PublishInSecs: secondsUntil(p.PublishAt),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't save draft", err)
+			return
+		}
+
+		respondWithJSON(w, http.StatusAccepted, toChirpDraft(draft))
+		return
+	}
+
+	params := database.CreateChirpParams{
 		Body:   cleaned,
 		UserID: userID,
-	})
+	}
+	if p.QuotedChirpID != nil {
+		if _, err := cfg.db.GetVisibleChirp(r.Context(), database.GetVisibleChirpParams{
+			ID:       *p.QuotedChirpID,
+			ViewerID: userID,
+		}); err != nil {
+			respondWithError(w, http.StatusBadRequest, "quoted chirp not found", err)
+			return
+		}
+		params.QuotedChirpID = uuid.NullUUID{UUID: *p.QuotedChirpID, Valid: true}
+	}
+	if p.ReplyToID != nil {
+		if err := cfg.setReplyTo(r.Context(), &params, *p.ReplyToID); err != nil {
+			respondWithAPIError(w, err)
+			return
+		}
+	}
+
+	if p.Poll != nil {
+		cfg.createChirpWithPoll(w, r, params, *p.Poll)
+		return
+	}
+
+	chirp, err := cfg.db.CreateChirp(r.Context(), params)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
+	resp := []Chirp{cfg.chirpCreated(r.Context(), chirp)}
+	if err := cfg.attachQuotes(r.Context(), resp, userID); err != nil {
+		log.Println("couldn't load quoted chirp:", err)
+	}
+	respondWithJSON(w, http.StatusCreated, resp[0])
+}
+
+func (cfg *apiConf) createChirpWithPoll(w http.ResponseWriter, r *http.Request, chirpParams database.CreateChirpParams, params pollParams) {
+	labels, err := validatePoll(params)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	tx, err := cfg.beginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx.Tx)
+
+	chirp, err := qtx.CreateChirp(r.Context(), chirpParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
+		return
+	}
+
+	poll, err := createPoll(r.Context(), qtx, chirp.ID, labels, time.Until(params.ExpiresAt))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create poll", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
+		return
+	}
+
+	resp := []Chirp{cfg.chirpCreated(r.Context(), chirp)}
+	resp[0].Poll = poll
+	if err := cfg.attachQuotes(r.Context(), resp, chirp.UserID); err != nil {
+		log.Println("couldn't load quoted chirp:", err)
+	}
+	respondWithJSON(w, http.StatusCreated, resp[0])
+}
+
+// chirpCreated fans a freshly stored chirp out to stream subscribers, the
+// notifier and the link previewer, and returns its API representation.
+func (cfg *apiConf) chirpCreated(ctx context.Context, chirp database.Chirp) Chirp {
+	resp := toChirp(chirp)
+	cfg.publishChirpEvent(ctx, pubsub.EventChirpCreated, resp)
+	afterCommit(ctx, func() {
+		cfg.notifier.chirpCreated(chirp)
+		cfg.previews.chirpCreated(chirp)
 	})
+	return resp
+}
+
+func toChirp(c database.Chirp) Chirp {
+	resp := Chirp{
+		ID:        c.ID,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		Body:      c.Body,
+		UserID:    c.UserID,
+	}
+	if c.QuotedChirpID.Valid {
+		resp.QuotedChirpID = &c.QuotedChirpID.UUID
+	}
+	if c.ReplyToID.Valid {
+		resp.ReplyToID = &c.ReplyToID.UUID
+	}
+	if c.ThreadID.Valid {
+		resp.ThreadID = &c.ThreadID.UUID
+	}
+	return resp
+}
+
+var badWords = map[string]struct{}{
+	"kerfuffle": {},
+	"sharbert":  {},
+	"fornax":    {},
 }
 
 func validateChirp(body string) (string, error) {
 	const maxChirpLength = 140
 	if len(body) > maxChirpLength {
-		return "", errors.New("Chirp is too long")
+		return "", errValidation(FieldError{Field: "body", Code: codeFieldTooLong, Message: fmt.Sprintf("must be at most %d characters", maxChirpLength)})
 	}
 
-	badWords := map[string]struct{}{
-		"kerfuffle": {},
-		"sharbert":  {},
-		"fornax":    {},
-	}
 	cleaned := getCleanedBody(body, badWords)
 	return cleaned, nil
 }
@@ -1,48 +1,53 @@
 package main
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+	"github.com/tsironi93/WebServer/internal/pubsub"
 )
 
 // HandlerChirpsDelete godoc
 // @Summary Delete a chirp
-// @Description Deletes a chirp by UUID. Requires Bearer JWT token of the owner.
+// @Description Moves a chirp to the owner's trash. It can be restored within the retention window, after which it is purged. Requires Bearer JWT token of the owner. With If-Match the chirp is only deleted if it still has that ETag.
 // @Tags chirps
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer <JWT token>"
 // @Param chirpID path string true "Chirp UUID"
+// @Param If-Match header string false "ETag from GET /api/chirps/{chirpID}"
 // @Success 204
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 412 {object} Problem "The chirp changed since the ETag was taken"
 // @Router /api/chirps/{chirpID} [delete]
 func (cfg *apiConf) HandlerChirpsDelete(w http.ResponseWriter, r *http.Request) {
 	bearer, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "no bearer header", err)
+		respondWithAPIError(w, errMissingToken(err))
 		return
 	}
 
-	user, err := auth.ValidateJWT(bearer, cfg.JWTSecret)
+	user, err := cfg.validateJWT(bearer)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "invalid JWT", err)
+		respondWithAPIError(w, errInvalidToken(err))
 		return
 	}
 
 	uuidString := r.PathValue("chirpID")
 	chirpUUID, err := uuid.Parse(uuidString)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed parsing uuid", err)
+		respondWithAPIError(w, errInvalidID("chirpID", err))
 		return
 	}
 
 	chirp, err := cfg.db.GetSingleChirp(r.Context(), chirpUUID)
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "chirp not in the database", err)
+		respondWithDBError(w, err, "chirp not in the database")
 		return
 	}
 
@@ -51,10 +56,53 @@ func (cfg *apiConf) HandlerChirpsDelete(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := cfg.db.DeleteSingleChirp(r.Context(), chirp.ID); err != nil {
-		respondWithError(w, http.StatusNotFound, "Error deleting chirp", err)
+	if r.Header.Get("If-Match") != "" {
+		etag, err := cfg.chirpETag(r.Context(), chirp, user)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "couldn't check If-Match", err)
+			return
+		}
+		if !ifMatch(r, etag) {
+			respondWithError(w, http.StatusPreconditionFailed, "chirp has changed", nil)
+			return
+		}
+	}
+
+	if err := cfg.trashChirp(r.Context(), callerFromRequest(r), chirp); err != nil {
+		respondWithAPIError(w, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusNoContent, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// trashChirp soft deletes a chirp on behalf of its author. A chirp that was
+// deleted since it was loaded is not found.
+func (cfg *apiConf) trashChirp(ctx context.Context, c caller, chirp database.Chirp) error {
+	n, err := cfg.db.SoftDeleteChirp(ctx, chirp.ID)
+	if err != nil {
+		return statusError(http.StatusInternalServerError, "couldn't delete chirp", err)
+	}
+	if n == 0 {
+		return statusError(http.StatusNotFound, "chirp not in the database", nil)
+	}
+
+	cfg.auditCaller(ctx, c, auditEvent{
+		Type:       auditChirpDeleted,
+		ActorID:    chirp.UserID,
+		SubjectID:  chirp.UserID,
+		TargetType: "chirp",
+		TargetID:   chirp.ID,
+	})
+	cfg.publishChirpEvent(ctx, pubsub.EventChirpDeleted, toChirp(chirp))
+	return nil
+}
+
+// chirpETag is the ETag viewer gets from GET /api/chirps/{chirpID}.
+func (cfg *apiConf) chirpETag(ctx context.Context, chirp database.Chirp, viewer uuid.UUID) (string, error) {
+	resp := []Chirp{toChirp(chirp)}
+	if err := cfg.enrichChirps(ctx, resp, viewer); err != nil {
+		return "", err
+	}
+	return representationETag(resp[0])
 }
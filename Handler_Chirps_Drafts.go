@@ -0,0 +1,387 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+// ChirpDraft is an unpublished chirp. Drafts without PublishAt wait for the
+// author, scheduled ones are published by the scheduler job.
+type ChirpDraft struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Body      string     `json:"body"`
+	UserID    uuid.UUID  `json:"user_id"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+type updateChirpDraft struct {
+	Body      string     `json:"body"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+func toChirpDraft(d database.ChirpDraft) ChirpDraft {
+	resp := ChirpDraft{
+		ID:        d.ID,
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+		Body:      d.Body,
+		UserID:    d.UserID,
+	}
+	if d.PublishAt.Valid {
+		resp.PublishAt = &d.PublishAt.Time
+	}
+	return resp
+}
+
+// secondsUntil is how far t is from now, or NULL for a nil t. Queries add it
+// to NOW() rather than storing t, since the TIMESTAMP columns hold the
+// database's local time.
+func secondsUntil(t *time.Time) sql.NullFloat64 {
+	if t == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: time.Until(*t).Seconds(), Valid: true}
+}
+
+// HandlerChirpDraftsList godoc
+// @Summary List drafts and scheduled chirps
+// @Description Returns the authenticated user's unpublished chirps. Optional query param status (draft|scheduled).
+// @Tags chirps
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param status query string false "draft or scheduled"
+// @Success 200 {array} ChirpDraft
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/drafts [get]
+func (cfg *apiConf) HandlerChirpDraftsList(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && status != "draft" && status != "scheduled" {
+		respondWithError(w, http.StatusBadRequest, "status must be draft or scheduled", nil)
+		return
+	}
+
+	drafts, err := cfg.db.ListChirpDrafts(r.Context(), database.ListChirpDraftsParams{
+		UserID: user,
+		Status: status,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting drafts", err)
+		return
+	}
+
+	resp := make([]ChirpDraft, len(drafts))
+	for i, d := range drafts {
+		resp[i] = toChirpDraft(d)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// HandlerChirpDraftsGet godoc
+// @Summary Get a draft or scheduled chirp
+// @Description Responses carry a strong ETag to use with If-Match when editing or discarding the draft, and for If-None-Match.
+// @Tags chirps
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param draftID path string true "Draft UUID"
+// @Param If-None-Match header string false "ETag of the cached copy"
+// @Param If-Modified-Since header string false "Last-Modified of the cached copy"
+// @Success 200 {object} ChirpDraft
+// @Success 304
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /api/drafts/{draftID} [get]
+func (cfg *apiConf) HandlerChirpDraftsGet(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("draftID", err))
+		return
+	}
+
+	draft, err := cfg.db.GetChirpDraft(r.Context(), database.GetChirpDraftParams{
+		ID:     draftID,
+		UserID: user,
+	})
+	if err != nil {
+		respondWithDBError(w, err, "draft not found")
+		return
+	}
+
+	respondWithJSONConditional(w, r, toChirpDraft(draft), draft.UpdatedAt)
+}
+
+// checkDraftIfMatch locks the draft for the rest of the transaction and
+// checks the request's If-Match against it. A non-zero code is the error
+// response to send.
+func checkDraftIfMatch(r *http.Request, qtx *database.Queries, draftID, user uuid.UUID) (int, string, error) {
+	if r.Header.Get("If-Match") == "" {
+		return 0, "", nil
+	}
+
+	current, err := qtx.GetChirpDraftForUpdate(r.Context(), database.GetChirpDraftForUpdateParams{
+		ID:     draftID,
+		UserID: user,
+	})
+	if err != nil {
+		return http.StatusNotFound, "draft not found", err
+	}
+
+	etag, err := representationETag(toChirpDraft(current))
+	if err != nil {
+		return http.StatusInternalServerError, "couldn't check If-Match", err
+	}
+	if !ifMatch(r, etag) {
+		return http.StatusPreconditionFailed, "draft has changed", nil
+	}
+	return 0, "", nil
+}
+
+// HandlerChirpDraftsUpdate godoc
+// @Summary Edit a draft or scheduled chirp
+// @Description Replaces the body and schedule. A null publish_at turns a scheduled chirp back into a draft. With If-Match the draft is only changed if it still has that ETag, so two devices can't overwrite each other's edits.
+// @Tags chirps
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param draftID path string true "Draft UUID"
+// @Param draft body updateChirpDraft true "Draft payload"
+// @Param If-Match header string false "ETag from GET /api/drafts/{draftID}"
+// @Success 200 {object} ChirpDraft
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 412 {object} Problem "The draft changed since the ETag was taken"
+// @Failure 500 {object} Problem
+// @Router /api/drafts/{draftID} [put]
+func (cfg *apiConf) HandlerChirpDraftsUpdate(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("draftID", err))
+		return
+	}
+
+	var params updateChirpDraft
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithAPIError(w, errInvalidJSON(err))
+		return
+	}
+
+	cleaned, err := validateChirp(params.Body)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	if params.PublishAt != nil && !params.PublishAt.After(time.Now()) {
+		respondWithAPIError(w, errValidation(FieldError{Field: "publish_at", Code: codeFieldRange, Message: "must be in the future"}))
+		return
+	}
+
+	tx, err := cfg.beginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't update draft", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx.Tx)
+
+	if code, msg, err := checkDraftIfMatch(r, qtx, draftID, user); code != 0 {
+		respondWithError(w, code, msg, err)
+		return
+	}
+
+	draft, err := qtx.UpdateChirpDraft(r.Context(), database.UpdateChirpDraftParams{
+		ID:            draftID,
+		UserID:        user,
+		Body:          cleaned,
+		PublishInSecs: secondsUntil(params.PublishAt),
+	})
+	if err != nil {
+		respondWithDBError(w, err, "draft not found")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't update draft", err)
+		return
+	}
+
+	resp := toChirpDraft(draft)
+	if etag, err := representationETag(resp); err == nil {
+		w.Header().Set("ETag", etag)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// HandlerChirpDraftsDelete godoc
+// @Summary Discard a draft or cancel a scheduled chirp
+// @Description With If-Match the draft is only discarded if it still has that ETag.
+// @Tags chirps
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param draftID path string true "Draft UUID"
+// @Param If-Match header string false "ETag from GET /api/drafts/{draftID}"
+// @Success 204
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 412 {object} Problem "The draft changed since the ETag was taken"
+// @Failure 500 {object} Problem
+// @Router /api/drafts/{draftID} [delete]
+func (cfg *apiConf) HandlerChirpDraftsDelete(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("draftID", err))
+		return
+	}
+
+	tx, err := cfg.beginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't delete draft", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx.Tx)
+
+	if code, msg, err := checkDraftIfMatch(r, qtx, draftID, user); code != 0 {
+		respondWithError(w, code, msg, err)
+		return
+	}
+
+	if _, err := qtx.DeleteChirpDraft(r.Context(), database.DeleteChirpDraftParams{
+		ID:     draftID,
+		UserID: user,
+	}); err != nil {
+		respondWithDBError(w, err, "draft not found")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't delete draft", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerChirpDraftsPublish godoc
+// @Summary Publish a draft now
+// @Tags chirps
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param draftID path string true "Draft UUID"
+// @Success 201 {object} Chirp
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/drafts/{draftID}/publish [post]
+func (cfg *apiConf) HandlerChirpDraftsPublish(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("draftID", err))
+		return
+	}
+
+	tx, err := cfg.beginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't publish draft", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx.Tx)
+
+	draft, err := qtx.DeleteChirpDraft(r.Context(), database.DeleteChirpDraftParams{
+		ID:     draftID,
+		UserID: user,
+	})
+	if err != nil {
+		respondWithDBError(w, err, "draft not found")
+		return
+	}
+
+	chirp, err := qtx.CreateChirp(r.Context(), database.CreateChirpParams{
+		Body:   draft.Body,
+		UserID: draft.UserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't publish draft", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, cfg.chirpCreated(r.Context(), chirp))
+}
@@ -1,23 +1,30 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"sort"
 
 	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
 )
 
 // HandlerChirpsGetAll godoc
 // @Summary List chirps
-// @Description Returns a list of chirps. Optional query params: author_id (UUID) and sort (asc|desc).
+// @Description Returns a list of chirps. Optional query params: author_id (UUID) and sort (asc|desc). With a Bearer JWT, chirps from blocked and muted users are left out; an invalid or expired token is treated as none. When author_id is given, the author's pinned chirps come first. Responses carry a strong ETag and Last-Modified for conditional requests; Last-Modified follows the chirps, their polls, pins, quoted chirps and link previews, If-None-Match also catches blocks, mutes and suspensions.
 // @Tags chirps
 // @Accept json
 // @Produce json
+// @Param Authorization header string false "Bearer <JWT token>"
 // @Param author_id query string false "Author UUID"
 // @Param sort query string false "Sort order (asc|desc)"
+// @Param If-None-Match header string false "ETag of the cached copy"
+// @Param If-Modified-Since header string false "Last-Modified of the cached copy"
 // @Success 200 {array} Chirp
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 304
+// @Failure 400 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /api/chirps [get]
 func (cfg *apiConf) HandlerChirpsGetAll(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -32,33 +39,128 @@ func (cfg *apiConf) HandlerChirpsGetAll(w http.ResponseWriter, r *http.Request)
 	if s := r.URL.Query().Get("author_id"); s != "" {
 		id, err := uuid.Parse(s)
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "couldn't parse author_id", err)
+			respondWithAPIError(w, errInvalidID("author_id", err))
 			return
 		}
 		authorID = id
 	}
 
-	chirps, err := cfg.db.GetChirps(ctx, authorID)
+	viewer := cfg.viewerFromRequest(r)
+
+	resp, err := cfg.listChirps(ctx, authorID, viewer, isDesc)
 	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+	if err := cfg.enrichChirps(ctx, resp, viewer); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "error getting chirps", err)
 		return
 	}
 
+	lastModified, err := cfg.db.GetChirpsLastModified(ctx, database.GetChirpsLastModifiedParams{
+		AuthorID: authorID,
+		Urls:     previewURLs(resp),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting chirps", err)
+		return
+	}
+	respondWithJSONConditional(w, r, resp, lastModified)
+}
+
+// listChirps returns the chirps the viewer may see, of one author or of
+// everyone when authorID is uuid.Nil. An author's pinned chirps come first.
+// Call enrichChirps on the chirps that are returned to the viewer.
+func (cfg *apiConf) listChirps(ctx context.Context, authorID, viewer uuid.UUID, desc bool) ([]Chirp, error) {
+	chirps, err := cfg.db.GetChirps(ctx, database.GetChirpsParams{
+		AuthorID: authorID,
+		ViewerID: viewer,
+	})
+	if err != nil {
+		return nil, statusError(http.StatusInternalServerError, "error getting chirps", err)
+	}
+
 	resp := make([]Chirp, len(chirps))
 	for i, c := range chirps {
-		resp[i] = Chirp{
-			ID:        c.ID,
-			CreatedAt: c.CreatedAt,
-			UpdatedAt: c.UpdatedAt,
-			Body:      c.Body,
-			UserID:    c.UserID,
-		}
+		resp[i] = toChirp(c)
 	}
 
-	if isDesc {
+	if desc {
 		sort.Slice(resp, func(i, j int) bool {
 			return resp[j].CreatedAt.Before(resp[i].CreatedAt)
 		})
 	}
-	respondWithJSON(w, http.StatusOK, resp)
+
+	if authorID != uuid.Nil {
+		pinned, err := cfg.db.GetPinnedChirpIDs(ctx, authorID)
+		if err != nil {
+			return nil, statusError(http.StatusInternalServerError, "error getting pinned chirps", err)
+		}
+		resp = pinnedFirst(resp, pinned)
+	}
+	return resp, nil
+}
+
+// viewerFromRequest returns the user behind an optional Bearer JWT, or
+// uuid.Nil for anonymous requests. Public reads don't need the token, so an
+// invalid or expired one is read as anonymous rather than rejected.
+func (cfg *apiConf) viewerFromRequest(r *http.Request) uuid.UUID {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.Nil
+	}
+
+	viewer, err := cfg.validateJWT(bearer)
+	if err != nil {
+		return uuid.Nil
+	}
+	return viewer
+}
+
+// enrichChirps loads everything a chirp response embeds besides the chirp
+// row itself, in a fixed number of queries for the whole page.
+func (cfg *apiConf) enrichChirps(ctx context.Context, chirps []Chirp, viewer uuid.UUID) error {
+	if err := cfg.attachPolls(ctx, chirps, viewer); err != nil {
+		return err
+	}
+	if err := cfg.attachQuotes(ctx, chirps, viewer); err != nil {
+		return err
+	}
+	return cfg.attachLinkPreviews(ctx, chirps)
+}
+
+// attachQuotes embeds the quoted chirps that the viewer may see. Quoted
+// chirps are embedded one level deep, without their own poll or previews.
+func (cfg *apiConf) attachQuotes(ctx context.Context, chirps []Chirp, viewer uuid.UUID) error {
+	var ids []uuid.UUID
+	for _, c := range chirps {
+		if c.QuotedChirpID != nil {
+			ids = append(ids, *c.QuotedChirpID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	quoted, err := cfg.db.GetVisibleChirpsByIDs(ctx, database.GetVisibleChirpsByIDsParams{
+		Ids:      ids,
+		ViewerID: viewer,
+	})
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[uuid.UUID]Chirp, len(quoted))
+	for _, q := range quoted {
+		byID[q.ID] = toChirp(q)
+	}
+	for i, c := range chirps {
+		if c.QuotedChirpID == nil {
+			continue
+		}
+		if q, ok := byID[*c.QuotedChirpID]; ok {
+			chirps[i].QuotedChirp = &q
+		}
+	}
+	return nil
 }
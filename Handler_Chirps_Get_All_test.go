@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/auth"
+)
+
+func TestViewerFromRequest(t *testing.T) {
+	const secret = "test-secret"
+	userID := uuid.New()
+	token, err := auth.MakeJWT(userID, secret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := auth.MakeJWT(userID, secret, -time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := auth.MakeJWT(userID, "other-secret", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          uuid.UUID
+	}{
+		{name: "no token", want: uuid.Nil},
+		{name: "valid token", authorization: "Bearer " + token, want: userID},
+		{name: "expired token", authorization: "Bearer " + expired, want: uuid.Nil},
+		{name: "forged token", authorization: "Bearer " + forged, want: uuid.Nil},
+		{name: "not a bearer token", authorization: "ApiKey " + token, want: uuid.Nil},
+	}
+
+	cfg := &apiConf{JWTSecret: secret, suspensions: newSuspensionCache()}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/chirps", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			if got := cfg.viewerFromRequest(r); got != tt.want {
+				t.Errorf("viewerFromRequest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,38 +4,56 @@ import (
 	"net/http"
 
 	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/database"
 )
 
 // HandlerChirpsGetSingle godoc
 // @Summary Get a single chirp
-// @Description Get a chirp by its UUID
+// @Description Get a chirp by its UUID. Responses carry a strong ETag and Last-Modified; send If-None-Match or If-Modified-Since to get 304 when nothing changed. Last-Modified follows the chirp, its poll, pin, quoted chirp and link previews.
 // @Tags chirps
 // @Accept json
 // @Produce json
+// @Param Authorization header string false "Bearer <JWT token>"
 // @Param chirpID path string true "Chirp UUID"
+// @Param If-None-Match header string false "ETag of the cached copy"
+// @Param If-Modified-Since header string false "Last-Modified of the cached copy"
 // @Success 200 {object} Chirp
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 304
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /api/chirps/{chirpID} [get]
 func (cfg *apiConf) HandlerChirpsGetSingle(w http.ResponseWriter, r *http.Request) {
 	uuidString := r.PathValue("chirpID")
 	chirpUUID, err := uuid.Parse(uuidString)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed parsing uuid", err)
+		respondWithAPIError(w, errInvalidID("chirpID", err))
 		return
 	}
 
-	chirp, err := cfg.db.GetSingleChirp(r.Context(), chirpUUID)
+	viewer := cfg.viewerFromRequest(r)
+
+	chirp, err := cfg.db.GetVisibleChirp(r.Context(), database.GetVisibleChirpParams{
+		ID:       chirpUUID,
+		ViewerID: viewer,
+	})
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "chirp not in the database", err)
+		respondWithDBError(w, err, "chirp not in the database")
+		return
+	}
+
+	resp := []Chirp{toChirp(chirp)}
+	if err := cfg.enrichChirps(r.Context(), resp, viewer); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting chirp", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
+	lastModified, err := cfg.db.GetChirpLastModified(r.Context(), database.GetChirpLastModifiedParams{
+		ChirpID: chirp.ID,
+		Urls:    previewURLs(resp),
 	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting chirp", err)
+		return
+	}
+	respondWithJSONConditional(w, r, resp[0], lastModified)
 }
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+const maxPinnedChirps = 3
+
+// HandlerChirpsPin godoc
+// @Summary Pin a chirp to your profile
+// @Description Pinned chirps come first when listing chirps by author_id. Only your own chirps can be pinned, at most 3 at a time.
+// @Tags chirps
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param chirpID path string true "Chirp UUID"
+// @Success 204
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem "Pin limit reached"
+// @Failure 500 {object} Problem
+// @Router /api/chirps/{chirpID}/pin [post]
+func (cfg *apiConf) HandlerChirpsPin(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("chirpID", err))
+		return
+	}
+
+	chirp, err := cfg.db.GetSingleChirp(r.Context(), chirpID)
+	if err != nil {
+		respondWithDBError(w, err, "chirp not in the database")
+		return
+	}
+
+	if chirp.UserID != user {
+		respondWithError(w, http.StatusForbidden, "you can only pin your own chirps", nil)
+		return
+	}
+
+	tx, err := cfg.beginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't pin chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx.Tx)
+
+	if err := qtx.LockUserPins(r.Context(), user); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't pin chirp", err)
+		return
+	}
+
+	if _, err := qtx.PinChirp(r.Context(), database.PinChirpParams{
+		UserID:  user,
+		ChirpID: chirp.ID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't pin chirp", err)
+		return
+	}
+
+	pinned, err := qtx.CountPinnedChirps(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't pin chirp", err)
+		return
+	}
+	if pinned > maxPinnedChirps {
+		respondWithError(w, http.StatusConflict, "unpin a chirp before pinning another one", nil)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't pin chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerChirpsUnpin godoc
+// @Summary Unpin a chirp
+// @Tags chirps
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param chirpID path string true "Chirp UUID"
+// @Success 204
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/chirps/{chirpID}/pin [delete]
+func (cfg *apiConf) HandlerChirpsUnpin(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("chirpID", err))
+		return
+	}
+
+	if err := cfg.db.UnpinChirp(r.Context(), database.UnpinChirpParams{
+		UserID:  user,
+		ChirpID: chirpID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't unpin chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pinnedFirst moves the author's pinned chirps to the front, most recently
+// pinned first, and marks them as pinned. The rest keep their order.
+func pinnedFirst(chirps []Chirp, pinnedIDs []uuid.UUID) []Chirp {
+	if len(pinnedIDs) == 0 {
+		return chirps
+	}
+
+	rank := make(map[uuid.UUID]int, len(pinnedIDs))
+	for i, id := range pinnedIDs {
+		rank[id] = i
+	}
+
+	pinned := make([]Chirp, len(pinnedIDs))
+	found := make([]bool, len(pinnedIDs))
+	rest := make([]Chirp, 0, len(chirps))
+	for _, c := range chirps {
+		if i, ok := rank[c.ID]; ok {
+			c.Pinned = true
+			pinned[i] = c
+			found[i] = true
+			continue
+		}
+		rest = append(rest, c)
+	}
+
+	resp := make([]Chirp, 0, len(chirps))
+	for i, c := range pinned {
+		// pinned chirps in the trash aren't listed
+		if found[i] {
+			resp = append(resp, c)
+		}
+	}
+	return append(resp, rest...)
+}
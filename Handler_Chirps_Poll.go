@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+const (
+	pollMinOptions     = 2
+	pollMaxOptions     = 4
+	pollMaxLabelLength = 25
+	pollMinDuration    = 5 * time.Minute
+	pollMaxDuration    = 7 * 24 * time.Hour
+)
+
+// Poll is attached to a chirp. Votes are counted live until the poll expires,
+// after that the freeze job stores the final tally.
+type Poll struct {
+	ID            uuid.UUID    `json:"id"`
+	ExpiresAt     time.Time    `json:"expires_at"`
+	Closed        bool         `json:"closed"`
+	TotalVotes    int64        `json:"total_votes"`
+	Options       []PollOption `json:"options"`
+	VotedOptionID *uuid.UUID   `json:"voted_option_id,omitempty"`
+}
+
+type PollOption struct {
+	ID    uuid.UUID `json:"id"`
+	Label string    `json:"label"`
+	Votes int64     `json:"votes"`
+}
+
+type pollParams struct {
+	Options   []string  `json:"options"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type pollVote struct {
+	OptionID uuid.UUID `json:"option_id"`
+}
+
+func validatePoll(p pollParams) ([]string, error) {
+	if len(p.Options) < pollMinOptions || len(p.Options) > pollMaxOptions {
+		return nil, errValidation(FieldError{Field: "poll.options", Code: codeFieldRange, Message: fmt.Sprintf("must have %d to %d options", pollMinOptions, pollMaxOptions)})
+	}
+
+	until := time.Until(p.ExpiresAt)
+	if until < pollMinDuration || until > pollMaxDuration {
+		return nil, errValidation(FieldError{Field: "poll.expires_at", Code: codeFieldRange, Message: "must be between 5 minutes and 7 days from now"})
+	}
+
+	labels := make([]string, len(p.Options))
+	seen := make(map[string]struct{}, len(p.Options))
+	for i, o := range p.Options {
+		label := strings.TrimSpace(o)
+		if label == "" || len(label) > pollMaxLabelLength {
+			return nil, errValidation(FieldError{Field: fmt.Sprintf("poll.options[%d]", i), Code: codeFieldInvalid, Message: fmt.Sprintf("must be 1 to %d characters", pollMaxLabelLength)})
+		}
+		key := strings.ToLower(label)
+		if _, ok := seen[key]; ok {
+			return nil, errValidation(FieldError{Field: fmt.Sprintf("poll.options[%d]", i), Code: codeFieldInvalid, Message: "must be unique"})
+		}
+		seen[key] = struct{}{}
+		labels[i] = getCleanedBody(label, badWords)
+	}
+	return labels, nil
+}
+
+// createPoll stores a poll, open for expiresIn, for a chirp created in the
+// same transaction.
+func createPoll(ctx context.Context, qtx *database.Queries, chirpID uuid.UUID, labels []string, expiresIn time.Duration) (*Poll, error) {
+	poll, err := qtx.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID:       chirpID,
+		ExpiresInSecs: expiresIn.Seconds(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &Poll{
+		ID:        poll.ID,
+		ExpiresAt: poll.ExpiresAt,
+		Options:   make([]PollOption, len(labels)),
+	}
+	for i, label := range labels {
+		o, err := qtx.CreatePollOption(ctx, database.CreatePollOptionParams{
+			PollID:   poll.ID,
+			Position: int32(i),
+			Label:    label,
+		})
+		if err != nil {
+			return nil, err
+		}
+		resp.Options[i] = PollOption{ID: o.ID, Label: o.Label}
+	}
+	return resp, nil
+}
+
+// loadPolls returns the polls of the given chirps keyed by chirp ID, with a
+// fixed number of queries however many chirps are passed. For a non-nil
+// viewer VotedOptionID is filled in.
+func (cfg *apiConf) loadPolls(ctx context.Context, chirpIDs []uuid.UUID, viewer uuid.UUID) (map[uuid.UUID]*Poll, error) {
+	if len(chirpIDs) == 0 {
+		return nil, nil
+	}
+
+	polls, err := cfg.db.GetPollsByChirpIDs(ctx, chirpIDs)
+	if err != nil || len(polls) == 0 {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]*Poll, len(polls))
+	byChirp := make(map[uuid.UUID]*Poll, len(polls))
+	pollIDs := make([]uuid.UUID, len(polls))
+	for i, p := range polls {
+		poll := &Poll{
+			ID:        p.ID,
+			ExpiresAt: p.ExpiresAt,
+			Closed:    p.Closed,
+			Options:   []PollOption{},
+		}
+		byID[p.ID] = poll
+		byChirp[p.ChirpID] = poll
+		pollIDs[i] = p.ID
+	}
+
+	tallies, err := cfg.db.GetPollTallies(ctx, pollIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tallies {
+		poll := byID[t.PollID]
+		poll.Options = append(poll.Options, PollOption{
+			ID:    t.ID,
+			Label: t.Label,
+			Votes: t.Votes,
+		})
+		poll.TotalVotes += t.Votes
+	}
+
+	if viewer != uuid.Nil {
+		votes, err := cfg.db.GetUserPollVotes(ctx, database.GetUserPollVotesParams{
+			UserID:  viewer,
+			PollIds: pollIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range votes {
+			byID[v.PollID].VotedOptionID = &v.OptionID
+		}
+	}
+
+	return byChirp, nil
+}
+
+// attachPolls sets Poll on every chirp that has one.
+func (cfg *apiConf) attachPolls(ctx context.Context, chirps []Chirp, viewer uuid.UUID) error {
+	ids := make([]uuid.UUID, len(chirps))
+	for i, c := range chirps {
+		ids[i] = c.ID
+	}
+
+	polls, err := cfg.loadPolls(ctx, ids, viewer)
+	if err != nil {
+		return err
+	}
+	for i := range chirps {
+		chirps[i].Poll = polls[chirps[i].ID]
+	}
+	return nil
+}
+
+// HandlerChirpsPollVote godoc
+// @Summary Vote in a chirp's poll
+// @Description Casts the authenticated user's vote. Each user votes once per poll and votes can't be changed.
+// @Tags chirps
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param chirpID path string true "Chirp UUID"
+// @Param vote body pollVote true "Option to vote for"
+// @Success 200 {object} Poll
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem "Already voted or poll closed"
+// @Failure 500 {object} Problem
+// @Router /api/chirps/{chirpID}/poll/vote [post]
+func (cfg *apiConf) HandlerChirpsPollVote(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("chirpID", err))
+		return
+	}
+
+	var params pollVote
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithAPIError(w, errInvalidJSON(err))
+		return
+	}
+
+	if _, err := cfg.db.GetVisibleChirp(r.Context(), database.GetVisibleChirpParams{
+		ID:       chirpID,
+		ViewerID: user,
+	}); err != nil {
+		respondWithDBError(w, err, "chirp not in the database")
+		return
+	}
+
+	poll, err := cfg.db.GetPollByChirpID(r.Context(), chirpID)
+	if err != nil {
+		respondWithDBError(w, err, "chirp has no poll")
+		return
+	}
+
+	// The insert is a single statement guarded by the primary key, so
+	// concurrent votes by the same user can't both succeed.
+	n, err := cfg.db.VoteInPoll(r.Context(), database.VoteInPollParams{
+		PollID:   poll.ID,
+		UserID:   user,
+		OptionID: params.OptionID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't record vote", err)
+		return
+	}
+
+	polls, err := cfg.loadPolls(r.Context(), []uuid.UUID{chirpID}, user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't load poll", err)
+		return
+	}
+	resp, ok := polls[chirpID]
+	if !ok {
+		// the chirp was purged in the meantime
+		respondWithError(w, http.StatusNotFound, "chirp has no poll", nil)
+		return
+	}
+
+	if n == 0 {
+		// nothing was inserted, work out why
+		switch {
+		case resp.Closed:
+			respondWithError(w, http.StatusConflict, "poll is closed", nil)
+		case !hasPollOption(resp, params.OptionID):
+			respondWithError(w, http.StatusBadRequest, "option doesn't belong to this poll", nil)
+		default:
+			respondWithError(w, http.StatusConflict, "already voted in this poll", nil)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func hasPollOption(p *Poll, optionID uuid.UUID) bool {
+	for _, o := range p.Options {
+		if o.ID == optionID {
+			return true
+		}
+	}
+	return false
+}
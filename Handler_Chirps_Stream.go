@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/pubsub"
+)
+
+const streamHeartbeat = 15 * time.Second
+
+// HandlerChirpsStream godoc
+// @Summary Live chirp timeline (Server-Sent Events)
+// @Description Streams created and deleted chirps as Server-Sent Events. Optional query param author_id (UUID). Send Last-Event-ID to resume from the replay buffer. With a Bearer JWT, chirps of users the viewer blocked or was blocked by are left out.
+// @Tags chirps
+// @Produce text/event-stream
+// @Param Authorization header string false "Bearer <JWT token>"
+// @Param author_id query string false "Author UUID"
+// @Param Last-Event-ID header string false "ID of the last event received"
+// @Success 200 {string} string
+// @Failure 400 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/chirps/stream [get]
+func (cfg *apiConf) HandlerChirpsStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, "streaming not supported", nil)
+		return
+	}
+
+	authorID := uuid.Nil
+	if s := r.URL.Query().Get("author_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			respondWithAPIError(w, errInvalidID("author_id", err))
+			return
+		}
+		authorID = id
+	}
+
+	viewer := cfg.viewerFromRequest(r)
+	blocks, err := cfg.loadBlockSet(r.Context(), viewer)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't load blocks", err)
+		return
+	}
+	go blocks.watch(r.Context(), cfg.db)
+
+	filter := func(e pubsub.Event) bool {
+		return isChirpEvent(e) && (authorID == uuid.Nil || e.AuthorID == authorID) && !blocks.hides(e.AuthorID)
+	}
+
+	var lastID uint64
+	if s := r.Header.Get("Last-Event-ID"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "couldn't parse Last-Event-ID", err)
+			return
+		}
+		lastID = id
+	}
+
+	sub, missed := cfg.broker.Subscribe(lastID, filter)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, e := range missed {
+		writeEvent(w, e)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case e, ok := <-sub.C:
+			// the broker dropped us, the client reconnects with Last-Event-ID
+			if !ok {
+				return
+			}
+			writeEvent(w, e)
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e pubsub.Event) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+}
+
+func (cfg *apiConf) publishChirpEvent(ctx context.Context, eventType string, chirp Chirp) {
+	data, err := json.Marshal(chirp)
+	if err != nil {
+		log.Println("couldn't marshal chirp event:", err)
+		return
+	}
+
+	e := pubsub.Event{
+		Type:     eventType,
+		AuthorID: chirp.UserID,
+		ChirpID:  chirp.ID,
+		Data:     data,
+	}
+	if chirp.ThreadID != nil {
+		e.ThreadID = *chirp.ThreadID
+	}
+	afterCommit(ctx, func() {
+		if err := cfg.events.Publish(ctx, e); err != nil {
+			log.Println("couldn't publish chirp event:", err)
+		}
+	})
+}
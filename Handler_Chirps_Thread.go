@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+// HandlerChirpsThread godoc
+// @Summary Get the thread of a chirp
+// @Description Returns the first chirp of the thread the chirp belongs to and every reply in it, oldest first. With a Bearer JWT, chirps from blocked and muted users are left out.
+// @Tags chirps
+// @Produce json
+// @Param Authorization header string false "Bearer <JWT token>"
+// @Param chirpID path string true "Chirp UUID"
+// @Success 200 {array} Chirp
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/chirps/{chirpID}/thread [get]
+func (cfg *apiConf) HandlerChirpsThread(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("chirpID", err))
+		return
+	}
+
+	viewer := cfg.viewerFromRequest(r)
+
+	chirp, err := cfg.db.GetVisibleChirp(r.Context(), database.GetVisibleChirpParams{
+		ID:       chirpID,
+		ViewerID: viewer,
+	})
+	if err != nil {
+		respondWithDBError(w, err, "chirp not in the database")
+		return
+	}
+
+	rows, err := cfg.db.GetThread(r.Context(), database.GetThreadParams{
+		ThreadID: threadOf(chirp),
+		ViewerID: viewer,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting thread", err)
+		return
+	}
+
+	resp := make([]Chirp, len(rows))
+	for i, c := range rows {
+		resp[i] = toChirp(c)
+	}
+	if err := cfg.enrichChirps(r.Context(), resp, viewer); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting thread", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// threadOf is the ID of the first chirp of c's thread, c's own ID when it
+// isn't a reply.
+func threadOf(c database.Chirp) uuid.UUID {
+	if c.ThreadID.Valid {
+		return c.ThreadID.UUID
+	}
+	return c.ID
+}
+
+// setReplyTo makes params a reply to parentID, which the author has to be
+// able to see.
+func (cfg *apiConf) setReplyTo(ctx context.Context, params *database.CreateChirpParams, parentID uuid.UUID) error {
+	parent, err := cfg.db.GetVisibleChirp(ctx, database.GetVisibleChirpParams{
+		ID:       parentID,
+		ViewerID: params.UserID,
+	})
+	if err != nil {
+		return statusError(http.StatusBadRequest, "chirp to reply to not found", err)
+	}
+	params.ReplyToID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	params.ThreadID = uuid.NullUUID{UUID: threadOf(parent), Valid: true}
+	return nil
+}
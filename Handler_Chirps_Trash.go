@@ -0,0 +1,146 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+	"github.com/tsironi93/WebServer/internal/pubsub"
+)
+
+// chirpRetention is how long a deleted chirp stays in the trash before the
+// purge job removes it for good.
+const chirpRetention = 30 * 24 * time.Hour
+
+type TrashedChirp struct {
+	Chirp
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+// HandlerChirpsTrash godoc
+// @Summary List deleted chirps
+// @Description Returns the authenticated user's deleted chirps that can still be restored, most recently deleted first.
+// @Tags chirps
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Success 200 {array} TrashedChirp
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/users/me/trash [get]
+func (cfg *apiConf) HandlerChirpsTrash(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	chirps, err := cfg.db.GetDeletedChirps(r.Context(), database.GetDeletedChirpsParams{
+		UserID:        user,
+		RetentionSecs: chirpRetention.Seconds(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting deleted chirps", err)
+		return
+	}
+
+	resp := make([]TrashedChirp, len(chirps))
+	for i, c := range chirps {
+		resp[i] = TrashedChirp{
+			Chirp:     toChirp(c),
+			DeletedAt: c.DeletedAt.Time,
+			PurgeAt:   c.DeletedAt.Time.Add(chirpRetention),
+		}
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// HandlerChirpsRestore godoc
+// @Summary Restore a deleted chirp
+// @Description Restores one of the authenticated user's deleted chirps if it is still within the retention window. If it was pinned and the user has pinned 3 other chirps since, it comes back unpinned.
+// @Tags chirps
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param chirpID path string true "Chirp UUID"
+// @Success 200 {object} Chirp
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /api/chirps/{chirpID}/restore [post]
+func (cfg *apiConf) HandlerChirpsRestore(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	chirpUUID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("chirpID", err))
+		return
+	}
+
+	tx, err := cfg.beginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't restore chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx.Tx)
+
+	if err := qtx.LockUserPins(r.Context(), user); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't restore chirp", err)
+		return
+	}
+
+	chirp, err := qtx.RestoreChirp(r.Context(), database.RestoreChirpParams{
+		ID:            chirpUUID,
+		UserID:        user,
+		RetentionSecs: chirpRetention.Seconds(),
+	})
+	if err != nil {
+		respondWithDBError(w, err, "chirp not in the trash")
+		return
+	}
+
+	// Trashed chirps don't count towards the pin limit, so the user may have
+	// pinned others since. A restored chirp over the limit comes back unpinned.
+	pinned, err := qtx.CountPinnedChirps(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't restore chirp", err)
+		return
+	}
+	if pinned > maxPinnedChirps {
+		if err := qtx.UnpinChirp(r.Context(), database.UnpinChirpParams{
+			UserID:  user,
+			ChirpID: chirp.ID,
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "couldn't restore chirp", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't restore chirp", err)
+		return
+	}
+
+	resp := toChirp(chirp)
+	cfg.publishChirpEvent(r.Context(), pubsub.EventChirpCreated, resp)
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
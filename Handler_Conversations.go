@@ -0,0 +1,575 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+const (
+	maxConversationSize = 8
+	maxMessageLength    = 1000
+)
+
+type Conversation struct {
+	ID           uuid.UUID                 `json:"id"`
+	CreatedAt    time.Time                 `json:"created_at"`
+	UpdatedAt    time.Time                 `json:"updated_at"`
+	CreatedBy    uuid.UUID                 `json:"created_by"`
+	Participants []ConversationParticipant `json:"participants"`
+}
+
+// ConversationParticipant doubles as the read receipt: every message created
+// at or before LastReadAt has been read by that participant.
+type ConversationParticipant struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	JoinedAt   time.Time  `json:"joined_at"`
+	LastReadAt *time.Time `json:"last_read_at"`
+}
+
+type Message struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Body           string    `json:"body"`
+}
+
+type MessagesResponse struct {
+	Messages   []Message `json:"messages"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+type createConversation struct {
+	ParticipantIDs []uuid.UUID `json:"participant_ids"`
+}
+
+type sendMessage struct {
+	Body string `json:"body"`
+}
+
+// HandlerConversationsCreate godoc
+// @Summary Start a conversation
+// @Description Creates a one-to-one or small group conversation with the given users. The caller is always a participant. Starting a one-to-one conversation that already exists returns the existing one. Users who blocked the caller, or whom the caller blocked, can't be added.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param conversation body createConversation true "Participants"
+// @Success 200 {object} Conversation "Existing one-to-one conversation"
+// @Success 201 {object} Conversation
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/conversations [post]
+func (cfg *apiConf) HandlerConversationsCreate(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	var params createConversation
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithAPIError(w, errInvalidJSON(err))
+		return
+	}
+
+	participants := []uuid.UUID{user}
+	seen := map[uuid.UUID]struct{}{user: {}}
+	for _, id := range params.ParticipantIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		participants = append(participants, id)
+	}
+
+	if len(participants) < 2 {
+		respondWithError(w, http.StatusBadRequest, "a conversation needs at least one other participant", nil)
+		return
+	}
+	if len(participants) > maxConversationSize {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("a conversation can have at most %d participants", maxConversationSize), nil)
+		return
+	}
+
+	blocked, err := cfg.db.HasBlockWith(r.Context(), database.HasBlockWithParams{UserID: user, OtherIds: participants[1:]})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't check blocks", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "can't start a conversation with a user who blocked you or whom you blocked", nil)
+		return
+	}
+
+	tx, err := cfg.beginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't create conversation", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx.Tx)
+
+	if len(participants) == 2 {
+		pair := database.FindDirectConversationParams{UserA: participants[0], UserB: participants[1]}
+		// the lock holds until commit, so a concurrent request for the same
+		// pair finds the conversation created here
+		if err := qtx.LockDirectConversation(r.Context(), database.LockDirectConversationParams(pair)); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "couldn't create conversation", err)
+			return
+		}
+		existing, err := qtx.FindDirectConversation(r.Context(), pair)
+		if err == nil {
+			cfg.respondWithConversation(w, r, http.StatusOK, existing, user)
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusInternalServerError, "couldn't look up conversation", err)
+			return
+		}
+	}
+
+	conversation, err := qtx.CreateConversation(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't create conversation", err)
+		return
+	}
+
+	for _, id := range participants {
+		err := qtx.AddConversationParticipant(r.Context(), database.AddConversationParticipantParams{
+			ConversationID: conversation.ID,
+			UserID:         id,
+		})
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			respondWithError(w, http.StatusBadRequest, "unknown participant", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "couldn't add participant", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't create conversation", err)
+		return
+	}
+
+	cfg.respondWithConversation(w, r, http.StatusCreated, conversation, user)
+}
+
+// HandlerConversationsList godoc
+// @Summary List conversations
+// @Description Returns the authenticated user's conversations, most recently active first.
+// @Tags messages
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param offset query int false "Offset"
+// @Success 200 {array} Conversation
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/conversations [get]
+func (cfg *apiConf) HandlerConversationsList(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	conversations, err := cfg.db.ListConversations(r.Context(), database.ListConversationsParams{
+		ViewerID:  user,
+		RowLimit:  limit,
+		RowOffset: offset,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting conversations", err)
+		return
+	}
+
+	resp := make([]Conversation, len(conversations))
+	for i, c := range conversations {
+		resp[i], err = cfg.toConversation(r.Context(), c, user)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error getting participants", err)
+			return
+		}
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// HandlerConversationsGet godoc
+// @Summary Get a conversation
+// @Description Returns a conversation with its participants and their read receipts. Only participants can see it.
+// @Tags messages
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param conversationID path string true "Conversation UUID"
+// @Success 200 {object} Conversation
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /api/conversations/{conversationID} [get]
+func (cfg *apiConf) HandlerConversationsGet(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("conversationID", err))
+		return
+	}
+
+	conversation, err := cfg.db.GetConversation(r.Context(), database.GetConversationParams{
+		ViewerID: user,
+		ID:       conversationID,
+	})
+	if err != nil {
+		respondWithDBError(w, err, "conversation not found")
+		return
+	}
+
+	cfg.respondWithConversation(w, r, http.StatusOK, conversation, user)
+}
+
+// HandlerMessagesSend godoc
+// @Summary Send a message
+// @Description Sends a message to a conversation the caller participates in. Bodies go through the same filtering as chirps. Fails with 403 when the caller blocked another participant or was blocked by one.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param conversationID path string true "Conversation UUID"
+// @Param message body sendMessage true "Message payload"
+// @Success 201 {object} Message
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/conversations/{conversationID}/messages [post]
+func (cfg *apiConf) HandlerMessagesSend(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("conversationID", err))
+		return
+	}
+
+	var params sendMessage
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithAPIError(w, errInvalidJSON(err))
+		return
+	}
+
+	cleaned, err := validateMessage(params.Body)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	blocked, err := cfg.db.ConversationHasBlock(r.Context(), database.ConversationHasBlockParams{UserID: user, ConversationID: conversationID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't check blocks", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "can't message a user who blocked you or whom you blocked", nil)
+		return
+	}
+
+	message, err := cfg.db.CreateMessage(r.Context(), database.CreateMessageParams{
+		ConversationID: conversationID,
+		SenderID:       user,
+		Body:           cleaned,
+	})
+	// no row means the sender isn't a participant
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "conversation not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't send message", err)
+		return
+	}
+
+	if err := cfg.db.TouchConversation(r.Context(), conversationID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't update conversation", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, toMessage(message))
+}
+
+// HandlerMessagesList godoc
+// @Summary List messages
+// @Description Returns messages of a conversation, newest first. Pass next_cursor from the previous page as before to continue.
+// @Tags messages
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param conversationID path string true "Conversation UUID"
+// @Param before query string false "Cursor from a previous page"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Success 200 {object} MessagesResponse
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/conversations/{conversationID}/messages [get]
+func (cfg *apiConf) HandlerMessagesList(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("conversationID", err))
+		return
+	}
+
+	limit, _, err := parsePagination(r)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	params := database.ListMessagesParams{
+		ConversationID: conversationID,
+		ViewerID:       user,
+		RowLimit:       limit,
+	}
+	if s := r.URL.Query().Get("before"); s != "" {
+		createdAt, id, err := decodeCursor(s)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid cursor", err)
+			return
+		}
+		params.HasCursor = true
+		params.CursorCreatedAt = createdAt
+		params.CursorID = id
+	}
+
+	// an empty page can't tell "no access" from "no messages", so check
+	// membership first to return a proper 404
+	if _, err := cfg.db.GetConversation(r.Context(), database.GetConversationParams{
+		ViewerID: user,
+		ID:       conversationID,
+	}); err != nil {
+		respondWithDBError(w, err, "conversation not found")
+		return
+	}
+
+	messages, err := cfg.db.ListMessages(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting messages", err)
+		return
+	}
+
+	resp := MessagesResponse{
+		Messages: make([]Message, len(messages)),
+	}
+	for i, m := range messages {
+		resp.Messages[i] = toMessage(m)
+	}
+	if len(messages) == int(limit) {
+		last := messages[len(messages)-1]
+		resp.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// HandlerConversationsMarkRead godoc
+// @Summary Mark a conversation as read
+// @Description Moves the caller's read receipt to now.
+// @Tags messages
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param conversationID path string true "Conversation UUID"
+// @Success 204
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /api/conversations/{conversationID}/read [post]
+func (cfg *apiConf) HandlerConversationsMarkRead(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("conversationID", err))
+		return
+	}
+
+	n, err := cfg.db.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ConversationID: conversationID,
+		UserID:         user,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't mark conversation as read", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "conversation not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validateMessage(body string) (string, error) {
+	if strings.TrimSpace(body) == "" {
+		return "", errValidation(FieldError{Field: "body", Code: codeFieldRequired, Message: "is required"})
+	}
+	if len(body) > maxMessageLength {
+		return "", errValidation(FieldError{Field: "body", Code: codeFieldTooLong, Message: fmt.Sprintf("must be at most %d characters", maxMessageLength)})
+	}
+
+	return getCleanedBody(body, badWords), nil
+}
+
+func (cfg *apiConf) toConversation(ctx context.Context, c database.Conversation, viewer uuid.UUID) (Conversation, error) {
+	participants, err := cfg.db.GetConversationParticipants(ctx, database.GetConversationParticipantsParams{
+		ConversationID: c.ID,
+		ViewerID:       viewer,
+	})
+	if err != nil {
+		return Conversation{}, err
+	}
+
+	resp := Conversation{
+		ID:           c.ID,
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+		CreatedBy:    c.CreatedBy,
+		Participants: make([]ConversationParticipant, len(participants)),
+	}
+	for i, p := range participants {
+		resp.Participants[i] = ConversationParticipant{
+			UserID:   p.UserID,
+			JoinedAt: p.JoinedAt,
+		}
+		if p.LastReadAt.Valid {
+			resp.Participants[i].LastReadAt = &p.LastReadAt.Time
+		}
+	}
+	return resp, nil
+}
+
+func (cfg *apiConf) respondWithConversation(w http.ResponseWriter, r *http.Request, code int, c database.Conversation, viewer uuid.UUID) {
+	resp, err := cfg.toConversation(r.Context(), c, viewer)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting participants", err)
+		return
+	}
+	respondWithJSON(w, code, resp)
+}
+
+func toMessage(m database.Message) Message {
+	return Message{
+		ID:             m.ID,
+		CreatedAt:      m.CreatedAt,
+		ConversationID: m.ConversationID,
+		SenderID:       m.SenderID,
+		Body:           m.Body,
+	}
+}
+
+// encodeCursor packs the keyset position of a row into an opaque string.
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.Format(time.RFC3339Nano) + "," + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+
+	ts, rawID, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return time.Time{}, uuid.Nil, errors.New("malformed cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+
+	return createdAt, id, nil
+}
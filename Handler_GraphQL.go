@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/tsironi93/WebServer/internal/graphql"
+)
+
+const (
+	maxGraphQLBodyBytes     = 1 << 20
+	persistedQueryCacheSize = 1000
+
+	// codes of the automatic persisted queries protocol, which Apollo
+	// clients look for
+	codePersistedQueryNotFound     = "PERSISTED_QUERY_NOT_FOUND"
+	codePersistedQueryNotSupported = "PERSISTED_QUERY_NOT_SUPPORTED"
+)
+
+type graphQLParams struct {
+	Query         string            `json:"query"`
+	OperationName string            `json:"operationName"`
+	Variables     map[string]any    `json:"variables"`
+	Extensions    graphQLExtensions `json:"extensions"`
+}
+
+type graphQLExtensions struct {
+	PersistedQuery *persistedQuery `json:"persistedQuery"`
+}
+
+type persistedQuery struct {
+	Version    int    `json:"version"`
+	Sha256Hash string `json:"sha256Hash"`
+}
+
+// persistedQueries maps the SHA-256 of a query to its parsed document. It
+// backs automatic persisted queries, and spares parsing the same queries
+// over and over.
+type persistedQueries struct {
+	mu   sync.Mutex
+	docs map[string]*graphql.Document
+}
+
+func newPersistedQueries() *persistedQueries {
+	return &persistedQueries{docs: make(map[string]*graphql.Document)}
+}
+
+func (p *persistedQueries) get(hash string) (*graphql.Document, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	doc, ok := p.docs[hash]
+	return doc, ok
+}
+
+func (p *persistedQueries) put(hash string, doc *graphql.Document) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.docs) >= persistedQueryCacheSize {
+		clear(p.docs)
+	}
+	p.docs[hash] = doc
+}
+
+// HandlerGraphQL godoc
+// @Summary GraphQL endpoint
+// @Description Runs a GraphQL query against schema.graphql. Supports automatic persisted queries: send extensions.persistedQuery with the query's sha256Hash and no query, and the query itself only when the answer is PERSISTED_QUERY_NOT_FOUND. GraphQL errors, including depth and complexity limits, come back as a 200 with an errors list; problem+json is only used for an invalid body. An invalid or expired token is treated as no token.
+// @Tags graphql
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "Bearer <JWT token>"
+// @Param request body graphQLParams true "GraphQL request"
+// @Success 200 {object} graphql.Response
+// @Failure 400 {object} Problem
+// @Router /graphql [post]
+func (cfg *apiConf) HandlerGraphQL(w http.ResponseWriter, r *http.Request) {
+	var params graphQLParams
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGraphQLBodyBytes))
+	decoder.UseNumber()
+	if err := decoder.Decode(&params); err != nil {
+		respondWithAPIError(w, errInvalidJSON(err))
+		return
+	}
+	cfg.serveGraphQL(w, r, params)
+}
+
+// HandlerGraphQLGet godoc
+// @Summary GraphQL endpoint for cacheable queries
+// @Description Like POST /graphql with the request in query params, variables and extensions JSON encoded. Meant for persisted queries, whose URLs stay short and can be cached.
+// @Tags graphql
+// @Produce json
+// @Param Authorization header string false "Bearer <JWT token>"
+// @Param query query string false "Query document"
+// @Param operationName query string false "Operation to run"
+// @Param variables query string false "JSON object of variables"
+// @Param extensions query string false "JSON object of extensions"
+// @Success 200 {object} graphql.Response
+// @Failure 400 {object} Problem
+// @Router /graphql [get]
+func (cfg *apiConf) HandlerGraphQLGet(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	params := graphQLParams{
+		Query:         q.Get("query"),
+		OperationName: q.Get("operationName"),
+	}
+	for name, dst := range map[string]any{"variables": &params.Variables, "extensions": &params.Extensions} {
+		s := q.Get(name)
+		if s == "" {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader([]byte(s)))
+		decoder.UseNumber()
+		if err := decoder.Decode(dst); err != nil {
+			respondWithAPIError(w, errValidation(FieldError{Field: name, Code: codeFieldInvalid, Message: "must be a JSON object"}))
+			return
+		}
+	}
+	cfg.serveGraphQL(w, r, params)
+}
+
+func (cfg *apiConf) serveGraphQL(w http.ResponseWriter, r *http.Request, params graphQLParams) {
+	viewer := cfg.viewerFromRequest(r)
+
+	doc, errResp := cfg.graphQLDocument(params)
+	if errResp != nil {
+		respondWithJSON(w, http.StatusOK, errResp)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), graphQLRequestKey{}, cfg.newGraphQLRequest(viewer))
+	respondWithJSON(w, http.StatusOK, cfg.graphql.Exec(ctx, doc, params.OperationName, params.Variables))
+}
+
+// graphQLDocument returns the parsed query of params, from the persisted
+// queries when it only has a hash.
+func (cfg *apiConf) graphQLDocument(params graphQLParams) (*graphql.Document, *graphql.Response) {
+	hash := ""
+	if pq := params.Extensions.PersistedQuery; pq != nil {
+		if pq.Version != 1 {
+			return nil, graphql.ErrorResponse(codePersistedQueryNotSupported, "persisted query version %d is not supported", pq.Version)
+		}
+		hash = pq.Sha256Hash
+	}
+
+	if params.Query == "" {
+		if hash == "" {
+			return nil, graphql.ErrorResponse(graphql.CodeInvalidQuery, "query is required")
+		}
+		if doc, ok := cfg.persistedQueries.get(hash); ok {
+			return doc, nil
+		}
+		return nil, graphql.ErrorResponse(codePersistedQueryNotFound, "PersistedQueryNotFound")
+	}
+
+	sum := sha256.Sum256([]byte(params.Query))
+	queryHash := hex.EncodeToString(sum[:])
+	if hash != "" && hash != queryHash {
+		return nil, graphql.ErrorResponse(graphql.CodeInvalidQuery, "provided sha does not match query")
+	}
+	if doc, ok := cfg.persistedQueries.get(queryHash); ok {
+		return doc, nil
+	}
+
+	doc, errs := cfg.graphql.Parse(params.Query)
+	if errs != nil {
+		return nil, &graphql.Response{Errors: errs}
+	}
+	cfg.persistedQueries.put(queryHash, doc)
+	return doc, nil
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
+	"html"
 	"net/http"
+	"strings"
 )
 
 func (cfg *apiConf) middlewareMetricsInc(next http.Handler) http.Handler {
@@ -14,13 +16,21 @@ func (cfg *apiConf) middlewareMetricsInc(next http.Handler) http.Handler {
 
 // HandlerMetrics godoc
 // @Summary Admin metrics page
-// @Description Returns a simple HTML page with metrics. Admin-only endpoint in this app.
+// @Description Returns a simple HTML page with metrics: file server hits and gRPC calls by method and status code. Admins only.
 // @Tags admin
 // @Accept html
 // @Produce html
+// @Param Authorization header string true "Bearer <JWT token>"
 // @Success 200 {string} string
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
 // @Router /admin/metrics [get]
 func (cfg *apiConf) HandlerMetrics(w http.ResponseWriter, r *http.Request) {
+	var grpcCalls strings.Builder
+	for _, m := range cfg.grpcMetrics.Snapshot() {
+		fmt.Fprintf(&grpcCalls, "\n\t\t\t\t<li>%s %s: %d calls, %v total</li>", html.EscapeString(m.Method), m.Code, m.Calls, m.Total)
+	}
+
 	w.Header().Add("Content-Type", "text/html; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	adminMetrics := fmt.Sprintf(`
@@ -28,9 +38,12 @@ func (cfg *apiConf) HandlerMetrics(w http.ResponseWriter, r *http.Request) {
 		<body>
 			<h1>Welcome, Chirpy Admin</h1>
 			<p>Chirpy has been visited %d times!</p>
+			<h2>gRPC calls</h2>
+			<ul>%s
+			</ul>
 		</body>
 	</html>
-	`, cfg.fileserverHits.Load())
+	`, cfg.fileserverHits.Load(), grpcCalls.String())
 
 	w.Write([]byte(adminMetrics))
 }
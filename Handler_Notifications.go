@@ -0,0 +1,290 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+type Notification struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Type      string     `json:"type"`
+	ActorID   *uuid.UUID `json:"actor_id"`
+	ChirpID   *uuid.UUID `json:"chirp_id"`
+	ReadAt    *time.Time `json:"read_at"`
+}
+
+type NotificationsResponse struct {
+	Notifications []Notification `json:"notifications"`
+	UnreadCount   int64          `json:"unread_count"`
+}
+
+func toNotification(n database.Notification) Notification {
+	resp := Notification{
+		ID:        n.ID,
+		CreatedAt: n.CreatedAt,
+		Type:      n.Type,
+	}
+	if n.ActorID.Valid {
+		resp.ActorID = &n.ActorID.UUID
+	}
+	if n.ChirpID.Valid {
+		resp.ChirpID = &n.ChirpID.UUID
+	}
+	if n.ReadAt.Valid {
+		resp.ReadAt = &n.ReadAt.Time
+	}
+	return resp
+}
+
+// HandlerNotificationsList godoc
+// @Summary List notifications
+// @Description Returns the authenticated user's notifications, newest first, with the unread count. Optional query params: unread (true|false), limit, offset.
+// @Tags notifications
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param unread query bool false "Only unread notifications"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} NotificationsResponse
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/notifications [get]
+func (cfg *apiConf) HandlerNotificationsList(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	notifications, err := cfg.db.ListNotifications(r.Context(), database.ListNotificationsParams{
+		UserID:     user,
+		UnreadOnly: r.URL.Query().Get("unread") == "true",
+		RowLimit:   limit,
+		RowOffset:  offset,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting notifications", err)
+		return
+	}
+
+	unread, err := cfg.db.CountUnreadNotifications(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error counting notifications", err)
+		return
+	}
+
+	resp := NotificationsResponse{
+		Notifications: make([]Notification, len(notifications)),
+		UnreadCount:   unread,
+	}
+	for i, n := range notifications {
+		resp.Notifications[i] = toNotification(n)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// HandlerNotificationsMarkRead godoc
+// @Summary Mark a notification as read
+// @Tags notifications
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param notificationID path string true "Notification UUID"
+// @Success 204
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /api/notifications/{notificationID}/read [post]
+func (cfg *apiConf) HandlerNotificationsMarkRead(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	notificationID, err := uuid.Parse(r.PathValue("notificationID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("notificationID", err))
+		return
+	}
+
+	n, err := cfg.db.MarkNotificationRead(r.Context(), database.MarkNotificationReadParams{
+		ID:     notificationID,
+		UserID: user,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't mark notification as read", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "notification not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerNotificationsMarkAllRead godoc
+// @Summary Mark all notifications as read
+// @Tags notifications
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Success 204
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/notifications/read [post]
+func (cfg *apiConf) HandlerNotificationsMarkAllRead(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	if err := cfg.db.MarkAllNotificationsRead(r.Context(), user); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't mark notifications as read", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerNotificationPreferencesGet godoc
+// @Summary Get notification preferences
+// @Description Returns which notification types are enabled for the authenticated user. Types not explicitly set are enabled.
+// @Tags notifications
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Success 200 {object} map[string]bool
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/notifications/preferences [get]
+func (cfg *apiConf) HandlerNotificationPreferencesGet(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	prefs, err := cfg.notificationPreferences(r, user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting preferences", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, prefs)
+}
+
+// HandlerNotificationPreferencesUpdate godoc
+// @Summary Update notification preferences
+// @Description Enables or disables notification types, e.g. {"mention": false}. Types: reply, mention, follow, chirpy_red.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param preferences body map[string]bool true "Type to enabled flag"
+// @Success 200 {object} map[string]bool
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/notifications/preferences [put]
+func (cfg *apiConf) HandlerNotificationPreferencesUpdate(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	var update map[string]bool
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		respondWithAPIError(w, errInvalidJSON(err))
+		return
+	}
+
+	for kind := range update {
+		if !slices.Contains(notificationTypes, kind) {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("unknown notification type %q", kind), nil)
+			return
+		}
+	}
+
+	for kind, enabled := range update {
+		err := cfg.db.SetNotificationPreference(r.Context(), database.SetNotificationPreferenceParams{
+			UserID:  user,
+			Type:    kind,
+			Enabled: enabled,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "couldn't update preferences", err)
+			return
+		}
+	}
+
+	prefs, err := cfg.notificationPreferences(r, user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting preferences", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, prefs)
+}
+
+func (cfg *apiConf) notificationPreferences(r *http.Request, user uuid.UUID) (map[string]bool, error) {
+	rows, err := cfg.db.GetNotificationPreferences(r.Context(), user)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs := make(map[string]bool, len(notificationTypes))
+	for _, kind := range notificationTypes {
+		prefs[kind] = true
+	}
+	for _, p := range rows {
+		// skip types that were dropped since the row was stored
+		if _, ok := prefs[p.Type]; ok {
+			prefs[p.Type] = p.Enabled
+		}
+	}
+	return prefs, nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+const (
+	reportTargetChirp = "chirp"
+	reportTargetUser  = "user"
+
+	maxReportDetailsLength = 500
+)
+
+var reportReasons = []string{
+	"spam",
+	"harassment",
+	"hate",
+	"violence",
+	"sexual_content",
+	"self_harm",
+	"misinformation",
+	"impersonation",
+	"other",
+}
+
+type Report struct {
+	ID             uuid.UUID  `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ReporterID     uuid.UUID  `json:"reporter_id"`
+	TargetType     string     `json:"target_type"`
+	TargetID       uuid.UUID  `json:"target_id"`
+	ReportedUserID uuid.UUID  `json:"reported_user_id"`
+	Reason         string     `json:"reason"`
+	Details        string     `json:"details"`
+	Status         string     `json:"status"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy     *uuid.UUID `json:"resolved_by,omitempty"`
+	Resolution     string     `json:"resolution,omitempty"`
+}
+
+type createReport struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+func toReport(r database.Report) Report {
+	resp := Report{
+		ID:             r.ID,
+		CreatedAt:      r.CreatedAt,
+		ReporterID:     r.ReporterID,
+		TargetType:     r.TargetType,
+		TargetID:       r.TargetID,
+		ReportedUserID: r.ReportedUserID,
+		Reason:         r.Reason,
+		Details:        r.Details,
+		Status:         r.Status,
+		Resolution:     r.Resolution.String,
+	}
+	if r.ResolvedAt.Valid {
+		resp.ResolvedAt = &r.ResolvedAt.Time
+	}
+	if r.ResolvedBy.Valid {
+		resp.ResolvedBy = &r.ResolvedBy.UUID
+	}
+	return resp
+}
+
+// HandlerChirpsReport godoc
+// @Summary Report a chirp
+// @Description Puts the chirp in the moderation queue. Reporting the same chirp again while the first report is open is a no-op. Reasons: spam, harassment, hate, violence, sexual_content, self_harm, misinformation, impersonation, other.
+// @Tags moderation
+// @Accept json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param chirpID path string true "Chirp UUID"
+// @Param report body createReport true "Reason and optional details"
+// @Success 202
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/chirps/{chirpID}/report [post]
+func (cfg *apiConf) HandlerChirpsReport(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("chirpID", err))
+		return
+	}
+
+	chirp, err := cfg.db.GetVisibleChirp(r.Context(), database.GetVisibleChirpParams{
+		ID:       chirpID,
+		ViewerID: user,
+	})
+	if err != nil {
+		respondWithDBError(w, err, "chirp not in the database")
+		return
+	}
+
+	cfg.createReport(w, r, database.CreateReportParams{
+		ReporterID:     user,
+		TargetType:     reportTargetChirp,
+		TargetID:       chirp.ID,
+		ReportedUserID: chirp.UserID,
+	})
+}
+
+// HandlerUserReport godoc
+// @Summary Report a user
+// @Description Puts the user in the moderation queue. Reasons are the same as for chirps.
+// @Tags moderation
+// @Accept json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param userID path string true "User UUID"
+// @Param report body createReport true "Reason and optional details"
+// @Success 202
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/users/{userID}/report [post]
+func (cfg *apiConf) HandlerUserReport(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	target, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("userID", err))
+		return
+	}
+
+	if _, err := cfg.db.GetUserByID(r.Context(), target); err != nil {
+		respondWithDBError(w, err, "user not found")
+		return
+	}
+
+	cfg.createReport(w, r, database.CreateReportParams{
+		ReporterID:     user,
+		TargetType:     reportTargetUser,
+		TargetID:       target,
+		ReportedUserID: target,
+	})
+}
+
+// createReport reads the reason from the request body and stores the report.
+func (cfg *apiConf) createReport(w http.ResponseWriter, r *http.Request, params database.CreateReportParams) {
+	var body createReport
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondWithAPIError(w, errInvalidJSON(err))
+		return
+	}
+
+	if !slices.Contains(reportReasons, body.Reason) {
+		respondWithError(w, http.StatusBadRequest, "unknown report reason", nil)
+		return
+	}
+	if len(body.Details) > maxReportDetailsLength {
+		respondWithError(w, http.StatusBadRequest, "details are too long", nil)
+		return
+	}
+	if params.ReportedUserID == params.ReporterID {
+		respondWithError(w, http.StatusBadRequest, "can't report yourself", nil)
+		return
+	}
+
+	params.Reason = body.Reason
+	params.Details = body.Details
+	if _, err := cfg.db.CreateReport(r.Context(), params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't save report", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
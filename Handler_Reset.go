@@ -7,13 +7,15 @@ import (
 
 // HandlerResetHits godoc
 // @Summary Reset application hits and delete all users (dev only)
-// @Description Resets in-memory hits counter and deletes all users from the database. Only available on dev platform.
+// @Description Resets in-memory hits counter and deletes all users from the database. Only available on dev platform, and only to admins.
 // @Tags admin
 // @Accept json
 // @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
 // @Success 200 {string} string
-// @Failure 403 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /admin/reset [post]
 func (cfg *apiConf) HandlerResetHits(w http.ResponseWriter, r *http.Request) {
 	if cfg.platform != "dev" {
@@ -23,13 +25,18 @@ func (cfg *apiConf) HandlerResetHits(w http.ResponseWriter, r *http.Request) {
 
 	cfg.fileserverHits.Store(0)
 	if err := cfg.db.DeleteAllUsers(r.Context()); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Failed to reset the database: " + err.Error()))
+		respondWithError(w, http.StatusInternalServerError, "Failed to reset the database", err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	// the users are gone but the audit log isn't
+	cfg.audit(r, auditEvent{
+		Type:    auditDataReset,
+		ActorID: userFromContext(r.Context()).ID,
+	})
+
 	w.Header().Add("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
 	hits := fmt.Sprintf("Hits: %d", cfg.fileserverHits.Load())
 	w.Write([]byte(hits))
 }
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"net/http"
 	"time"
 
@@ -19,32 +22,58 @@ type RefreshResp struct {
 // @Produce json
 // @Param Authorization header string true "Bearer <refresh token>"
 // @Success 200 {object} RefreshResp
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem "Account suspended"
+// @Failure 500 {object} Problem
 // @Router /api/refresh [post]
 func (cfg *apiConf) HandlerTokenRefresh(w http.ResponseWriter, r *http.Request) {
 
 	bearer, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "couldnt get token", err)
+		respondWithAPIError(w, errMissingToken(err))
 		return
 	}
 
-	user, err := cfg.db.GetUserFromRefreshToken(r.Context(), bearer)
+	token, err := cfg.refreshAccessToken(r.Context(), callerFromRequest(r), bearer)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "token not in the database", err)
+		respondWithAPIError(w, err)
 		return
 	}
 
+	respondWithJSON(w, http.StatusOK, RefreshResp{
+		Token: token,
+	})
+}
+
+// refreshAccessToken exchanges a refresh token for a new access token.
+func (cfg *apiConf) refreshAccessToken(ctx context.Context, c caller, refreshToken string) (string, error) {
+	user, err := cfg.db.GetUserFromRefreshToken(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = errInvalidToken(err)
+		}
+		return "", err
+	}
+
+	// suspending revokes refresh tokens, this also covers tokens issued
+	// while a suspension was being created
+	if _, err := cfg.db.GetActiveSuspension(ctx, user); err == nil {
+		return "", errInvalidToken(errAccountSuspended)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return "", statusError(http.StatusInternalServerError, "couldn't check account status", err)
+	}
+
 	expires := time.Hour
 	token, err := auth.MakeJWT(user, cfg.JWTSecret, expires)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed to create token", err)
-		return
+		return "", statusError(http.StatusInternalServerError, "failed to create token", err)
 	}
 
-	respondWithJSON(w, http.StatusOK, RefreshResp{
-		Token: token,
+	cfg.auditCaller(ctx, c, auditEvent{
+		Type:      auditTokenRefreshed,
+		ActorID:   user,
+		SubjectID: user,
 	})
+	return token, nil
 }
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"net/http"
 
 	"github.com/tsironi93/WebServer/internal/auth"
@@ -14,20 +17,37 @@ import (
 // @Produce json
 // @Param Authorization header string true "Bearer <refresh token>"
 // @Success 204
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
 // @Router /api/revoke [post]
 func (cfg *apiConf) HandlerTokenRevoke(w http.ResponseWriter, r *http.Request) {
 	bearer, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "couldnt get token", err)
+		respondWithAPIError(w, errMissingToken(err))
 		return
 	}
 
-	if err := cfg.db.Revoke(r.Context(), bearer); err != nil {
-		respondWithError(w, http.StatusUnauthorized, "token not int he database", err)
+	if err := cfg.revokeRefreshToken(r.Context(), callerFromRequest(r), bearer); err != nil {
+		respondWithAPIError(w, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusNoContent, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConf) revokeRefreshToken(ctx context.Context, c caller, refreshToken string) error {
+	user, err := cfg.db.Revoke(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = errInvalidToken(err)
+		}
+		return err
+	}
+
+	cfg.auditCaller(ctx, c, auditEvent{
+		Type:      auditTokenRevoked,
+		ActorID:   user,
+		SubjectID: user,
+	})
+	return nil
 }
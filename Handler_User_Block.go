@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+type UserRelation struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HandlerUserBlock godoc
+// @Summary Block a user
+// @Description Blocked users can't see the blocker's chirps, and the blocker no longer sees theirs.
+// @Tags users
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param userID path string true "User UUID"
+// @Success 204
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/users/{userID}/block [post]
+func (cfg *apiConf) HandlerUserBlock(w http.ResponseWriter, r *http.Request) {
+	cfg.handleUserRelation(w, r, func(ctx context.Context, user, target uuid.UUID) error {
+		return cfg.db.BlockUser(ctx, database.BlockUserParams{
+			BlockerID: user,
+			BlockedID: target,
+		})
+	})
+}
+
+// HandlerUserUnblock godoc
+// @Summary Unblock a user
+// @Tags users
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param userID path string true "User UUID"
+// @Success 204
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/users/{userID}/block [delete]
+func (cfg *apiConf) HandlerUserUnblock(w http.ResponseWriter, r *http.Request) {
+	cfg.handleUserRelation(w, r, func(ctx context.Context, user, target uuid.UUID) error {
+		return cfg.db.UnblockUser(ctx, database.UnblockUserParams{
+			BlockerID: user,
+			BlockedID: target,
+		})
+	})
+}
+
+// HandlerUserMute godoc
+// @Summary Mute a user
+// @Description Hides the user's chirps from your listings. The muted user is not notified.
+// @Tags users
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param userID path string true "User UUID"
+// @Success 204
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/users/{userID}/mute [post]
+func (cfg *apiConf) HandlerUserMute(w http.ResponseWriter, r *http.Request) {
+	cfg.handleUserRelation(w, r, func(ctx context.Context, user, target uuid.UUID) error {
+		return cfg.db.MuteUser(ctx, database.MuteUserParams{
+			MuterID: user,
+			MutedID: target,
+		})
+	})
+}
+
+// HandlerUserUnmute godoc
+// @Summary Unmute a user
+// @Tags users
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param userID path string true "User UUID"
+// @Success 204
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/users/{userID}/mute [delete]
+func (cfg *apiConf) HandlerUserUnmute(w http.ResponseWriter, r *http.Request) {
+	cfg.handleUserRelation(w, r, func(ctx context.Context, user, target uuid.UUID) error {
+		return cfg.db.UnmuteUser(ctx, database.UnmuteUserParams{
+			MuterID: user,
+			MutedID: target,
+		})
+	})
+}
+
+// HandlerUserBlocksList godoc
+// @Summary List blocked users
+// @Tags users
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Success 200 {array} UserRelation
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/users/me/blocks [get]
+func (cfg *apiConf) HandlerUserBlocksList(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	blocks, err := cfg.db.ListBlockedUsers(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting blocked users", err)
+		return
+	}
+
+	resp := make([]UserRelation, len(blocks))
+	for i, b := range blocks {
+		resp[i] = UserRelation{
+			UserID:    b.BlockedID,
+			CreatedAt: b.CreatedAt,
+		}
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// HandlerUserMutesList godoc
+// @Summary List muted users
+// @Tags users
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Success 200 {array} UserRelation
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/users/me/mutes [get]
+func (cfg *apiConf) HandlerUserMutesList(w http.ResponseWriter, r *http.Request) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	mutes, err := cfg.db.ListMutedUsers(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting muted users", err)
+		return
+	}
+
+	resp := make([]UserRelation, len(mutes))
+	for i, m := range mutes {
+		resp[i] = UserRelation{
+			UserID:    m.MutedID,
+			CreatedAt: m.CreatedAt,
+		}
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// handleUserRelation authenticates the caller, parses {userID} and runs
+// apply with both IDs.
+func (cfg *apiConf) handleUserRelation(w http.ResponseWriter, r *http.Request, apply func(ctx context.Context, user, target uuid.UUID) error) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	target, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithAPIError(w, errInvalidID("userID", err))
+		return
+	}
+
+	if target == user {
+		respondWithError(w, http.StatusBadRequest, "can't do that to yourself", nil)
+		return
+	}
+
+	err = apply(r.Context(), user, target)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't update user relation", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"strings"
 	"time"
@@ -21,6 +20,24 @@ type User struct {
 	IsChirpyRed bool      `json:"is_chirpy_red"`
 }
 
+// UserV2 is the user object of API v2. It carries no credentials and no
+// email, so it can be shown to anyone.
+type UserV2 struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+}
+
+func userV2(user database.User) UserV2 {
+	return UserV2{
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		IsChirpyRed: user.IsChirpyRed,
+	}
+}
+
 type createUser struct {
 	Email          string `json:"email"`
 	HashedPassword string `json:"password"`
@@ -34,27 +51,80 @@ type createUser struct {
 // @Produce json
 // @Param user body createUser true "User creation payload"
 // @Success 201 {object} User
-// @Failure 400 {object} map[string]string "Bad request (invalid email or password)"
-// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 400 {object} Problem "Bad request (invalid email or password)"
+// @Failure 409 {object} Problem "Email is already registered"
+// @Failure 500 {object} Problem "Internal server error"
 // @Router /api/users [post]
 func (cfg *apiConf) HandlerUserCreate(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.registerUser(w, r)
+	if !ok {
+		return
+	}
+
+	expires := time.Hour
+	token, err := auth.MakeJWT(user.ID, cfg.JWTSecret, expires)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldnt create token", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, User{
+		ID:          user.ID,
+		Email:       user.Email,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		Token:       token,
+		IsChirpyRed: user.IsChirpyRed,
+	})
+}
+
+// HandlerUserCreateV2 godoc
+// @Summary Create a new user (v2)
+// @Description Creates a new user with email and password. Unlike v1 the response carries neither a token nor the email; log in to get tokens.
+// @Tags auth, users
+// @Accept json
+// @Produce json
+// @Param user body createUser true "User creation payload"
+// @Param Idempotency-Key header string false "Unique key to make retries safe"
+// @Success 201 {object} UserV2
+// @Failure 400 {object} Problem "Bad request (invalid email or password)"
+// @Failure 409 {object} Problem "Email is already registered"
+// @Failure 500 {object} Problem "Internal server error"
+// @Router /api/v2/users [post]
+func (cfg *apiConf) HandlerUserCreateV2(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.registerUser(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, userV2(user))
+}
 
+// registerUser validates the sign up payload and creates the user. On
+// failure the error response has been written.
+func (cfg *apiConf) registerUser(w http.ResponseWriter, r *http.Request) (database.User, bool) {
 	decoder := json.NewDecoder(r.Body)
 	create := createUser{}
 	if err := decoder.Decode(&create); err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err)
-		return
+		respondWithAPIError(w, errInvalidJSON(err))
+		return database.User{}, false
 	}
 
+	var invalid []FieldError
 	if !strings.Contains(create.Email, "@") || !strings.Contains(create.Email, ".") {
-		respondWithError(w, http.StatusBadRequest, "Wrong email format", fmt.Errorf("Not valid email"))
-		return
+		invalid = append(invalid, FieldError{Field: "email", Code: codeFieldInvalid, Message: "must be an email address"})
+	}
+	if create.HashedPassword == "" {
+		invalid = append(invalid, FieldError{Field: "password", Code: codeFieldRequired, Message: "is required"})
+	}
+	if len(invalid) > 0 {
+		respondWithAPIError(w, errValidation(invalid...))
+		return database.User{}, false
 	}
 
 	pass, err := auth.HashPassword(create.HashedPassword)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "couldnt hash the password", fmt.Errorf("Not valid email"))
-		return
+		respondWithError(w, http.StatusInternalServerError, "couldn't hash the password", err)
+		return database.User{}, false
 	}
 
 	user, err := cfg.db.CreateUser(r.Context(), database.CreateUserParams{
@@ -62,23 +132,13 @@ func (cfg *apiConf) HandlerUserCreate(w http.ResponseWriter, r *http.Request) {
 		HashedPassword: pass,
 	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to create user", err)
-		return
+		apiErr := dbError(err, "")
+		if apiErr.Code == codeAlreadyExists {
+			apiErr.Detail = "email is already registered"
+			apiErr.Fields = []FieldError{{Field: "email", Code: codeAlreadyExists, Message: "is already registered"}}
+		}
+		respondWithAPIError(w, apiErr)
+		return database.User{}, false
 	}
-
-	expires := time.Hour
-	token, err := auth.MakeJWT(user.ID, cfg.JWTSecret, expires)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "couldnt create token", err)
-		return
-	}
-
-	respondWithJSON(w, http.StatusCreated, User{
-		ID:          user.ID,
-		Email:       user.Email,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
-		Token:       token,
-		IsChirpyRed: user.IsChirpyRed,
-	})
+	return user, true
 }
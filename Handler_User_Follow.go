@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+// HandlerUserFollow godoc
+// @Summary Follow a user
+// @Description The user gets a follow notification. Following a user who blocked you, or whom you blocked, does nothing.
+// @Tags users
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param userID path string true "User UUID"
+// @Success 204
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/users/{userID}/follow [post]
+func (cfg *apiConf) HandlerUserFollow(w http.ResponseWriter, r *http.Request) {
+	cfg.handleUserRelation(w, r, func(ctx context.Context, user, target uuid.UUID) error {
+		n, err := cfg.db.FollowUser(ctx, database.FollowUserParams{
+			FollowerID: user,
+			FollowedID: target,
+		})
+		if err != nil {
+			return err
+		}
+		// only a new follow notifies, not a repeated one
+		if n > 0 {
+			afterCommit(ctx, func() {
+				cfg.notifier.notify(target, user, notificationFollow, uuid.Nil)
+			})
+		}
+		return nil
+	})
+}
+
+// HandlerUserUnfollow godoc
+// @Summary Unfollow a user
+// @Tags users
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param userID path string true "User UUID"
+// @Success 204
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/users/{userID}/follow [delete]
+func (cfg *apiConf) HandlerUserUnfollow(w http.ResponseWriter, r *http.Request) {
+	cfg.handleUserRelation(w, r, func(ctx context.Context, user, target uuid.UUID) error {
+		return cfg.db.UnfollowUser(ctx, database.UnfollowUserParams{
+			FollowerID: user,
+			FollowedID: target,
+		})
+	})
+}
+
+// HandlerUserFollowersList godoc
+// @Summary List your followers
+// @Description Returns the users following the authenticated user, most recent first.
+// @Tags users
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param offset query int false "Number of followers to skip"
+// @Success 200 {array} UserRelation
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/users/me/followers [get]
+func (cfg *apiConf) HandlerUserFollowersList(w http.ResponseWriter, r *http.Request) {
+	cfg.handleFollowList(w, r, func(ctx context.Context, user uuid.UUID, limit, offset int32) ([]UserRelation, error) {
+		follows, err := cfg.db.ListFollowers(ctx, database.ListFollowersParams{
+			UserID:    user,
+			RowLimit:  limit,
+			RowOffset: offset,
+		})
+		resp := make([]UserRelation, len(follows))
+		for i, f := range follows {
+			resp[i] = UserRelation{
+				UserID:    f.FollowerID,
+				CreatedAt: f.CreatedAt,
+			}
+		}
+		return resp, err
+	})
+}
+
+// HandlerUserFollowingList godoc
+// @Summary List the users you follow
+// @Description Returns the users the authenticated user follows, most recent first.
+// @Tags users
+// @Produce json
+// @Param Authorization header string true "Bearer <JWT token>"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param offset query int false "Number of users to skip"
+// @Success 200 {array} UserRelation
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/users/me/following [get]
+func (cfg *apiConf) HandlerUserFollowingList(w http.ResponseWriter, r *http.Request) {
+	cfg.handleFollowList(w, r, func(ctx context.Context, user uuid.UUID, limit, offset int32) ([]UserRelation, error) {
+		follows, err := cfg.db.ListFollowing(ctx, database.ListFollowingParams{
+			UserID:    user,
+			RowLimit:  limit,
+			RowOffset: offset,
+		})
+		resp := make([]UserRelation, len(follows))
+		for i, f := range follows {
+			resp[i] = UserRelation{
+				UserID:    f.FollowedID,
+				CreatedAt: f.CreatedAt,
+			}
+		}
+		return resp, err
+	})
+}
+
+// handleFollowList authenticates the caller, reads the page and responds
+// with what list returns for it.
+func (cfg *apiConf) handleFollowList(w http.ResponseWriter, r *http.Request, list func(ctx context.Context, user uuid.UUID, limit, offset int32) ([]UserRelation, error)) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	user, err := cfg.validateJWT(bearer)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	resp, err := list(r.Context(), user, limit, offset)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error getting follows", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,79 +26,206 @@ type LoginResponse struct {
 	IsChirpyRed  bool      `json:"is_chirpy_red"`
 }
 
+// LoginResponseV2 is the v2 login response: the tokens next to the public
+// user object.
+type LoginResponseV2 struct {
+	User         UserV2 `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
 // HandlerUserLogin godoc
 // @Summary User login
-// @Description Authenticate user with email and password, returns JWT and refresh token
+// @Description Authenticate user with email and password, returns JWT and refresh token. Failed attempts are counted per email and per client IP; after a few the next attempt has to wait, growing to a 15 minute lockout.
 // @Tags auth, users
 // @Accept json
 // @Produce json
 // @Param credentials body object true "Login credentials"
 // @Success 200 {object} LoginResponse
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem "Account suspended"
+// @Failure 429 {object} Problem "Too many failed attempts, see Retry-After"
+// @Failure 500 {object} Problem
 // @Router /api/login [post]
 func (cfg *apiConf) HandlerUserLogin(w http.ResponseWriter, r *http.Request) {
+	login, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
 
+	respondWithJSON(w, http.StatusOK, LoginResponse{
+		ID:           login.user.ID,
+		CreatedAt:    login.user.CreatedAt,
+		UpdatedAt:    login.user.UpdatedAt,
+		Email:        login.user.Email,
+		Token:        login.token,
+		RefreshToken: login.refreshToken,
+		IsChirpyRed:  login.user.IsChirpyRed,
+	})
+}
+
+// HandlerUserLoginV2 godoc
+// @Summary User login (v2)
+// @Description Same as v1, but the tokens are kept apart from the user object, which no longer carries the email.
+// @Tags auth, users
+// @Accept json
+// @Produce json
+// @Param credentials body object true "Login credentials"
+// @Success 200 {object} LoginResponseV2
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem "Account suspended"
+// @Failure 429 {object} Problem "Too many failed attempts, see Retry-After"
+// @Failure 500 {object} Problem
+// @Router /api/v2/login [post]
+func (cfg *apiConf) HandlerUserLoginV2(w http.ResponseWriter, r *http.Request) {
+	login, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, LoginResponseV2{
+		User:         userV2(login.user),
+		Token:        login.token,
+		RefreshToken: login.refreshToken,
+	})
+}
+
+type loginResult struct {
+	user         database.User
+	token        string
+	refreshToken string
+}
+
+// authenticate checks the credentials in the request body with login. On
+// failure the error response has been written.
+func (cfg *apiConf) authenticate(w http.ResponseWriter, r *http.Request) (loginResult, bool) {
 	type parameters struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
-	login := parameters{}
-	if err := decoder.Decode(&login); err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err)
-		return
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithAPIError(w, errInvalidJSON(err))
+		return loginResult{}, false
 	}
 
-	userID, err := cfg.db.GetUserIDByEmail(r.Context(), login.Email)
+	login, err := cfg.login(r.Context(), callerFromRequest(r), params.Email, params.Password)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "invalid email or password", err)
-		return
+		var locked *loginLockedError
+		if errors.As(err, &locked) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.wait.Seconds()))))
+		}
+		respondWithAPIError(w, err)
+		return loginResult{}, false
+	}
+	return login, true
+}
+
+// loginLockedError is returned by login while the email or the IP is locked
+// out.
+type loginLockedError struct {
+	wait time.Duration
+}
+
+func (e *loginLockedError) Error() string {
+	return "login locked for " + e.wait.String()
+}
+
+// login checks the credentials, applying the login throttle, and issues an
+// access and a refresh token. Errors are apiErrors, or a loginLockedError
+// wrapped in one. Failed attempts are audited outside of batch transactions,
+// a rollback mustn't hide them.
+func (cfg *apiConf) login(ctx context.Context, c caller, email, password string) (loginResult, error) {
+	wait, err := cfg.loginLockedFor(ctx, c.IP, email)
+	if err != nil {
+		return loginResult{}, statusError(http.StatusInternalServerError, "couldn't check login attempts", err)
+	}
+	if wait > 0 {
+		cfg.auditCaller(outsideBatch(ctx), c, auditEvent{
+			Type:    auditLoginFailed,
+			Details: map[string]any{"email": email, "reason": "locked"},
+		})
+		return loginResult{}, statusError(http.StatusTooManyRequests, "too many failed login attempts, try again later", &loginLockedError{wait: wait})
+	}
+
+	userID, err := cfg.db.GetUserIDByEmail(ctx, email)
+	found := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return loginResult{}, statusError(http.StatusInternalServerError, "couldn't look up user", err)
 	}
 
-	ok, err := auth.CheckPasswordHash(login.Password, userID.HashedPassword)
+	// unknown emails are checked against a dummy hash so they take as long
+	// as a wrong password and don't reveal which accounts exist
+	hash := dummyPasswordHash()
+	if found {
+		hash = userID.HashedPassword
+	}
+	ok, err := auth.CheckPasswordHash(password, hash)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "error dehashing password", err)
-		return
+		return loginResult{}, statusError(http.StatusInternalServerError, "couldn't check password", err)
 	}
 
-	if !ok {
-		respondWithError(w, http.StatusUnauthorized, "invalid email or password", nil)
-		return
+	if !found || !ok {
+		if err := cfg.recordLoginFailure(ctx, c.IP, email); err != nil {
+			log.Println("record login failure:", err)
+		}
+
+		event := auditEvent{
+			Type:      auditLoginFailed,
+			SubjectID: userID.ID,
+			Details:   map[string]any{"reason": "wrong_password"},
+		}
+		if !found {
+			event.Details = map[string]any{"email": email, "reason": "unknown_email"}
+		}
+		cfg.auditCaller(outsideBatch(ctx), c, event)
+
+		return loginResult{}, statusError(http.StatusUnauthorized, "invalid email or password", nil)
+	}
+
+	if err := cfg.clearLoginFailures(ctx, email); err != nil {
+		log.Println("clear login failures:", err)
+	}
+
+	if _, err := cfg.db.GetActiveSuspension(ctx, userID.ID); err == nil {
+		cfg.auditCaller(outsideBatch(ctx), c, auditEvent{
+			Type:      auditLoginFailed,
+			SubjectID: userID.ID,
+			Details:   map[string]any{"reason": "suspended"},
+		})
+		return loginResult{}, statusError(http.StatusForbidden, "account suspended", nil)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return loginResult{}, statusError(http.StatusInternalServerError, "couldn't check account status", err)
 	}
 
 	expires := time.Hour
 	token, err := auth.MakeJWT(userID.ID, cfg.JWTSecret, expires)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed to create token", err)
-		return
+		return loginResult{}, statusError(http.StatusInternalServerError, "failed to create token", err)
 	}
 
 	refreshToken, err := auth.MakeRefreshToken()
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed to create refresh token", err)
-		return
+		return loginResult{}, statusError(http.StatusInternalServerError, "failed to create refresh token", err)
 	}
 
-	dbRefresh, err := cfg.db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+	dbRefresh, err := cfg.db.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
 		Token:  refreshToken,
 		UserID: userID.ID,
 	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "couldnt create refresh token", err)
-		log.Println("CreateRefreshToken error:", err)
-		return
+		return loginResult{}, statusError(http.StatusInternalServerError, "couldnt create refresh token", err)
 	}
 
-	respondWithJSON(w, http.StatusOK, LoginResponse{
-		ID:           userID.ID,
-		CreatedAt:    userID.CreatedAt,
-		UpdatedAt:    userID.UpdatedAt,
-		Email:        userID.Email,
-		Token:        token,
-		RefreshToken: dbRefresh.Token,
-		IsChirpyRed:  userID.IsChirpyRed,
+	cfg.auditCaller(ctx, c, auditEvent{
+		Type:      auditLoginSucceeded,
+		ActorID:   userID.ID,
+		SubjectID: userID.ID,
 	})
+
+	return loginResult{user: userID, token: token, refreshToken: dbRefresh.Token}, nil
 }
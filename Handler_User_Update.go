@@ -26,44 +26,60 @@ type ResponseUserUpdate struct {
 // @Param Authorization header string true "Bearer <JWT token>"
 // @Param user body RequestUserUpdate true "User update payload"
 // @Success 200 {object} ResponseUserUpdate
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /api/users [put]
 func (cfg *apiConf) HandlerUserUpdate(w http.ResponseWriter, r *http.Request) {
 	bearer, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "no bearer header", err)
+		respondWithAPIError(w, errMissingToken(err))
 		return
 	}
 
-	user, err := auth.ValidateJWT(bearer, cfg.JWTSecret)
+	user, err := cfg.validateJWT(bearer)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "invalid JWT", err)
+		respondWithAPIError(w, errInvalidToken(err))
 		return
 	}
 
 	decoder := json.NewDecoder(r.Body)
 	update := RequestUserUpdate{}
 	if err := decoder.Decode(&update); err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err)
+		respondWithAPIError(w, errInvalidJSON(err))
+		return
+	}
+
+	if update.Password == "" {
+		respondWithAPIError(w, errValidation(FieldError{Field: "password", Code: codeFieldRequired, Message: "is required"}))
 		return
 	}
 
 	pass, err := auth.HashPassword(update.Password)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "couldnt hash the password", err)
+		respondWithError(w, http.StatusInternalServerError, "couldn't hash the password", err)
 		return
 	}
 
-	err = cfg.db.UserUpdatePassword(r.Context(), database.UserUpdatePasswordParams{
+	n, err := cfg.db.UserUpdatePassword(r.Context(), database.UserUpdatePasswordParams{
 		ID:             user,
 		HashedPassword: pass,
 	})
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "user doesnt exists", err)
+		respondWithError(w, http.StatusInternalServerError, "couldn't update the password", err)
 		return
 	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "user not found", nil)
+		return
+	}
+
+	cfg.audit(r, auditEvent{
+		Type:      auditPasswordChanged,
+		ActorID:   user,
+		SubjectID: user,
+	})
 
 	respondWithJSON(w, http.StatusOK, ResponseUserUpdate{
 		Email: update.Email,
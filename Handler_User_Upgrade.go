@@ -25,39 +25,55 @@ type Data struct {
 // @Produce json
 // @Param Authorization header string true "ApiKey <key>"
 // @Param payload body Payload true "Webhook payload"
+// @Param Idempotency-Key header string false "Unique key to make retries safe"
 // @Success 204
-// @Failure 401 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
 // @Router /api/polka/webhooks [post]
 func (cfg *apiConf) HandlerUserUpgradeToRed(w http.ResponseWriter, r *http.Request) {
 
 	decoder := json.NewDecoder(r.Body)
 	param := Payload{}
 	if err := decoder.Decode(&param); err != nil {
-		respondWithError(w, http.StatusInternalServerError, "couldnt decode request", err)
+		respondWithAPIError(w, errInvalidJSON(err))
 		return
 	}
 
 	k, err := auth.GetAPIKey(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		respondWithError(w, http.StatusUnauthorized, "missing API key", err)
 		return
 	}
 
 	if k != cfg.PolkaKey {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		respondWithError(w, http.StatusUnauthorized, "invalid API key", nil)
 		return
 	}
 
 	if param.Event != "user.upgraded" {
-		respondWithError(w, http.StatusNoContent, "", nil)
+		// events we don't care about are acknowledged so Polka stops sending them
+		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	if err := cfg.db.UserUpgradeToChirpRed(r.Context(), param.Data.UserID); err != nil {
-		respondWithError(w, http.StatusNotFound, "failed to upgraded", err)
+	n, err := cfg.db.UserUpgradeToChirpRed(r.Context(), param.Data.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't upgrade user", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "user not found", nil)
 		return
 	}
 
-	respondWithJSON(w, http.StatusNoContent, nil)
+	cfg.audit(r, auditEvent{
+		Type:      auditUserUpgraded,
+		SubjectID: param.Data.UserID,
+		Details:   map[string]any{"source": "polka"},
+	})
+	afterCommit(r.Context(), func() {
+		cfg.notifier.notify(param.Data.UserID, uuid.Nil, notificationChirpyRed, uuid.Nil)
+	})
+
+	w.WriteHeader(http.StatusNoContent)
 }
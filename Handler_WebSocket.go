@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+	"github.com/tsironi93/WebServer/internal/pubsub"
+)
+
+const (
+	wsWriteWait          = 10 * time.Second
+	wsPongWait           = 60 * time.Second
+	wsPingPeriod         = (wsPongWait * 9) / 10
+	wsMaxMessageSize     = 4096
+	wsMaxSubscriptions   = 32
+	wsMaxConnsPerUser    = 5
+	wsOutgoingBufferSize = 16
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// wsClientMessage is what clients send: {"type":"subscribe","topic":"author:<uuid>"}.
+//
+// Topics:
+//   - notifications      the authenticated user's own notifications
+//   - chirp:<uuid>       events about a chirp, and replies to the first chirp of a thread
+//   - author:<uuid>      chirps created/deleted by an author
+//   - presence:<uuid>    online/offline changes of a user
+type wsClientMessage struct {
+	Type  string `json:"type"`
+	Topic string `json:"topic"`
+}
+
+type wsServerMessage struct {
+	Type  string        `json:"type"`
+	Topic string        `json:"topic,omitempty"`
+	Error string        `json:"error,omitempty"`
+	Event *pubsub.Event `json:"event,omitempty"`
+}
+
+type wsClient struct {
+	userID uuid.UUID
+	conn   *websocket.Conn
+	send   chan wsServerMessage
+	db     *database.Queries
+	// blocks hides events of users the client blocked or was blocked by
+	blocks *blockSet
+
+	mu     sync.Mutex
+	topics map[string]func(pubsub.Event) bool
+}
+
+// socketRegistry counts open connections per user on this instance so we can
+// enforce wsMaxConnsPerUser and publish presence changes. A slot is taken
+// before the upgrade so the limit can be answered with a 429, but only
+// upgraded connections count towards presence.
+type socketRegistry struct {
+	mu     sync.Mutex
+	conns  map[uuid.UUID]int
+	online map[uuid.UUID]int
+}
+
+func newSocketRegistry() *socketRegistry {
+	return &socketRegistry{
+		conns:  make(map[uuid.UUID]int),
+		online: make(map[uuid.UUID]int),
+	}
+}
+
+func (s *socketRegistry) acquire(userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conns[userID] >= wsMaxConnsPerUser {
+		return errors.New("too many connections")
+	}
+	s.conns[userID]++
+	return nil
+}
+
+// connected marks an acquired slot as an upgraded connection.
+func (s *socketRegistry) connected(userID uuid.UUID) (first bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.online[userID]++
+	return s.online[userID] == 1
+}
+
+// release frees a slot, and reports whether it was the user's last
+// upgraded connection.
+func (s *socketRegistry) release(userID uuid.UUID, connected bool) (last bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.conns[userID]--
+	if s.conns[userID] <= 0 {
+		delete(s.conns, userID)
+	}
+	if !connected {
+		return false
+	}
+	s.online[userID]--
+	if s.online[userID] <= 0 {
+		delete(s.online, userID)
+		return true
+	}
+	return false
+}
+
+// HandlerWebSocket godoc
+// @Summary WebSocket API for notifications and presence
+// @Description Upgrades to a WebSocket. Authenticate with a Bearer JWT in the Authorization header or the token query param, then send {"type":"subscribe","topic":"..."} messages. Topics: notifications, chirp:<uuid>, author:<uuid>, presence:<uuid>. Users who blocked each other see neither chirps nor presence of the other.
+// @Tags realtime
+// @Param Authorization header string false "Bearer <JWT token>"
+// @Param token query string false "JWT token, for clients that can't set headers"
+// @Success 101
+// @Failure 401 {object} Problem
+// @Failure 429 {object} Problem
+// @Router /api/ws [get]
+func (cfg *apiConf) HandlerWebSocket(w http.ResponseWriter, r *http.Request) {
+	tokenStr, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		tokenStr = r.URL.Query().Get("token")
+	}
+	if tokenStr == "" {
+		respondWithAPIError(w, errMissingToken(err))
+		return
+	}
+
+	userID, err := cfg.validateJWT(tokenStr)
+	if err != nil {
+		respondWithAPIError(w, errInvalidToken(err))
+		return
+	}
+
+	blocks, err := cfg.loadBlockSet(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't load blocks", err)
+		return
+	}
+
+	if err := cfg.sockets.acquire(userID); err != nil {
+		respondWithError(w, http.StatusTooManyRequests, "too many open connections", err)
+		return
+	}
+	connected := false
+	defer func() {
+		if cfg.sockets.release(userID, connected) {
+			cfg.publishPresence(context.Background(), pubsub.EventUserOffline, userID)
+		}
+	}()
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade already wrote the error response
+		log.Println("websocket upgrade:", err)
+		return
+	}
+	connected = true
+	if cfg.sockets.connected(userID) {
+		cfg.publishPresence(r.Context(), pubsub.EventUserOnline, userID)
+	}
+	defer conn.Close()
+
+	client := &wsClient{
+		userID: userID,
+		conn:   conn,
+		send:   make(chan wsServerMessage, wsOutgoingBufferSize),
+		db:     cfg.db,
+		blocks: blocks,
+		topics: make(map[string]func(pubsub.Event) bool),
+	}
+
+	sub, _ := cfg.broker.Subscribe(0, client.wants)
+	defer sub.Close()
+	go blocks.watch(r.Context(), cfg.db)
+
+	done := make(chan struct{})
+	go client.writePump(sub, done)
+	client.readPump(r.Context())
+	close(done)
+}
+
+// readPump handles subscribe/unsubscribe messages until the connection fails.
+func (c *wsClient) readPump(ctx context.Context) {
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		var msg wsClientMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Println("websocket read:", err)
+			}
+			return
+		}
+
+		reply := wsServerMessage{Type: "ack", Topic: msg.Topic}
+		var err error
+		switch msg.Type {
+		case "subscribe":
+			err = c.subscribe(ctx, msg.Topic)
+		case "unsubscribe":
+			c.unsubscribe(msg.Topic)
+		default:
+			err = fmt.Errorf("unknown message type %q", msg.Type)
+		}
+		if err != nil {
+			reply = wsServerMessage{Type: "error", Topic: msg.Topic, Error: err.Error()}
+		}
+
+		select {
+		case c.send <- reply:
+		default:
+			// the client isn't reading its replies, drop it
+			return
+		}
+	}
+}
+
+// writePump is the only goroutine writing to the connection. It forwards
+// broker events and replies, and keeps the connection alive with pings.
+func (c *wsClient) writePump(sub *pubsub.Subscription, done <-chan struct{}) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+	defer c.conn.Close()
+
+	for {
+		var msg wsServerMessage
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+			continue
+		case msg = <-c.send:
+		case e, ok := <-sub.C:
+			if !ok {
+				c.conn.WriteControl(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "slow consumer"),
+					time.Now().Add(wsWriteWait),
+				)
+				return
+			}
+			msg = wsServerMessage{Type: "event", Topic: c.topicFor(e), Event: &e}
+		}
+
+		c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		if err := c.conn.WriteJSON(msg); err != nil {
+			return
+		}
+	}
+}
+
+func (c *wsClient) subscribe(ctx context.Context, topic string) error {
+	match, err := c.parseTopic(ctx, topic)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.topics[topic]; !ok && len(c.topics) >= wsMaxSubscriptions {
+		return errors.New("too many subscriptions")
+	}
+	c.topics[topic] = match
+	return nil
+}
+
+func (c *wsClient) unsubscribe(topic string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.topics, topic)
+}
+
+func (c *wsClient) wants(e pubsub.Event) bool {
+	return c.topicFor(e) != ""
+}
+
+func (c *wsClient) topicFor(e pubsub.Event) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for topic, match := range c.topics {
+		if match(e) {
+			return topic
+		}
+	}
+	return ""
+}
+
+func (c *wsClient) parseTopic(ctx context.Context, topic string) (func(pubsub.Event) bool, error) {
+	if topic == "notifications" {
+		return func(e pubsub.Event) bool {
+			return e.Type == pubsub.EventNotification && e.RecipientID == c.userID
+		}, nil
+	}
+
+	kind, rawID, ok := strings.Cut(topic, ":")
+	if !ok {
+		return nil, fmt.Errorf("unknown topic %q", topic)
+	}
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse id in topic %q", topic)
+	}
+
+	if kind == "author" || kind == "presence" {
+		blocked, err := c.db.HasBlockWith(ctx, database.HasBlockWithParams{UserID: c.userID, OtherIds: []uuid.UUID{id}})
+		if err != nil {
+			log.Println("websocket subscribe:", err)
+			return nil, errors.New("couldn't check blocks")
+		}
+		if blocked {
+			return nil, fmt.Errorf("can't subscribe to %q, one of you blocked the other", topic)
+		}
+	}
+
+	switch kind {
+	case "chirp":
+		return func(e pubsub.Event) bool {
+			// replies reach the topic of the first chirp of their thread
+			return isChirpEvent(e) && (e.ChirpID == id || e.ThreadID == id) && !c.blocks.hides(e.AuthorID)
+		}, nil
+	case "author":
+		return func(e pubsub.Event) bool {
+			return isChirpEvent(e) && e.AuthorID == id && !c.blocks.hides(e.AuthorID)
+		}, nil
+	case "presence":
+		return func(e pubsub.Event) bool {
+			return (e.Type == pubsub.EventUserOnline || e.Type == pubsub.EventUserOffline) && e.AuthorID == id && !c.blocks.hides(e.AuthorID)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown topic %q", topic)
+}
+
+func isChirpEvent(e pubsub.Event) bool {
+	return e.Type == pubsub.EventChirpCreated || e.Type == pubsub.EventChirpDeleted
+}
+
+func (cfg *apiConf) publishPresence(ctx context.Context, eventType string, userID uuid.UUID) {
+	if err := cfg.events.Publish(ctx, pubsub.Event{
+		Type:     eventType,
+		AuthorID: userID,
+	}); err != nil {
+		log.Println("couldn't publish presence event:", err)
+	}
+}
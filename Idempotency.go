@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+	maxIdempotentBodyBytes  = 1 << 20
+)
+
+// idempotent makes retries of next safe. A request with an Idempotency-Key
+// header runs once; repeating it with the same key and body replays the
+// stored response, with a different body it is rejected with 422. Keys are
+// scoped to the user of the JWT, see idempotencyScope for anonymous callers,
+// and expire after 24 hours. Requests without the header are passed through.
+//
+// Stored responses are replayed to anyone who has the key, so don't wrap
+// routes whose responses carry credentials.
+func (cfg *apiConf) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			respondWithError(w, http.StatusBadRequest, "Idempotency-Key is too long", nil)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+		if err != nil {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "request body is too large", err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// keyed, so the stored fingerprint can't be used to guess passwords
+		// in the body
+		fingerprint := cfg.idempotencyMAC(r.Method+" "+r.URL.Path+"\n", string(body))
+
+		scope := cfg.idempotencyScope(r)
+		_, err = cfg.db.ClaimIdempotencyKey(r.Context(), database.ClaimIdempotencyKeyParams{
+			Scope:       scope,
+			Key:         key,
+			Fingerprint: fingerprint,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			cfg.replayIdempotent(w, r, scope, key, fingerprint)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "couldn't store idempotency key", err)
+			return
+		}
+
+		rec := &responseRecorder{header: w.Header().Clone(), status: http.StatusOK}
+		next(rec, r)
+
+		if rec.status >= 500 {
+			// let the client retry server errors for real
+			if err := cfg.db.ReleaseIdempotencyKey(r.Context(), database.ReleaseIdempotencyKeyParams{
+				Scope: scope,
+				Key:   key,
+			}); err != nil {
+				log.Println("release idempotency key:", err)
+			}
+		} else if err := cfg.db.CompleteIdempotencyKey(r.Context(), database.CompleteIdempotencyKeyParams{
+			ResponseStatus:      sql.NullInt32{Int32: int32(rec.status), Valid: true},
+			ResponseContentType: sql.NullString{String: rec.header.Get("Content-Type"), Valid: true},
+			ResponseBody:        rec.body.Bytes(),
+			Scope:               scope,
+			Key:                 key,
+		}); err != nil {
+			log.Println("complete idempotency key:", err)
+		}
+
+		rec.flush(w)
+	}
+}
+
+// replayIdempotent answers a request whose key is already taken.
+func (cfg *apiConf) replayIdempotent(w http.ResponseWriter, r *http.Request, scope, key, fingerprint string) {
+	stored, err := cfg.db.GetIdempotencyKey(r.Context(), database.GetIdempotencyKeyParams{
+		Scope: scope,
+		Key:   key,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't load idempotency key", err)
+		return
+	}
+
+	if stored.Fingerprint != fingerprint {
+		respondWithError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request", nil)
+		return
+	}
+	if !stored.ResponseStatus.Valid {
+		w.Header().Set("Retry-After", "1")
+		respondWithError(w, http.StatusConflict, "a request with this Idempotency-Key is still in progress", nil)
+		return
+	}
+
+	if stored.ResponseContentType.String != "" {
+		w.Header().Set("Content-Type", stored.ResponseContentType.String)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(int(stored.ResponseStatus.Int32))
+	w.Write(stored.ResponseBody)
+}
+
+// idempotencyScope is the namespace of the request's key. Anonymous callers
+// only share keys with themselves on the same route: webhook senders by
+// their API key, everyone else by IP.
+func (cfg *apiConf) idempotencyScope(r *http.Request) string {
+	if bearer, err := auth.GetBearerToken(r.Header); err == nil {
+		if userID, err := cfg.validateJWT(bearer); err == nil {
+			return "user:" + userID.String()
+		}
+	}
+	if apiKey, err := auth.GetAPIKey(r.Header); err == nil {
+		return "anonymous:" + r.Pattern + ":key:" + cfg.idempotencyMAC(apiKey)
+	}
+	return "anonymous:" + r.Pattern + ":ip:" + clientIP(r)
+}
+
+// idempotencyMAC is an HMAC of parts keyed with the server secret, for
+// storing request data that mustn't be readable from the database.
+func (cfg *apiConf) idempotencyMAC(parts ...string) string {
+	mac := hmac.New(sha256.New, []byte(cfg.JWTSecret))
+	for _, p := range parts {
+		io.WriteString(mac, p)
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// responseRecorder buffers a response so it can be stored before it is
+// sent.
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *responseRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.wroteHeader {
+		return
+	}
+	rec.status = status
+	rec.wroteHeader = true
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return rec.body.Write(b)
+}
+
+func (rec *responseRecorder) flush(w http.ResponseWriter) {
+	for k, v := range rec.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(rec.status)
+	w.Write(rec.body.Bytes())
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+// runPurgeJob hard-deletes chirps that have been in the trash for longer than
+// chirpRetention, and clears out expired login throttles and idempotency
+// keys. Running it on every instance is fine, the DELETEs are idempotent.
+func (cfg *apiConf) runPurgeJob(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		n, err := cfg.db.PurgeDeletedChirps(ctx, chirpRetention.Seconds())
+		if err != nil {
+			log.Println("purge deleted chirps:", err)
+		} else if n > 0 {
+			log.Printf("purged %d deleted chirps", n)
+		}
+
+		if _, err := cfg.db.DeleteStaleLoginThrottles(ctx, loginFailureWindow.Seconds()); err != nil {
+			log.Println("delete stale login throttles:", err)
+		}
+
+		if _, err := cfg.db.DeleteExpiredIdempotencyKeys(ctx); err != nil {
+			log.Println("delete expired idempotency keys:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runScheduler publishes scheduled chirps once their publish_at has passed.
+// Drafts are claimed with FOR UPDATE SKIP LOCKED and deleted in the same
+// transaction that creates the chirp, so with several instances running each
+// draft is still published exactly once.
+func (cfg *apiConf) runScheduler(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := cfg.publishDueDrafts(ctx, 50)
+			if err != nil {
+				log.Println("publish scheduled chirps:", err)
+			}
+			// a full batch means there may be more waiting
+			if err != nil || n < 50 {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runPollFreezer stores the final results of expired polls. The UPDATE only
+// touches polls that aren't frozen yet, so it's safe on every instance.
+func (cfg *apiConf) runPollFreezer(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		if _, err := cfg.db.FreezeExpiredPolls(ctx); err != nil {
+			log.Println("freeze expired polls:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConf) publishDueDrafts(ctx context.Context, batch int32) (int, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	drafts, err := qtx.ClaimDueChirpDrafts(ctx, batch)
+	if err != nil {
+		return 0, err
+	}
+
+	chirps := make([]database.Chirp, 0, len(drafts))
+	for _, d := range drafts {
+		chirp, err := qtx.CreateChirp(ctx, database.CreateChirpParams{
+			Body:   d.Body,
+			UserID: d.UserID,
+		})
+		if err != nil {
+			return 0, err
+		}
+		if err := qtx.DeleteClaimedChirpDraft(ctx, d.ID); err != nil {
+			return 0, err
+		}
+		chirps = append(chirps, chirp)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	for _, c := range chirps {
+		cfg.chirpCreated(ctx, c)
+	}
+	return len(drafts), nil
+}
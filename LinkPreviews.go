@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/tsironi93/WebServer/internal/database"
+	"github.com/tsironi93/WebServer/internal/unfurl"
+)
+
+const (
+	maxPreviewsPerChirp = 2
+	linkPreviewTTL      = 24 * time.Hour
+	failedPreviewTTL    = time.Hour
+)
+
+// previewCache keeps unfurl results in the link_previews table so every
+// instance shares them.
+type previewCache struct {
+	db *database.Queries
+}
+
+func (c previewCache) Get(ctx context.Context, rawURL string) (*unfurl.Preview, bool, error) {
+	// stale previews come back as no rows and are fetched again
+	row, err := c.db.GetLinkPreview(ctx, database.GetLinkPreviewParams{
+		URL:              rawURL,
+		MaxAgeSecs:       linkPreviewTTL.Seconds(),
+		FailedMaxAgeSecs: failedPreviewTTL.Seconds(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !row.Ok {
+		return nil, true, nil
+	}
+	return toPreview(row), true, nil
+}
+
+func (c previewCache) Set(ctx context.Context, rawURL string, p *unfurl.Preview) error {
+	params := database.UpsertLinkPreviewParams{URL: rawURL}
+	if p != nil {
+		params.Ok = true
+		params.Title = p.Title
+		params.Description = p.Description
+		params.ImageURL = p.ImageURL
+		params.SiteName = p.SiteName
+	}
+	return c.db.UpsertLinkPreview(ctx, params)
+}
+
+func toPreview(row database.LinkPreview) *unfurl.Preview {
+	return &unfurl.Preview{
+		URL:         row.URL,
+		Title:       row.Title,
+		Description: row.Description,
+		ImageURL:    row.ImageURL,
+		SiteName:    row.SiteName,
+	}
+}
+
+// linkPreviewer unfurls the links of new chirps in the background, the
+// request that created the chirp never waits for a remote site.
+type linkPreviewer struct {
+	unfurler *unfurl.Unfurler
+	jobs     chan string
+}
+
+func newLinkPreviewer(unfurler *unfurl.Unfurler, queueSize int) *linkPreviewer {
+	return &linkPreviewer{
+		unfurler: unfurler,
+		jobs:     make(chan string, queueSize),
+	}
+}
+
+func (p *linkPreviewer) run(ctx context.Context, workers int) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case rawURL := <-p.jobs:
+					if _, err := p.unfurler.Unfurl(ctx, rawURL); err != nil && !errors.Is(err, unfurl.ErrNoPreview) {
+						log.Printf("unfurl %s: %v", rawURL, err)
+					}
+				}
+			}
+		}()
+	}
+}
+
+func (p *linkPreviewer) chirpCreated(chirp database.Chirp) {
+	for _, rawURL := range unfurl.FindURLs(chirp.Body, maxPreviewsPerChirp) {
+		select {
+		case p.jobs <- rawURL:
+		default:
+			log.Println("link preview queue full, dropping", rawURL)
+		}
+	}
+}
+
+// attachLinkPreviews adds the previews that are ready. Links that are still
+// being fetched, or have no preview, are left out.
+func (cfg *apiConf) attachLinkPreviews(ctx context.Context, chirps []Chirp) error {
+	urls := previewURLs(chirps)
+	if len(urls) == 0 {
+		return nil
+	}
+
+	rows, err := cfg.db.GetLinkPreviews(ctx, urls)
+	if err != nil {
+		return err
+	}
+	previews := make(map[string]*unfurl.Preview, len(rows))
+	for _, row := range rows {
+		previews[row.URL] = toPreview(row)
+	}
+
+	for i, c := range chirps {
+		for _, u := range unfurl.FindURLs(c.Body, maxPreviewsPerChirp) {
+			if p, ok := previews[u]; ok {
+				chirps[i].LinkPreviews = append(chirps[i].LinkPreviews, *p)
+			}
+		}
+	}
+	return nil
+}
+
+// previewURLs are the links of chirps that get preview cards.
+func previewURLs(chirps []Chirp) []string {
+	var urls []string
+	for _, c := range chirps {
+		urls = append(urls, unfurl.FindURLs(c.Body, maxPreviewsPerChirp)...)
+	}
+	return urls
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+const (
+	throttleScopeEmail = "email"
+	throttleScopeIP    = "ip"
+
+	// loginFailureWindow is how long a failure counts against the next
+	// attempts. Stale rows are cleaned up by the purge job.
+	loginFailureWindow = 15 * time.Minute
+)
+
+// loginPolicy turns a number of consecutive failures into how long the next
+// attempt has to wait. The first free failures cost nothing, then the wait
+// doubles from a second up to lockout, and at lockAt failures it is lockout.
+type loginPolicy struct {
+	free    int32
+	lockAt  int32
+	lockout time.Duration
+}
+
+var (
+	// per email, so guessing one account's password is slow
+	emailLoginPolicy = loginPolicy{free: 3, lockAt: 10, lockout: 15 * time.Minute}
+	// per IP, so trying one password against many accounts is slow too
+	ipLoginPolicy = loginPolicy{free: 20, lockAt: 50, lockout: 15 * time.Minute}
+)
+
+func (p loginPolicy) wait(failures int32) time.Duration {
+	if failures >= p.lockAt {
+		return p.lockout
+	}
+	if failures <= p.free {
+		return 0
+	}
+	// the shift is capped so it can't overflow before the min kicks in
+	shift := min(failures-p.free-1, 20)
+	return min(time.Second<<shift, p.lockout)
+}
+
+func throttleEmailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// dummyPasswordHash is checked against for unknown emails, so they take as
+// long to reject as a wrong password.
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, err := auth.HashPassword("not the password you are looking for")
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
+// loginLockedFor returns how long the email or the client IP is still locked
+// out, 0 when a login may be attempted.
+func (cfg *apiConf) loginLockedFor(ctx context.Context, ip, email string) (time.Duration, error) {
+	secs, err := cfg.db.GetLoginLockWait(ctx, database.GetLoginLockWaitParams{
+		Email: throttleEmailKey(email),
+		IP:    ip,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(secs * float64(time.Second)), nil
+}
+
+// recordLoginFailure counts a failed attempt for the email and the client IP
+// and locks them when their policy says so. The count sticks even when the
+// attempt was part of an atomic batch that rolls back.
+func (cfg *apiConf) recordLoginFailure(ctx context.Context, ip, email string) error {
+	ctx = outsideBatch(ctx)
+	for _, t := range []struct {
+		scope  string
+		key    string
+		policy loginPolicy
+	}{
+		{throttleScopeEmail, throttleEmailKey(email), emailLoginPolicy},
+		{throttleScopeIP, ip, ipLoginPolicy},
+	} {
+		failures, err := cfg.db.RecordLoginFailure(ctx, database.RecordLoginFailureParams{
+			Scope:      t.scope,
+			Key:        t.key,
+			WindowSecs: loginFailureWindow.Seconds(),
+		})
+		if err != nil {
+			return err
+		}
+
+		wait := t.policy.wait(failures)
+		if wait == 0 {
+			continue
+		}
+		if err := cfg.db.LockLogin(ctx, database.LockLoginParams{
+			WaitSecs: wait.Seconds(),
+			Scope:    t.scope,
+			Key:      t.key,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearLoginFailures forgets the failures of the email after a successful
+// login. The IP keeps its count, logging into your own account shouldn't
+// reset a spraying attack. Like recordLoginFailure it writes outside an
+// atomic batch: a row cleared in the batch transaction stays locked until the
+// batch ends, and a later failure in the same batch would wait on it forever.
+func (cfg *apiConf) clearLoginFailures(ctx context.Context, email string) error {
+	ctx = outsideBatch(ctx)
+	_, err := cfg.db.ClearLoginFailures(ctx, database.ClearLoginFailuresParams{
+		Scope: throttleScopeEmail,
+		Key:   throttleEmailKey(email),
+	})
+	return err
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLoginPolicyWait(t *testing.T) {
+	policy := loginPolicy{free: 3, lockAt: 10, lockout: 15 * time.Minute}
+
+	tests := []struct {
+		name     string
+		policy   loginPolicy
+		failures int32
+		want     time.Duration
+	}{
+		{name: "no failures", policy: policy, failures: 0, want: 0},
+		{name: "last free failure", policy: policy, failures: 3, want: 0},
+		{name: "first paid failure", policy: policy, failures: 4, want: time.Second},
+		{name: "doubles", policy: policy, failures: 5, want: 2 * time.Second},
+		{name: "just before lockAt", policy: policy, failures: 9, want: 32 * time.Second},
+		{name: "at lockAt", policy: policy, failures: 10, want: 15 * time.Minute},
+		{name: "past lockAt", policy: policy, failures: 11, want: 15 * time.Minute},
+		{
+			name:     "capped by lockout before lockAt",
+			policy:   loginPolicy{free: 0, lockAt: 100, lockout: time.Minute},
+			failures: 20,
+			want:     time.Minute,
+		},
+		{
+			name:     "shift doesn't overflow",
+			policy:   loginPolicy{free: 0, lockAt: math.MaxInt32, lockout: 15 * time.Minute},
+			failures: math.MaxInt32 - 1,
+			want:     15 * time.Minute,
+		},
+		{
+			name:     "shift is capped at 20",
+			policy:   loginPolicy{free: 0, lockAt: 1000, lockout: 100 * 24 * time.Hour},
+			failures: 64,
+			want:     time.Second << 20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.wait(tt.failures); got != tt.want {
+				t.Errorf("wait(%d) = %v, want %v", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/database"
+	"github.com/tsironi93/WebServer/internal/pubsub"
+)
+
+const (
+	notificationReply     = "reply"
+	notificationMention   = "mention"
+	notificationFollow    = "follow"
+	notificationChirpyRed = "chirpy_red"
+)
+
+var notificationTypes = []string{
+	notificationReply,
+	notificationMention,
+	notificationFollow,
+	notificationChirpyRed,
+}
+
+// notifier generates notifications off the request path. Handlers enqueue
+// work and return; a small pool of workers writes the rows and pushes them
+// to connected clients.
+type notifier struct {
+	db     *database.Queries
+	events pubsub.Publisher
+	jobs   chan func(ctx context.Context)
+}
+
+func newNotifier(db *database.Queries, events pubsub.Publisher, queueSize int) *notifier {
+	return &notifier{
+		db:     db,
+		events: events,
+		jobs:   make(chan func(ctx context.Context), queueSize),
+	}
+}
+
+func (n *notifier) run(ctx context.Context, workers int) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-n.jobs:
+					job(ctx)
+				}
+			}
+		}()
+	}
+}
+
+func (n *notifier) enqueue(job func(ctx context.Context)) {
+	select {
+	case n.jobs <- job:
+	default:
+		log.Println("notification queue full, dropping job")
+	}
+}
+
+// notify queues a single notification for userID. actorID and chirpID may be
+// uuid.Nil.
+func (n *notifier) notify(userID, actorID uuid.UUID, kind string, chirpID uuid.UUID) {
+	n.enqueue(func(ctx context.Context) {
+		n.create(ctx, userID, actorID, kind, chirpID)
+	})
+}
+
+// chirpCreated queues a reply notification for the author of the chirp it
+// answers, and mention notifications for every other user mentioned in the
+// chirp as @<email>.
+func (n *notifier) chirpCreated(chirp database.Chirp) {
+	n.enqueue(func(ctx context.Context) {
+		// the reply notification covers a mention of the same user
+		notified := uuid.Nil
+		if chirp.ReplyToID.Valid {
+			parent, err := n.db.GetSingleChirp(ctx, chirp.ReplyToID.UUID)
+			if err == nil && parent.UserID != chirp.UserID {
+				n.create(ctx, parent.UserID, chirp.UserID, notificationReply, chirp.ID)
+				notified = parent.UserID
+			}
+		}
+
+		for _, email := range findMentions(chirp.Body) {
+			user, err := n.db.GetUserIDByEmail(ctx, email)
+			if err != nil {
+				continue
+			}
+			if user.ID == chirp.UserID || user.ID == notified {
+				continue
+			}
+			n.create(ctx, user.ID, chirp.UserID, notificationMention, chirp.ID)
+		}
+	})
+}
+
+func (n *notifier) create(ctx context.Context, userID, actorID uuid.UUID, kind string, chirpID uuid.UUID) {
+	dbNotification, err := n.db.CreateNotification(ctx, database.CreateNotificationParams{
+		UserID:  userID,
+		ActorID: uuid.NullUUID{UUID: actorID, Valid: actorID != uuid.Nil},
+		Type:    kind,
+		ChirpID: uuid.NullUUID{UUID: chirpID, Valid: chirpID != uuid.Nil},
+	})
+	// no row means the user turned this type off
+	if errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+	if err != nil {
+		log.Println("couldn't create notification:", err)
+		return
+	}
+
+	data, err := json.Marshal(toNotification(dbNotification))
+	if err != nil {
+		log.Println("couldn't marshal notification:", err)
+		return
+	}
+
+	if err := n.events.Publish(ctx, pubsub.Event{
+		Type:        pubsub.EventNotification,
+		AuthorID:    actorID,
+		ChirpID:     chirpID,
+		RecipientID: userID,
+		Data:        data,
+	}); err != nil {
+		log.Println("couldn't publish notification:", err)
+	}
+}
+
+func findMentions(body string) []string {
+	seen := make(map[string]struct{})
+	var mentions []string
+	for _, word := range strings.Fields(body) {
+		email, ok := strings.CutPrefix(word, "@")
+		if !ok {
+			continue
+		}
+		email = strings.TrimRight(email, ".,!?:;)")
+		if !strings.Contains(email, "@") {
+			continue
+		}
+		if _, ok := seen[email]; ok {
+			continue
+		}
+		seen[email] = struct{}{}
+		mentions = append(mentions, email)
+	}
+	return mentions
+}
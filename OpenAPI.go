@@ -0,0 +1,96 @@
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/tsironi93/WebServer/internal/openapi"
+)
+
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+func loadOpenAPI() (*openapi.Validator, error) {
+	doc, err := openapi.Load(openAPISpec)
+	if err != nil {
+		return nil, err
+	}
+	return openapi.NewValidator(doc), nil
+}
+
+// HandlerOpenAPISpec godoc
+// @Summary This OpenAPI description
+// @Tags docs
+// @Produce application/yaml
+// @Success 200 {string} string
+// @Router /api/openapi.yaml [get]
+func HandlerOpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(http.StatusOK)
+	w.Write(openAPISpec)
+}
+
+// middlewareOpenAPI validates requests to routes described in openapi.yaml
+// before they reach next, so handlers only see well-formed input. With
+// validateResponses set, responses are checked too and a mismatch becomes a
+// 500; that is for tests and development, it buffers every response.
+func (cfg *apiConf) middlewareOpenAPI(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		method, path, _ := strings.Cut(pattern, " ")
+		route := cfg.spec.Route(method, path)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err := route.ValidateRequest(r); err != nil {
+			respondWithAPIError(w, specError(err))
+			return
+		}
+
+		if !cfg.validateResponses || route.Streams() || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &responseRecorder{header: w.Header().Clone(), status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if err := route.ValidateResponse(rec.status, rec.header, rec.body.Bytes()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "response doesn't match openapi.yaml", err)
+			return
+		}
+		rec.flush(w)
+	})
+}
+
+// specError turns a validation failure into the problem we send.
+func specError(err error) *apiError {
+	var specErr *openapi.Error
+	if !errors.As(err, &specErr) {
+		return &apiError{Status: http.StatusInternalServerError, Code: codeInternal, Detail: "internal server error", Err: err}
+	}
+
+	switch specErr.Kind {
+	case openapi.KindInvalidJSON:
+		return errInvalidJSON(err)
+	case openapi.KindUnsupportedMediaType:
+		return &apiError{Status: http.StatusUnsupportedMediaType, Code: codeUnsupportedMediaType, Detail: "request body must be application/json", Err: err}
+	case openapi.KindTooLarge:
+		return &apiError{Status: http.StatusRequestEntityTooLarge, Code: codePayloadTooLarge, Detail: "request body is too large", Err: err}
+	}
+
+	fields := make([]FieldError, len(specErr.Fields))
+	for i, f := range specErr.Fields {
+		// path parameters are IDs, report them like the handlers do
+		if f.In == "path" {
+			return errInvalidID(f.Field, err)
+		}
+		fields[i] = FieldError{Field: f.Field, Code: f.Code, Message: f.Message}
+	}
+	apiErr := errValidation(fields...)
+	apiErr.Err = err
+	return apiErr
+}
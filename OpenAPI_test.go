@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+// stubDB is a database/sql driver that answers each sqlc query, by its
+// name, with canned rows and every other query with none.
+type stubDB map[string][][]driver.Value
+
+func (s stubDB) Connect(context.Context) (driver.Conn, error) { return stubConn{s}, nil }
+func (s stubDB) Driver() driver.Driver                        { return s }
+func (s stubDB) Open(string) (driver.Conn, error)             { return stubConn{s}, nil }
+
+type stubConn struct{ db stubDB }
+
+func (c stubConn) Prepare(query string) (driver.Stmt, error) {
+	// sqlc queries start with "-- name: GetChirps :many"
+	name := ""
+	if fields := strings.Fields(query); len(fields) > 2 && fields[0] == "--" {
+		name = fields[2]
+	}
+	return stubStmt{rows: c.db[name]}, nil
+}
+func (c stubConn) Close() error              { return nil }
+func (c stubConn) Begin() (driver.Tx, error) { return stubTx{}, nil }
+
+type stubTx struct{}
+
+func (stubTx) Commit() error   { return nil }
+func (stubTx) Rollback() error { return nil }
+
+type stubStmt struct{ rows [][]driver.Value }
+
+func (s stubStmt) Close() error                               { return nil }
+func (s stubStmt) NumInput() int                              { return -1 }
+func (s stubStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(0), nil }
+func (s stubStmt) Query([]driver.Value) (driver.Rows, error)  { return &stubRows{rows: s.rows}, nil }
+
+type stubRows struct{ rows [][]driver.Value }
+
+func (r *stubRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	columns := make([]string, len(r.rows[0]))
+	for i := range columns {
+		columns[i] = fmt.Sprintf("c%d", i)
+	}
+	return columns
+}
+func (r *stubRows) Close() error { return nil }
+func (r *stubRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// TestResponsesMatchSpec serves a few routes through middlewareOpenAPI with
+// response validation on, so a handler that drifts from openapi.yaml fails
+// with a 500 here rather than in a client.
+func TestResponsesMatchSpec(t *testing.T) {
+	spec, err := loadOpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const secret = "test-secret"
+	userID, chirpID := uuid.New(), uuid.New()
+	token, err := auth.MakeJWT(userID, secret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	chirpRow := []driver.Value{chirpID.String(), now, now, "hello", userID.String(), nil, nil, nil, nil, nil}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		bearer bool
+		rows   stubDB
+		want   int
+	}{
+		{name: "health", method: http.MethodGet, path: "/api/healthz", want: http.StatusOK},
+		{
+			name:   "chirp list",
+			method: http.MethodGet,
+			path:   "/api/chirps?sort=desc",
+			rows:   stubDB{"GetChirps": {chirpRow}, "GetChirpsLastModified": {{now}}},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "empty chirp list",
+			method: http.MethodGet,
+			path:   "/api/chirps",
+			rows:   stubDB{"GetChirpsLastModified": {{time.Time{}}}},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "single chirp",
+			method: http.MethodGet,
+			path:   "/api/chirps/" + chirpID.String(),
+			rows:   stubDB{"GetVisibleChirp": {chirpRow}, "GetChirpLastModified": {{now}}},
+			want:   http.StatusOK,
+		},
+		{name: "missing chirp", method: http.MethodGet, path: "/api/chirps/" + chirpID.String(), want: http.StatusNotFound},
+		{name: "invalid chirp ID", method: http.MethodGet, path: "/api/chirps/nope", want: http.StatusBadRequest},
+		{
+			name:   "delete a chirp deleted meanwhile",
+			method: http.MethodDelete,
+			path:   "/api/chirps/" + chirpID.String(),
+			bearer: true,
+			rows:   stubDB{"GetSingleChirp": {chirpRow}},
+			want:   http.StatusNotFound,
+		},
+		{name: "create without token", method: http.MethodPost, path: "/api/chirps", body: `{"body":"hi"}`, want: http.StatusUnauthorized},
+		{
+			name:   "blocked users",
+			method: http.MethodGet,
+			path:   "/api/users/me/blocks",
+			bearer: true,
+			rows:   stubDB{"ListBlockedUsers": {{userID.String(), uuid.NewString(), now}}},
+			want:   http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &apiConf{
+				db:                database.New(sql.OpenDB(tt.rows)),
+				JWTSecret:         secret,
+				suspensions:       newSuspensionCache(),
+				spec:              spec,
+				validateResponses: true,
+			}
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/healthz", HandlerReadiness)
+			mux.HandleFunc("GET /api/chirps", cfg.HandlerChirpsGetAll)
+			mux.HandleFunc("POST /api/chirps", cfg.HandlerChirpsCreate)
+			mux.HandleFunc("GET /api/chirps/{chirpID}", cfg.HandlerChirpsGetSingle)
+			mux.HandleFunc("DELETE /api/chirps/{chirpID}", cfg.HandlerChirpsDelete)
+			mux.HandleFunc("GET /api/users/me/blocks", cfg.HandlerUserBlocksList)
+			handler := cfg.middlewareOpenAPI(mux, mux)
+
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			r := httptest.NewRequest(tt.method, tt.path, body)
+			if tt.body != "" {
+				r.Header.Set("Content-Type", "application/json")
+			}
+			if tt.bearer {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
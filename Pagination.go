@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// parsePagination reads the limit and offset query params, falling back to
+// defaultPageLimit and 0.
+func parsePagination(r *http.Request) (limit, offset int32, err error) {
+	limit = defaultPageLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return 0, 0, errValidation(FieldError{Field: "limit", Code: codeFieldRange, Message: fmt.Sprintf("must be between 1 and %d", maxPageLimit)})
+		}
+		limit = int32(n)
+	}
+
+	if s := r.URL.Query().Get("offset"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return 0, 0, errValidation(FieldError{Field: "offset", Code: codeFieldRange, Message: "must be a positive number"})
+		}
+		offset = int32(n)
+	}
+
+	return limit, offset, nil
+}
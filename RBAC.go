@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+const (
+	roleUser      = "user"
+	roleModerator = "moderator"
+	roleAdmin     = "admin"
+)
+
+var roles = []string{roleUser, roleModerator, roleAdmin}
+
+type permission string
+
+const (
+	permViewMetrics  permission = "metrics:view"
+	permResetData    permission = "data:reset"
+	permModerate     permission = "reports:moderate"
+	permManageRoles  permission = "users:manage_roles"
+	permViewModLog   permission = "moderation_log:view"
+	permSuspendUsers permission = "users:suspend"
+	permViewAudit    permission = "audit:view"
+	permUnlockLogins permission = "users:unlock_login"
+)
+
+// rolePermissions lists what each role may do. Roles don't inherit from each
+// other, every permission is spelled out.
+var rolePermissions = map[string][]permission{
+	roleUser: {},
+	roleModerator: {
+		permModerate,
+		permViewModLog,
+		permUnlockLogins,
+	},
+	roleAdmin: {
+		permViewMetrics,
+		permResetData,
+		permModerate,
+		permViewModLog,
+		permManageRoles,
+		permSuspendUsers,
+		permViewAudit,
+		permUnlockLogins,
+	},
+}
+
+func roleHas(role string, p permission) bool {
+	return slices.Contains(rolePermissions[role], p)
+}
+
+type ctxKey int
+
+const ctxKeyUser ctxKey = iota
+
+// requirePermission wraps a handler so it only runs for authenticated users
+// whose role grants p. The role is read from the database on every request,
+// so demoting someone takes effect immediately rather than when their JWT
+// expires. The handler gets the user from userFromContext.
+func (cfg *apiConf) requirePermission(p permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bearer, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			respondWithAPIError(w, errMissingToken(err))
+			return
+		}
+
+		userID, err := cfg.validateJWT(bearer)
+		if err != nil {
+			respondWithAPIError(w, errInvalidToken(err))
+			return
+		}
+
+		user, err := cfg.db.GetUserByID(r.Context(), userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithAPIError(w, errInvalidToken(err))
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "couldn't load user", err)
+			return
+		}
+
+		if !roleHas(user.Role, p) {
+			respondWithError(w, http.StatusForbidden, "Forbidden", nil)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), ctxKeyUser, user)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// userFromContext returns the user authenticated by requirePermission.
+func userFromContext(ctx context.Context) database.User {
+	user, _ := ctx.Value(ctxKeyUser).(database.User)
+	return user
+}
+
+// bootstrapAdmin promotes the user with the given email to admin. It only
+// works while there are no admins yet, after that roles are managed through
+// PUT /admin/users/{userID}/role.
+func bootstrapAdmin(ctx context.Context, db *database.Queries, email string) error {
+	n, err := db.BootstrapAdmin(ctx, email)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("no user with that email, or an admin already exists")
+	}
+	return nil
+}
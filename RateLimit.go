@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+	"github.com/tsironi93/WebServer/internal/ratelimit"
+)
+
+// planLimits are the budgets for anonymous clients (keyed by IP), users and
+// Chirpy Red users (keyed by user ID).
+type planLimits struct {
+	anonymous ratelimit.Limit
+	user      ratelimit.Limit
+	red       ratelimit.Limit
+}
+
+var defaultLimits = planLimits{
+	anonymous: ratelimit.Limit{Burst: 60, Period: time.Minute},
+	user:      ratelimit.Limit{Burst: 120, Period: time.Minute},
+	red:       ratelimit.Limit{Burst: 600, Period: time.Minute},
+}
+
+// routeLimits override defaultLimits for single routes, keyed by mux
+// pattern. Those routes get their own bucket and don't count against the
+// default one.
+var routeLimits = map[string]planLimits{
+	"POST /api/login": {
+		anonymous: ratelimit.Limit{Burst: 10, Period: time.Minute},
+		user:      ratelimit.Limit{Burst: 10, Period: time.Minute},
+		red:       ratelimit.Limit{Burst: 10, Period: time.Minute},
+	},
+	"POST /api/chirps": {
+		anonymous: ratelimit.Limit{Burst: 10, Period: time.Minute},
+		user:      ratelimit.Limit{Burst: 30, Period: time.Minute},
+		red:       ratelimit.Limit{Burst: 120, Period: time.Minute},
+	},
+}
+
+// rateLimitExempt routes are never limited, load balancers poll them.
+var rateLimitExempt = map[string]bool{
+	"GET /api/healthz": true,
+}
+
+// middlewareRateLimit applies the token bucket limits in front of next,
+// picking the limit by the route mux would serve, and reports them in
+// RateLimit-* headers. If the store fails, requests are let through rather
+// than taking the API down with it.
+func (cfg *apiConf) middlewareRateLimit(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		// both versions of a route draw from one bucket
+		pattern = v1Pattern(pattern)
+		if rateLimitExempt[pattern] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		limits, ok := routeLimits[pattern]
+		scope := pattern
+		if !ok {
+			limits, scope = defaultLimits, "*"
+		}
+
+		key, limit := cfg.rateLimitKey(r, limits)
+		res, err := cfg.rateLimits.Take(r.Context(), scope+"|"+key, limit)
+		if err != nil {
+			log.Println("rate limit:", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Burst, int(limit.Period.Seconds())))
+
+		if !res.Allowed {
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			respondWithError(w, http.StatusTooManyRequests, "rate limit exceeded", nil)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitKey picks the bucket for the request: the user for valid JWTs,
+// the client IP for everyone else.
+func (cfg *apiConf) rateLimitKey(r *http.Request, limits planLimits) (string, ratelimit.Limit) {
+	bearer, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return "ip:" + clientIP(r), limits.anonymous
+	}
+	userID, err := cfg.validateJWT(bearer)
+	if err != nil {
+		return "ip:" + clientIP(r), limits.anonymous
+	}
+
+	if cfg.plans.isChirpyRed(r.Context(), cfg.db, userID) {
+		return "user:" + userID.String(), limits.red
+	}
+	return "user:" + userID.String(), limits.user
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+const (
+	planCacheTTL  = time.Minute
+	planCacheSize = 10000
+)
+
+type planEntry struct {
+	red     bool
+	expires time.Time
+}
+
+// planCache remembers which users are Chirpy Red, so picking a rate limit
+// costs a query per user per minute instead of one per request.
+type planCache struct {
+	mu      sync.Mutex
+	entries map[uuid.UUID]planEntry
+}
+
+func newPlanCache() *planCache {
+	return &planCache{entries: make(map[uuid.UUID]planEntry)}
+}
+
+func (c *planCache) isChirpyRed(ctx context.Context, db *database.Queries, userID uuid.UUID) bool {
+	now := time.Now()
+
+	c.mu.Lock()
+	e, ok := c.entries[userID]
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.red
+	}
+
+	user, err := db.GetUserByID(ctx, userID)
+	if err != nil {
+		// fall back to the smaller budget and retry next time
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= planCacheSize {
+		clear(c.entries)
+	}
+	c.entries[userID] = planEntry{red: user.IsChirpyRed, expires: now.Add(planCacheTTL)}
+	return user.IsChirpyRed
+}
+
+// postgresRateStore shares buckets between instances through the
+// rate_limit_buckets table.
+type postgresRateStore struct {
+	db *database.Queries
+}
+
+func (s postgresRateStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	row, err := s.db.TakeRateLimitToken(ctx, database.TakeRateLimitTokenParams{
+		Key:   key,
+		Burst: float64(limit.Burst),
+		Rate:  limit.Rate(),
+	})
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	return ratelimit.Evaluate(limit, row.Tokens, row.Allowed), nil
+}
+
+// rateLimitIdle is how long a bucket has to go unused before it is
+// deleted. It is longer than any Period, so deleted buckets were full.
+const rateLimitIdle = time.Hour
+
+// runRateLimitCleanup drops buckets that have refilled completely.
+func (cfg *apiConf) runRateLimitCleanup(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		switch store := cfg.rateLimits.(type) {
+		case *ratelimit.MemoryStore:
+			store.Sweep()
+		case postgresRateStore:
+			if _, err := store.db.DeleteIdleRateLimitBuckets(ctx, rateLimitIdle.Seconds()); err != nil {
+				log.Println("delete idle rate limit buckets:", err)
+			}
+		}
+	}
+}
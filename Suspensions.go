@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tsironi93/WebServer/internal/auth"
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+var errAccountSuspended = errors.New("account suspended")
+
+// suspensionCache holds the IDs of currently suspended users so JWT
+// validation doesn't need a query per request. It is reloaded periodically,
+// which is also how temporary suspensions expire and how suspensions made on
+// other instances show up here.
+type suspensionCache struct {
+	mu        sync.RWMutex
+	suspended map[uuid.UUID]struct{}
+}
+
+func newSuspensionCache() *suspensionCache {
+	return &suspensionCache{suspended: make(map[uuid.UUID]struct{})}
+}
+
+func (c *suspensionCache) has(userID uuid.UUID) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.suspended[userID]
+	return ok
+}
+
+func (c *suspensionCache) add(userID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.suspended[userID] = struct{}{}
+}
+
+func (c *suspensionCache) remove(userID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.suspended, userID)
+}
+
+func (c *suspensionCache) reload(ctx context.Context, db *database.Queries) error {
+	ids, err := db.GetSuspendedUserIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	suspended := make(map[uuid.UUID]struct{}, len(ids))
+	for _, id := range ids {
+		suspended[id] = struct{}{}
+	}
+
+	c.mu.Lock()
+	c.suspended = suspended
+	c.mu.Unlock()
+	return nil
+}
+
+// runSuspensionRefresher reloads the suspension cache every tick.
+func (cfg *apiConf) runSuspensionRefresher(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := cfg.suspensions.reload(ctx, cfg.db); err != nil {
+			log.Println("reload suspensions:", err)
+		}
+	}
+}
+
+// validateJWT is auth.ValidateJWT plus a check that the user isn't
+// suspended. Handlers use it instead of calling auth directly.
+func (cfg *apiConf) validateJWT(token string) (uuid.UUID, error) {
+	userID, err := auth.ValidateJWT(token, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if cfg.suspensions.has(userID) {
+		return uuid.Nil, errAccountSuspended
+	}
+	return userID, nil
+}
+
+// suspendUser stores the suspension and revokes every refresh token of the
+// user, so they're logged out once their current JWT is rejected. Call
+// cfg.suspensions.add with afterCommit once the transaction commits.
+func suspendUser(ctx context.Context, qtx *database.Queries, params database.CreateUserSuspensionParams) (database.UserSuspension, error) {
+	suspension, err := qtx.CreateUserSuspension(ctx, params)
+	if err != nil {
+		return database.UserSuspension{}, err
+	}
+	if _, err := qtx.RevokeUserRefreshTokens(ctx, params.UserID); err != nil {
+		return database.UserSuspension{}, err
+	}
+	return suspension, nil
+}
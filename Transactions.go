@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/tsironi93/WebServer/internal/database"
+)
+
+// batchTx is the transaction of an atomic batch. While its sub-requests
+// run, every query on cfg.db goes through it, transactions begun with
+// cfg.beginTx are savepoints in it, and side effects outside the database
+// wait in afterCommit until it commits.
+type batchTx struct {
+	tx *sql.Tx
+
+	mu          sync.Mutex
+	savepoints  int
+	afterCommit []func()
+}
+
+type batchTxKey struct{}
+
+func withBatchTx(ctx context.Context, btx *batchTx) context.Context {
+	return context.WithValue(ctx, batchTxKey{}, btx)
+}
+
+func batchTxFrom(ctx context.Context) *batchTx {
+	btx, _ := ctx.Value(batchTxKey{}).(*batchTx)
+	return btx
+}
+
+// outsideBatch detaches ctx from the batch transaction, for writes that
+// must stick even when the batch rolls back.
+func outsideBatch(ctx context.Context) context.Context {
+	if batchTxFrom(ctx) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, batchTxKey{}, (*batchTx)(nil))
+}
+
+// commit commits the transaction and runs the side effects it held back.
+func (b *batchTx) commit() error {
+	if err := b.tx.Commit(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	fns := b.afterCommit
+	b.afterCommit = nil
+	b.mu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+	return nil
+}
+
+// afterCommit runs fn once the data it describes is committed: right away
+// outside a batch, after the batch commits inside one, and never if the
+// batch rolls back. Use it for anything that leaves the database, like
+// events, notifications and caches.
+func afterCommit(ctx context.Context, fn func()) {
+	btx := batchTxFrom(ctx)
+	if btx == nil {
+		fn()
+		return
+	}
+	btx.mu.Lock()
+	defer btx.mu.Unlock()
+	btx.afterCommit = append(btx.afterCommit, fn)
+}
+
+// txDB is the database.DBTX behind cfg.db. It sends queries to the batch
+// transaction of their context when there is one.
+type txDB struct {
+	db *sql.DB
+}
+
+func (d txDB) conn(ctx context.Context) database.DBTX {
+	if btx := batchTxFrom(ctx); btx != nil {
+		return btx.tx
+	}
+	return d.db
+}
+
+func (d txDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return d.conn(ctx).ExecContext(ctx, query, args...)
+}
+
+func (d txDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return d.conn(ctx).PrepareContext(ctx, query)
+}
+
+func (d txDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return d.conn(ctx).QueryContext(ctx, query, args...)
+}
+
+func (d txDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return d.conn(ctx).QueryRowContext(ctx, query, args...)
+}
+
+// dbTx is a transaction begun with cfg.beginTx. Use it like a *sql.Tx:
+// cfg.db.WithTx(tx.Tx), then Commit, with a deferred Rollback.
+type dbTx struct {
+	*sql.Tx
+	// savepoint is set inside an atomic batch, whose transaction Tx is
+	savepoint string
+	done      bool
+}
+
+// beginTx begins a transaction, or a savepoint when the request is part of
+// an atomic batch so the batch can still roll it back.
+func (cfg *apiConf) beginTx(ctx context.Context) (*dbTx, error) {
+	btx := batchTxFrom(ctx)
+	if btx == nil {
+		tx, err := cfg.dbConn.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &dbTx{Tx: tx}, nil
+	}
+
+	btx.mu.Lock()
+	btx.savepoints++
+	name := fmt.Sprintf("sp%d", btx.savepoints)
+	btx.mu.Unlock()
+	if _, err := btx.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return nil, err
+	}
+	return &dbTx{Tx: btx.tx, savepoint: name}, nil
+}
+
+func (t *dbTx) Commit() error {
+	if t.savepoint == "" {
+		return t.Tx.Commit()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	_, err := t.Tx.Exec("RELEASE SAVEPOINT " + t.savepoint)
+	return err
+}
+
+func (t *dbTx) Rollback() error {
+	if t.savepoint == "" {
+		return t.Tx.Rollback()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	_, err := t.Tx.Exec("ROLLBACK TO SAVEPOINT " + t.savepoint)
+	return err
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// API versions. v2 is selected with the /api/v2 path prefix or with
+// "Accept: application/vnd.chirpy.v2+json" on an /api path. Everything
+// else is v1, which also answers under /api/v1.
+//
+// v2 only registers the routes whose shape changed (under /api/v2 in the
+// mux); every other v2 request is served by the v1 handler.
+const (
+	apiV1 = 1
+	apiV2 = 2
+
+	mediaTypeV2 = "application/vnd.chirpy.v2+json"
+)
+
+// v1 is deprecated since v2 shipped and goes away at the sunset date.
+var (
+	v1DeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	v1Sunset       = time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC)
+)
+
+// versionExempt routes aren't part of either version and never get
+// deprecation headers.
+var versionExempt = map[string]bool{
+	"/api/healthz":      true,
+	"/api/openapi.yaml": true,
+}
+
+// middlewareAPIVersion works out the API version of a request and rewrites
+// its path to the route that serves it, so the middleware behind it and
+// the mux see the real pattern. v1 responses are marked deprecated
+// (Deprecation, RFC 9745, and Sunset, RFC 8594) with a link to the v2 path.
+func middlewareAPIVersion(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, "/api/")
+		if !ok || versionExempt[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		version := apiV1
+		switch {
+		case strings.HasPrefix(rest, "v2/"):
+			version = apiV2
+			rest = strings.TrimPrefix(rest, "v2/")
+		case strings.HasPrefix(rest, "v1/"):
+			rest = strings.TrimPrefix(rest, "v1/")
+		case acceptsV2(r):
+			version = apiV2
+		}
+		// the version can depend on Accept, caches must key on it
+		w.Header().Add("Vary", "Accept")
+
+		path := "/api/" + rest
+		if version == apiV2 {
+			w.Header().Set("API-Version", "2")
+			if hasRoute(mux, r, "/api/v2/"+rest) {
+				path = "/api/v2/" + rest
+			}
+		} else {
+			w.Header().Set("API-Version", "1")
+			w.Header().Set("Deprecation", fmt.Sprintf("@%d", v1DeprecatedAt.Unix()))
+			w.Header().Set("Sunset", v1Sunset.Format(http.TimeFormat))
+			w.Header().Add("Link", fmt.Sprintf(`</api/v2/%s>; rel="successor-version"`, rest))
+		}
+
+		if path != r.URL.Path {
+			r = r.Clone(r.Context())
+			r.URL.Path = path
+			r.URL.RawPath = ""
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// acceptsV2 reports whether the client asked for the v2 media type.
+func acceptsV2(r *http.Request) bool {
+	for _, v := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(v, ",") {
+			if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && mediaType == mediaTypeV2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasRoute reports whether mux has a route registered for r at path.
+func hasRoute(mux *http.ServeMux, r *http.Request, path string) bool {
+	u := *r.URL
+	u.Path = path
+	u.RawPath = ""
+	_, pattern := mux.Handler(&http.Request{Method: r.Method, URL: &u, Host: r.Host, Header: r.Header})
+	return pattern != ""
+}
+
+// v1Pattern maps the mux pattern of a v2 route to its v1 counterpart.
+func v1Pattern(pattern string) string {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		return pattern
+	}
+	if rest, ok := strings.CutPrefix(path, "/api/v2/"); ok {
+		return method + " /api/" + rest
+	}
+	return pattern
+}
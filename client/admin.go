@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditFilter narrows down the audit log, zero fields match everything.
+type AuditFilter struct {
+	EventType string
+	ActorID   uuid.UUID
+	SubjectID uuid.UUID
+	// Since is inclusive, Until exclusive.
+	Since time.Time
+	Until time.Time
+}
+
+func (f AuditFilter) query(q url.Values) url.Values {
+	if f.EventType != "" {
+		q.Set("event_type", f.EventType)
+	}
+	if f.ActorID != uuid.Nil {
+		q.Set("actor_id", f.ActorID.String())
+	}
+	if f.SubjectID != uuid.Nil {
+		q.Set("subject_id", f.SubjectID.String())
+	}
+	if !f.Since.IsZero() {
+		q.Set("since", f.Since.Format(time.RFC3339Nano))
+	}
+	if !f.Until.IsZero() {
+		q.Set("until", f.Until.Format(time.RFC3339Nano))
+	}
+	return q
+}
+
+// ListAuditEvents returns a page of the audit log, newest first. Admins
+// only.
+func (c *Client) ListAuditEvents(ctx context.Context, filter AuditFilter, page Page) ([]AuditEvent, error) {
+	var events []AuditEvent
+	r := &request{method: http.MethodGet, path: "/admin/audit", query: filter.query(page.query(nil)), auth: authBearer}
+	if _, err := c.do(ctx, r, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// AuditEvents iterates over the matching audit log, pageSize at a time.
+func (c *Client) AuditEvents(ctx context.Context, filter AuditFilter, pageSize int) iter.Seq2[AuditEvent, error] {
+	return paginate(ctx, pageSize, func(ctx context.Context, p Page) ([]AuditEvent, error) {
+		return c.ListAuditEvents(ctx, filter, p)
+	})
+}
+
+// Metrics returns the admin metrics page as HTML. Admins only.
+func (c *Client) Metrics(ctx context.Context) (string, error) {
+	return c.doText(ctx, &request{method: http.MethodGet, path: "/admin/metrics", auth: authBearer})
+}
+
+// Reset resets the hit counter and deletes every user. It only works on
+// servers running with PLATFORM=dev, and only for admins.
+func (c *Client) Reset(ctx context.Context) (string, error) {
+	return c.doText(ctx, &request{method: http.MethodPost, path: "/admin/reset", auth: authBearer})
+}
+
+// ListModerationLog returns a page of moderation actions, newest first.
+// Moderators and admins only.
+func (c *Client) ListModerationLog(ctx context.Context, page Page) ([]ModerationAction, error) {
+	var actions []ModerationAction
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: "/admin/moderation-log", query: page.query(nil), auth: authBearer}, &actions); err != nil {
+		return nil, err
+	}
+	return actions, nil
+}
+
+// ModerationLog iterates over the whole moderation log, pageSize at a time.
+func (c *Client) ModerationLog(ctx context.Context, pageSize int) iter.Seq2[ModerationAction, error] {
+	return paginate(ctx, pageSize, c.ListModerationLog)
+}
+
+// ListReports returns a page of the moderation queue, oldest first. status
+// is "open" (default) or "resolved". Moderators and admins only.
+func (c *Client) ListReports(ctx context.Context, status string, page Page) ([]Report, error) {
+	q := page.query(nil)
+	if status != "" {
+		q.Set("status", status)
+	}
+	var reports []Report
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: "/admin/reports", query: q, auth: authBearer}, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// Reports iterates over the reports with the given status, pageSize at a
+// time.
+func (c *Client) Reports(ctx context.Context, status string, pageSize int) iter.Seq2[Report, error] {
+	return paginate(ctx, pageSize, func(ctx context.Context, p Page) ([]Report, error) {
+		return c.ListReports(ctx, status, p)
+	})
+}
+
+// ResolveReport applies a moderation action to a report and every other
+// open report about the same target.
+func (c *Client) ResolveReport(ctx context.Context, reportID uuid.UUID, req ResolveReportRequest) (*ModerationAction, error) {
+	var action ModerationAction
+	if _, err := c.do(ctx, &request{method: http.MethodPost, path: pathf("/admin/reports/%s/resolve", reportID), body: req, auth: authBearer}, &action); err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// SetUserRole makes the user a "user", "moderator" or "admin". Admins only.
+func (c *Client) SetUserRole(ctx context.Context, userID uuid.UUID, role string) (*UserRole, error) {
+	var resp UserRole
+	r := &request{method: http.MethodPut, path: pathf("/admin/users/%s/role", userID), body: SetRoleRequest{Role: role}, auth: authBearer}
+	if _, err := c.do(ctx, r, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SuspendUser suspends the user until req.Until, or for good. Admins only.
+func (c *Client) SuspendUser(ctx context.Context, userID uuid.UUID, req SuspendUserRequest) (*Suspension, error) {
+	var s Suspension
+	if _, err := c.do(ctx, &request{method: http.MethodPost, path: pathf("/admin/users/%s/suspend", userID), body: req, auth: authBearer}, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// UnsuspendUser lifts every active suspension of the user. Admins only.
+func (c *Client) UnsuspendUser(ctx context.Context, userID uuid.UUID, req UnsuspendUserRequest) error {
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: pathf("/admin/users/%s/unsuspend", userID), body: req, auth: authBearer}, nil)
+	return err
+}
+
+// ListSuspensions returns the user's suspension history, newest first.
+// Admins only.
+func (c *Client) ListSuspensions(ctx context.Context, userID uuid.UUID) ([]Suspension, error) {
+	var suspensions []Suspension
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: pathf("/admin/users/%s/suspensions", userID), auth: authBearer}, &suspensions); err != nil {
+		return nil, err
+	}
+	return suspensions, nil
+}
+
+// UnlockUser lifts a failed login lockout of the user's email. Moderators
+// and admins only.
+func (c *Client) UnlockUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: pathf("/admin/users/%s/unlock", userID), auth: authBearer}, nil)
+	return err
+}
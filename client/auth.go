@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// CreateUser signs up a new user. The returned User carries an access token
+// but the client doesn't keep it, call Login to get a refresh token too.
+func (c *Client) CreateUser(ctx context.Context, req CreateUserRequest, opts ...CallOption) (*User, error) {
+	r := &request{method: http.MethodPost, path: "/api/users", body: req}
+	for _, opt := range opts {
+		opt(r)
+	}
+	var user User
+	if _, err := c.do(ctx, r, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateUserV2 signs up a new user through API v2, which returns neither
+// a token nor the email.
+func (c *Client) CreateUserV2(ctx context.Context, req CreateUserRequest, opts ...CallOption) (*UserV2, error) {
+	r := &request{method: http.MethodPost, path: "/api/v2/users", body: req}
+	for _, opt := range opts {
+		opt(r)
+	}
+	var user UserV2
+	if _, err := c.do(ctx, r, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Login authenticates and stores the access and refresh tokens in the
+// client, so later calls are made as this user.
+func (c *Client) Login(ctx context.Context, email, password string) (*LoginResponse, error) {
+	var resp LoginResponse
+	r := &request{method: http.MethodPost, path: "/api/login", body: LoginRequest{Email: email, Password: password}}
+	if _, err := c.do(ctx, r, &resp); err != nil {
+		return nil, err
+	}
+	c.SetTokens(resp.Token, resp.RefreshToken)
+	return &resp, nil
+}
+
+// LoginV2 is Login through API v2, which keeps the tokens apart from the
+// user object.
+func (c *Client) LoginV2(ctx context.Context, email, password string) (*LoginResponseV2, error) {
+	var resp LoginResponseV2
+	r := &request{method: http.MethodPost, path: "/api/v2/login", body: LoginRequest{Email: email, Password: password}}
+	if _, err := c.do(ctx, r, &resp); err != nil {
+		return nil, err
+	}
+	c.SetTokens(resp.Token, resp.RefreshToken)
+	return &resp, nil
+}
+
+// Refresh exchanges the refresh token for a new access token and stores it.
+// Calls that fail with an expired token do this on their own.
+func (c *Client) Refresh(ctx context.Context) (string, error) {
+	var resp RefreshResponse
+	if _, err := c.do(ctx, &request{method: http.MethodPost, path: "/api/refresh", auth: authRefresh}, &resp); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.token = resp.Token
+	onRefresh := c.onRefresh
+	c.mu.Unlock()
+	if onRefresh != nil {
+		onRefresh(resp.Token)
+	}
+	return resp.Token, nil
+}
+
+// Revoke revokes the refresh token and forgets both tokens.
+func (c *Client) Revoke(ctx context.Context) error {
+	if _, err := c.do(ctx, &request{method: http.MethodPost, path: "/api/revoke", auth: authRefresh}, nil); err != nil {
+		return err
+	}
+	c.SetTokens("", "")
+	return nil
+}
+
+// UpdateUser changes the password and, if set, the email of the logged in
+// user.
+func (c *Client) UpdateUser(ctx context.Context, req UpdateUserRequest) (*UpdateUserResponse, error) {
+	var resp UpdateUserResponse
+	if _, err := c.do(ctx, &request{method: http.MethodPut, path: "/api/users", body: req, auth: authBearer}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// Batch sends several requests to /api/batch in one round trip, with the
+// access token if the client has one. The responses are in the order of
+// the requests; a failed sub-request doesn't fail the call, see
+// BatchResponse.Decode.
+func (c *Client) Batch(ctx context.Context, req BatchRequest) ([]BatchResponse, error) {
+	var resp []BatchResponse
+	if _, err := c.do(ctx, &request{method: http.MethodPost, path: "/api/batch", body: req, auth: authOptional}, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Decode unmarshals the body into out, or returns an *Error if the
+// sub-request failed. out may be nil to only check for errors.
+func (r *BatchResponse) Decode(out any) error {
+	if r.Status >= 400 {
+		e := &Error{StatusCode: r.Status}
+		if err := json.Unmarshal(r.Body, &e.Problem); err != nil {
+			e.Title = http.StatusText(r.Status)
+		}
+		return e
+	}
+	if out == nil || len(r.Body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(r.Body, out)
+}
@@ -0,0 +1,240 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+)
+
+type ListChirpsParams struct {
+	// AuthorID only lists the author's chirps, pinned ones first.
+	AuthorID uuid.UUID
+	// Sort is "asc" (default) or "desc" by creation time.
+	Sort string
+}
+
+// ListChirps returns every chirp, or every chirp of an author. When logged
+// in, chirps of blocked and muted users are left out. The endpoint isn't
+// paginated, the whole list comes back at once.
+func (c *Client) ListChirps(ctx context.Context, params ListChirpsParams, opts ...CallOption) ([]Chirp, error) {
+	q := make(url.Values)
+	if params.AuthorID != uuid.Nil {
+		q.Set("author_id", params.AuthorID.String())
+	}
+	if params.Sort != "" {
+		q.Set("sort", params.Sort)
+	}
+	r := &request{method: http.MethodGet, path: "/api/chirps", query: q, auth: authOptional}
+	for _, opt := range opts {
+		opt(r)
+	}
+	var chirps []Chirp
+	if _, err := c.do(ctx, r, &chirps); err != nil {
+		return nil, err
+	}
+	return chirps, nil
+}
+
+var errUseCreateDraft = errors.New("chirpy: use CreateDraft for drafts and scheduled chirps")
+
+// CreateChirp publishes a chirp right away. Drafts and scheduled chirps
+// come back as a ChirpDraft, create those with CreateDraft.
+func (c *Client) CreateChirp(ctx context.Context, req CreateChirpRequest, opts ...CallOption) (*Chirp, error) {
+	if req.Draft || req.PublishAt != nil {
+		return nil, errUseCreateDraft
+	}
+	r := &request{method: http.MethodPost, path: "/api/chirps", body: req, auth: authBearer}
+	for _, opt := range opts {
+		opt(r)
+	}
+	var chirp Chirp
+	if _, err := c.do(ctx, r, &chirp); err != nil {
+		return nil, err
+	}
+	return &chirp, nil
+}
+
+// CreateDraft saves a draft, or schedules the chirp if req.PublishAt is
+// set.
+func (c *Client) CreateDraft(ctx context.Context, req CreateChirpRequest, opts ...CallOption) (*ChirpDraft, error) {
+	if req.PublishAt == nil {
+		req.Draft = true
+	}
+	r := &request{method: http.MethodPost, path: "/api/chirps", body: req, auth: authBearer}
+	for _, opt := range opts {
+		opt(r)
+	}
+	var draft ChirpDraft
+	if _, err := c.do(ctx, r, &draft); err != nil {
+		return nil, err
+	}
+	return &draft, nil
+}
+
+// GetChirp returns a chirp with its ETag. WithIfNoneMatch returns
+// ErrNotModified if it didn't change.
+func (c *Client) GetChirp(ctx context.Context, chirpID uuid.UUID, opts ...CallOption) (*Chirp, error) {
+	r := &request{method: http.MethodGet, path: pathf("/api/chirps/%s", chirpID), auth: authOptional}
+	for _, opt := range opts {
+		opt(r)
+	}
+	var chirp Chirp
+	resp, err := c.do(ctx, r, &chirp)
+	if err != nil {
+		return nil, err
+	}
+	chirp.ETag = resp.Header.Get("ETag")
+	return &chirp, nil
+}
+
+// GetThread returns the first chirp of the chirp's thread and its replies,
+// oldest first.
+func (c *Client) GetThread(ctx context.Context, chirpID uuid.UUID) ([]Chirp, error) {
+	var chirps []Chirp
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: pathf("/api/chirps/%s/thread", chirpID), auth: authOptional}, &chirps); err != nil {
+		return nil, err
+	}
+	return chirps, nil
+}
+
+// DeleteChirp moves one of your chirps to the trash. WithIfMatch only
+// deletes it if it is unchanged.
+func (c *Client) DeleteChirp(ctx context.Context, chirpID uuid.UUID, opts ...CallOption) error {
+	r := &request{method: http.MethodDelete, path: pathf("/api/chirps/%s", chirpID), auth: authBearer}
+	for _, opt := range opts {
+		opt(r)
+	}
+	_, err := c.do(ctx, r, nil)
+	return err
+}
+
+// RestoreChirp brings a deleted chirp back from the trash.
+func (c *Client) RestoreChirp(ctx context.Context, chirpID uuid.UUID) (*Chirp, error) {
+	var chirp Chirp
+	if _, err := c.do(ctx, &request{method: http.MethodPost, path: pathf("/api/chirps/%s/restore", chirpID), auth: authBearer}, &chirp); err != nil {
+		return nil, err
+	}
+	return &chirp, nil
+}
+
+// ListTrash returns your deleted chirps that can still be restored.
+func (c *Client) ListTrash(ctx context.Context) ([]TrashedChirp, error) {
+	var chirps []TrashedChirp
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: "/api/users/me/trash", auth: authBearer}, &chirps); err != nil {
+		return nil, err
+	}
+	return chirps, nil
+}
+
+func (c *Client) BookmarkChirp(ctx context.Context, chirpID uuid.UUID) error {
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: pathf("/api/chirps/%s/bookmark", chirpID), auth: authBearer}, nil)
+	return err
+}
+
+func (c *Client) UnbookmarkChirp(ctx context.Context, chirpID uuid.UUID) error {
+	_, err := c.do(ctx, &request{method: http.MethodDelete, path: pathf("/api/chirps/%s/bookmark", chirpID), auth: authBearer}, nil)
+	return err
+}
+
+// ListBookmarks returns a page of your bookmarks, most recent first.
+func (c *Client) ListBookmarks(ctx context.Context, page Page) ([]BookmarkedChirp, error) {
+	var chirps []BookmarkedChirp
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: "/api/users/me/bookmarks", query: page.query(nil), auth: authBearer}, &chirps); err != nil {
+		return nil, err
+	}
+	return chirps, nil
+}
+
+// Bookmarks iterates over all your bookmarks, pageSize at a time.
+func (c *Client) Bookmarks(ctx context.Context, pageSize int) iter.Seq2[BookmarkedChirp, error] {
+	return paginate(ctx, pageSize, c.ListBookmarks)
+}
+
+// PinChirp pins one of your chirps to your profile, at most 3 at a time.
+func (c *Client) PinChirp(ctx context.Context, chirpID uuid.UUID) error {
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: pathf("/api/chirps/%s/pin", chirpID), auth: authBearer}, nil)
+	return err
+}
+
+func (c *Client) UnpinChirp(ctx context.Context, chirpID uuid.UUID) error {
+	_, err := c.do(ctx, &request{method: http.MethodDelete, path: pathf("/api/chirps/%s/pin", chirpID), auth: authBearer}, nil)
+	return err
+}
+
+// VotePoll votes in the poll of a chirp and returns the updated results.
+func (c *Client) VotePoll(ctx context.Context, chirpID, optionID uuid.UUID) (*Poll, error) {
+	var poll Poll
+	r := &request{method: http.MethodPost, path: pathf("/api/chirps/%s/poll/vote", chirpID), body: PollVoteRequest{OptionID: optionID}, auth: authBearer}
+	if _, err := c.do(ctx, r, &poll); err != nil {
+		return nil, err
+	}
+	return &poll, nil
+}
+
+// ListDrafts returns your drafts and scheduled chirps. status filters them
+// by "draft" or "scheduled", empty returns both.
+func (c *Client) ListDrafts(ctx context.Context, status string) ([]ChirpDraft, error) {
+	q := make(url.Values)
+	if status != "" {
+		q.Set("status", status)
+	}
+	var drafts []ChirpDraft
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: "/api/drafts", query: q, auth: authBearer}, &drafts); err != nil {
+		return nil, err
+	}
+	return drafts, nil
+}
+
+// GetDraft returns a draft with the ETag to pass to UpdateDraft and
+// DeleteDraft WithIfMatch.
+func (c *Client) GetDraft(ctx context.Context, draftID uuid.UUID, opts ...CallOption) (*ChirpDraft, error) {
+	r := &request{method: http.MethodGet, path: pathf("/api/drafts/%s", draftID), auth: authBearer}
+	for _, opt := range opts {
+		opt(r)
+	}
+	var draft ChirpDraft
+	resp, err := c.do(ctx, r, &draft)
+	if err != nil {
+		return nil, err
+	}
+	draft.ETag = resp.Header.Get("ETag")
+	return &draft, nil
+}
+
+// UpdateDraft replaces the body and schedule of a draft.
+func (c *Client) UpdateDraft(ctx context.Context, draftID uuid.UUID, req UpdateDraftRequest, opts ...CallOption) (*ChirpDraft, error) {
+	r := &request{method: http.MethodPut, path: pathf("/api/drafts/%s", draftID), body: req, auth: authBearer}
+	for _, opt := range opts {
+		opt(r)
+	}
+	var draft ChirpDraft
+	resp, err := c.do(ctx, r, &draft)
+	if err != nil {
+		return nil, err
+	}
+	draft.ETag = resp.Header.Get("ETag")
+	return &draft, nil
+}
+
+// DeleteDraft discards a draft or cancels a scheduled chirp.
+func (c *Client) DeleteDraft(ctx context.Context, draftID uuid.UUID, opts ...CallOption) error {
+	r := &request{method: http.MethodDelete, path: pathf("/api/drafts/%s", draftID), auth: authBearer}
+	for _, opt := range opts {
+		opt(r)
+	}
+	_, err := c.do(ctx, r, nil)
+	return err
+}
+
+// PublishDraft publishes a draft or scheduled chirp now.
+func (c *Client) PublishDraft(ctx context.Context, draftID uuid.UUID) (*Chirp, error) {
+	var chirp Chirp
+	if _, err := c.do(ctx, &request{method: http.MethodPost, path: pathf("/api/drafts/%s/publish", draftID), auth: authBearer}, &chirp); err != nil {
+		return nil, err
+	}
+	return &chirp, nil
+}
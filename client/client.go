@@ -0,0 +1,361 @@
+// Package client is a typed Go client for the Chirpy API. It has a method
+// for every operation in openapi.yaml; the tests check the two against each
+// other, so a route added to the spec without a client method fails them.
+//
+// A Client holds the access and refresh tokens of one user. Login stores
+// them, and requests that fail because the access token expired are retried
+// once after exchanging the refresh token at /api/refresh.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Client calls a Chirpy server. It is safe for concurrent use.
+type Client struct {
+	baseURL string
+	http    *http.Client
+
+	mu           sync.Mutex
+	token        string
+	refreshToken string
+	// refreshing is set while a refresh is in flight so concurrent
+	// requests wait for it instead of refreshing again
+	refreshing chan struct{}
+	onRefresh  func(token string)
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sets the http.Client used for requests, http.DefaultClient
+// by default.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithTokens starts the client with tokens from an earlier Login.
+func WithTokens(token, refreshToken string) Option {
+	return func(c *Client) {
+		c.token = token
+		c.refreshToken = refreshToken
+	}
+}
+
+// WithRefreshHook calls fn with the new access token whenever the client
+// refreshes it, e.g. to persist it.
+func WithRefreshHook(fn func(token string)) Option {
+	return func(c *Client) { c.onRefresh = fn }
+}
+
+// New returns a client for the server at baseURL, e.g.
+// "http://localhost:8080".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Tokens returns the current access and refresh tokens.
+func (c *Client) Tokens() (token, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token, c.refreshToken
+}
+
+// SetTokens replaces the access and refresh tokens.
+func (c *Client) SetTokens(token, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+	c.refreshToken = refreshToken
+}
+
+// auth says which credential a request sends.
+type auth int
+
+const (
+	authNone auth = iota
+	// authBearer sends the access token and refreshes it when it expired
+	authBearer
+	// authOptional sends the access token if there is one
+	authOptional
+	// authRefresh sends the refresh token
+	authRefresh
+)
+
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   any
+	auth   auth
+}
+
+// CallOption sets optional headers on a single call.
+type CallOption func(*request)
+
+// WithIdempotencyKey makes retries of a create safe: the server replays the
+// first response for the same key instead of creating twice.
+func WithIdempotencyKey(key string) CallOption {
+	return func(r *request) { r.setHeader("Idempotency-Key", key) }
+}
+
+// WithIfMatch only applies a change if the resource still has etag,
+// otherwise the call fails with code precondition_failed.
+func WithIfMatch(etag string) CallOption {
+	return func(r *request) { r.setHeader("If-Match", etag) }
+}
+
+// WithIfNoneMatch makes a get return ErrNotModified if the resource still
+// has etag.
+func WithIfNoneMatch(etag string) CallOption {
+	return func(r *request) { r.setHeader("If-None-Match", etag) }
+}
+
+func (r *request) setHeader(key, value string) {
+	if r.header == nil {
+		r.header = make(http.Header)
+	}
+	r.header.Set(key, value)
+}
+
+// ErrNotModified is returned by gets made WithIfNoneMatch when the cached
+// copy is still current.
+var ErrNotModified = errors.New("chirpy: not modified")
+
+// Error is a problem+json error response.
+type Error struct {
+	StatusCode int
+	Problem
+}
+
+func (e *Error) Error() string {
+	msg := e.Detail
+	if msg == "" {
+		msg = e.Title
+	}
+	s := fmt.Sprintf("chirpy: %d %s: %s", e.StatusCode, e.Code, msg)
+	for _, f := range e.Errors {
+		s += fmt.Sprintf("; %s: %s", f.Field, f.Message)
+	}
+	return s
+}
+
+// Error codes the server uses, see Problem.Code.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeInvalidJSON          = "invalid_json"
+	CodeInvalidID            = "invalid_id"
+	CodeValidationFailed     = "validation_failed"
+	CodeMissingToken         = "missing_token"
+	CodeInvalidToken         = "invalid_token"
+	CodeUnauthorized         = "unauthorized"
+	CodeAccountSuspended     = "account_suspended"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeAlreadyExists        = "already_exists"
+	CodeConflict             = "conflict"
+	CodePreconditionFailed   = "precondition_failed"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeUnprocessable        = "unprocessable_entity"
+	CodeTooManyRequests      = "too_many_requests"
+	CodeFailedDependency     = "failed_dependency"
+	CodeInternal             = "internal_error"
+)
+
+// IsCode reports whether err is an API error with the given code.
+func IsCode(err error, code string) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// do sends req and decodes a JSON response into out, if out isn't nil.
+func (c *Client) do(ctx context.Context, req *request, out any) (*http.Response, error) {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return resp, ErrNotModified
+	}
+	if resp.StatusCode >= 400 {
+		return resp, decodeError(resp)
+	}
+	if out == nil {
+		return resp, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp, fmt.Errorf("chirpy: decoding %s %s: %w", req.method, req.path, err)
+	}
+	return resp, nil
+}
+
+// doText sends req and returns the body of a non JSON response, such as
+// the admin pages.
+func (c *Client) doText(ctx context.Context, req *request) (string, error) {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return "", decodeError(resp)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("chirpy: reading %s %s: %w", req.method, req.path, err)
+	}
+	return string(b), nil
+}
+
+// send sends req and returns the response with its body unread. An expired
+// access token is refreshed and the request retried once.
+func (c *Client) send(ctx context.Context, req *request) (*http.Response, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return nil, fmt.Errorf("chirpy: encoding %s %s: %w", req.method, req.path, err)
+		}
+	}
+
+	token, resp, err := c.sendOnce(ctx, req, body)
+	if err != nil || req.auth != authBearer || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	apiErr := decodeError(resp)
+	resp.Body.Close()
+	if !IsCode(apiErr, CodeInvalidToken) {
+		return nil, apiErr
+	}
+	if err := c.refreshAfter(ctx, token); err != nil {
+		// report why the original call failed, not the refresh
+		return nil, apiErr
+	}
+	_, resp, err = c.sendOnce(ctx, req, body)
+	return resp, err
+}
+
+// sendOnce returns the token it authenticated with along with the response.
+func (c *Client) sendOnce(ctx context.Context, req *request, body []byte) (string, *http.Response, error) {
+	u := c.baseURL + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u, r)
+	if err != nil {
+		return "", nil, fmt.Errorf("chirpy: %w", err)
+	}
+	for key, values := range req.header {
+		httpReq.Header[key] = values
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if httpReq.Header.Get("Accept") == "" {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+
+	token, refreshToken := c.Tokens()
+	var used string
+	switch req.auth {
+	case authBearer, authOptional:
+		used = token
+	case authRefresh:
+		used = refreshToken
+	}
+	if used != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+used)
+	}
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return "", nil, fmt.Errorf("chirpy: %w", err)
+	}
+	return used, resp, nil
+}
+
+// refreshAfter gets a new access token, unless another request already
+// replaced stale in the meantime.
+func (c *Client) refreshAfter(ctx context.Context, stale string) error {
+	for {
+		c.mu.Lock()
+		if c.token != stale {
+			c.mu.Unlock()
+			return nil
+		}
+		if c.refreshToken == "" {
+			c.mu.Unlock()
+			return errors.New("chirpy: no refresh token")
+		}
+		wait := c.refreshing
+		if wait == nil {
+			c.refreshing = make(chan struct{})
+			c.mu.Unlock()
+			_, err := c.Refresh(ctx)
+			c.mu.Lock()
+			close(c.refreshing)
+			c.refreshing = nil
+			c.mu.Unlock()
+			return err
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func decodeError(resp *http.Response) error {
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "application/problem+json" || mediaType == "application/json" {
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr.Problem); err == nil {
+			return apiErr
+		}
+	}
+	// not one of ours, e.g. a proxy in between
+	apiErr.Problem = Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(resp.StatusCode),
+		Status: resp.StatusCode,
+	}
+	return apiErr
+}
+
+// pathf builds a request path, escaping the arguments.
+func pathf(format string, args ...any) string {
+	escaped := make([]any, len(args))
+	for i, a := range args {
+		escaped[i] = url.PathEscape(fmt.Sprint(a))
+	}
+	return fmt.Sprintf(format, escaped...)
+}
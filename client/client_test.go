@@ -0,0 +1,871 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/tsironi93/WebServer/internal/openapi"
+)
+
+const (
+	testToken        = "access-token"
+	testRefreshToken = "refresh-token"
+	testPolkaKey     = "polka-key"
+)
+
+func loadSpec(t *testing.T) *openapi.Document {
+	t.Helper()
+	data, err := os.ReadFile("../openapi.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := openapi.Load(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func resolve(doc *openapi.Document, s *openapi.Schema) *openapi.Schema {
+	for s != nil && s.Ref != "" {
+		s = doc.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	return s
+}
+
+// sample builds a value that validates against s. Below the top level only
+// required properties are filled in, which ends recursive schemas.
+func sample(doc *openapi.Document, s *openapi.Schema, depth int) any {
+	s = resolve(doc, s)
+	if s == nil {
+		return map[string]any{}
+	}
+	if len(s.AllOf) > 0 {
+		merged := map[string]any{}
+		for _, sub := range s.AllOf {
+			for k, v := range sample(doc, sub, depth).(map[string]any) {
+				merged[k] = v
+			}
+		}
+		return merged
+	}
+	if len(s.Enum) > 0 {
+		return s.Enum[0]
+	}
+
+	typ := "object"
+	for _, t := range s.Type {
+		if t != "null" {
+			typ = t
+			break
+		}
+	}
+	switch typ {
+	case "string":
+		switch s.Format {
+		case "uuid":
+			return uuid.NewString()
+		case "date-time":
+			return time.Now().UTC().Format(time.RFC3339)
+		case "uri":
+			return "https://example.com"
+		case "email":
+			return "user@example.com"
+		}
+		return "x"
+	case "integer", "number":
+		return 1
+	case "boolean":
+		return true
+	case "array":
+		return []any{sample(doc, s.Items, depth+1)}
+	}
+
+	obj := map[string]any{}
+	for name, prop := range s.Properties {
+		if depth == 0 || slices.Contains(s.Required, name) {
+			obj[name] = sample(doc, prop, depth+1)
+		}
+	}
+	if s.AdditionalProperties != nil && s.PropertyNames != nil && len(s.PropertyNames.Enum) > 0 {
+		obj[fmt.Sprint(s.PropertyNames.Enum[0])] = sample(doc, s.AdditionalProperties, depth+1)
+	}
+	return obj
+}
+
+// fakeServer answers every operation in the spec with a sample response
+// after checking the request against the spec, and records which
+// operations were called.
+func fakeServer(t *testing.T, doc *openapi.Document) (*httptest.Server, func() map[string]bool) {
+	t.Helper()
+	validator := openapi.NewValidator(doc)
+	var mu sync.Mutex
+	called := make(map[string]bool)
+
+	mux := http.NewServeMux()
+	for path, item := range doc.Paths {
+		for method, op := range item.Operations() {
+			route := validator.Route(method, path)
+			mux.HandleFunc(method+" "+path, func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				called[op.OperationID] = true
+				mu.Unlock()
+
+				if err := route.ValidateRequest(r); err != nil {
+					t.Errorf("%s: request doesn't match the spec: %v", op.OperationID, err)
+				}
+				if want := credential(op); want != "" && r.Header.Get("Authorization") != want {
+					t.Errorf("%s: Authorization = %q, want %q", op.OperationID, r.Header.Get("Authorization"), want)
+				}
+				respond(t, doc, route, w, r)
+			})
+		}
+	}
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, func() map[string]bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return called
+	}
+}
+
+// credential is the Authorization header an operation requires, empty when
+// it works without one.
+func credential(op *openapi.Operation) string {
+	for _, req := range op.Security {
+		if len(req) == 0 {
+			return ""
+		}
+	}
+	for _, req := range op.Security {
+		switch {
+		case req["bearerAuth"] != nil:
+			return "Bearer " + testToken
+		case req["refreshToken"] != nil:
+			return "Bearer " + testRefreshToken
+		case req["polkaApiKey"] != nil:
+			return "ApiKey " + testPolkaKey
+		}
+	}
+	return ""
+}
+
+func respond(t *testing.T, doc *openapi.Document, route *openapi.Route, w http.ResponseWriter, r *http.Request) {
+	var statuses []string
+	for status := range route.Operation.Responses {
+		if status != "default" {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Strings(statuses)
+	status := statuses[0]
+	resp := route.Operation.Responses[status]
+
+	if status == "101" {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("%s: %v", route.Operation.OperationID, err)
+			return
+		}
+		defer conn.Close()
+		var msg map[string]string
+		if err := conn.ReadJSON(&msg); err == nil {
+			conn.WriteJSON(map[string]string{"type": "ack", "topic": msg["topic"]})
+		}
+		return
+	}
+
+	var code int
+	fmt.Sscan(status, &code)
+	for contentType, mt := range resp.Content {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(code)
+		switch contentType {
+		case "application/json":
+			body, _ := json.Marshal(sample(doc, mt.Schema, 0))
+			if err := route.ValidateResponse(code, w.Header(), body); err != nil {
+				t.Errorf("%s: bad sample: %v", route.Operation.OperationID, err)
+			}
+			w.Write(body)
+		case "text/event-stream":
+			chirp, _ := json.Marshal(sample(doc, &openapi.Schema{Ref: "#/components/schemas/Chirp"}, 0))
+			fmt.Fprintf(w, ": heartbeat\n\nid: 7\nevent: chirp.created\ndata: %s\n\n", chirp)
+		default:
+			w.Write([]byte("ok"))
+		}
+		return
+	}
+	w.WriteHeader(code)
+}
+
+// calls exercises every client method, keyed by the operationId it calls.
+var calls = map[string]func(ctx context.Context, c *Client) error{
+	"batch": func(ctx context.Context, c *Client) error {
+		_, err := c.Batch(ctx, BatchRequest{Atomic: true, Requests: []BatchSubRequest{
+			{Method: http.MethodGet, Path: "/api/users/me/bookmarks"},
+			{Method: http.MethodPost, Path: "/api/chirps", Body: CreateChirpRequest{Body: "hi"}},
+		}})
+		return err
+	},
+	"graphQL": func(ctx context.Context, c *Client) error {
+		_, err := c.GraphQL(ctx, GraphQLRequest{Query: "query($id: ID!) { user(id: $id) { id } }", Variables: map[string]any{"id": uuid.NewString()}})
+		return err
+	},
+	"graphQLGet": func(ctx context.Context, c *Client) error {
+		_, err := c.GraphQLGet(ctx, GraphQLRequest{Extensions: &GraphQLExtensions{PersistedQuery: &PersistedQuery{Version: 1, Sha256Hash: "abc"}}})
+		return err
+	},
+	"adminAudit": func(ctx context.Context, c *Client) error {
+		_, err := c.ListAuditEvents(ctx, AuditFilter{EventType: "auth.login", ActorID: uuid.New(), Since: time.Now()}, Page{Limit: 10})
+		return err
+	},
+	"metrics": func(ctx context.Context, c *Client) error {
+		_, err := c.Metrics(ctx)
+		return err
+	},
+	"adminModerationLog": func(ctx context.Context, c *Client) error {
+		_, err := c.ListModerationLog(ctx, Page{Offset: 20})
+		return err
+	},
+	"adminReportsList": func(ctx context.Context, c *Client) error {
+		_, err := c.ListReports(ctx, "resolved", Page{})
+		return err
+	},
+	"adminReportsResolve": func(ctx context.Context, c *Client) error {
+		until := time.Now().Add(time.Hour)
+		_, err := c.ResolveReport(ctx, uuid.New(), ResolveReportRequest{Action: "suspend_user", SuspendUntil: &until})
+		return err
+	},
+	"resetHits": func(ctx context.Context, c *Client) error {
+		_, err := c.Reset(ctx)
+		return err
+	},
+	"adminUserSetRole": func(ctx context.Context, c *Client) error {
+		_, err := c.SetUserRole(ctx, uuid.New(), "moderator")
+		return err
+	},
+	"adminUserSuspend": func(ctx context.Context, c *Client) error {
+		_, err := c.SuspendUser(ctx, uuid.New(), SuspendUserRequest{Reason: "spam", HideChirps: true})
+		return err
+	},
+	"adminUserSuspensions": func(ctx context.Context, c *Client) error {
+		_, err := c.ListSuspensions(ctx, uuid.New())
+		return err
+	},
+	"adminUserUnlock": func(ctx context.Context, c *Client) error {
+		return c.UnlockUser(ctx, uuid.New())
+	},
+	"adminUserUnsuspend": func(ctx context.Context, c *Client) error {
+		return c.UnsuspendUser(ctx, uuid.New(), UnsuspendUserRequest{Note: "appeal"})
+	},
+	"chirpsGetAll": func(ctx context.Context, c *Client) error {
+		_, err := c.ListChirps(ctx, ListChirpsParams{AuthorID: uuid.New(), Sort: "desc"})
+		return err
+	},
+	"chirpsCreate": func(ctx context.Context, c *Client) error {
+		quoted := uuid.New()
+		_, err := c.CreateChirp(ctx, CreateChirpRequest{
+			Body:          "hello",
+			Poll:          &PollRequest{Options: []string{"yes", "no"}, ExpiresAt: time.Now().Add(time.Hour)},
+			QuotedChirpID: &quoted,
+		}, WithIdempotencyKey("key"))
+		if err != nil {
+			return err
+		}
+		_, err = c.CreateDraft(ctx, CreateChirpRequest{Body: "later"})
+		return err
+	},
+	"chirpsStream": func(ctx context.Context, c *Client) error {
+		stream, err := c.StreamChirps(ctx, StreamParams{AuthorID: uuid.New(), LastEventID: 3})
+		if err != nil {
+			return err
+		}
+		defer stream.Close()
+		e, err := stream.Next()
+		if err != nil {
+			return err
+		}
+		if e.ID != 7 || e.Type != EventChirpCreated || e.Chirp.ID == uuid.Nil {
+			return fmt.Errorf("unexpected event %+v", e)
+		}
+		return nil
+	},
+	"chirpsGetSingle": func(ctx context.Context, c *Client) error {
+		chirp, err := c.GetChirp(ctx, uuid.New())
+		if err == nil && chirp.ETag != `"v1"` {
+			err = fmt.Errorf("ETag = %q", chirp.ETag)
+		}
+		return err
+	},
+	"chirpsDelete": func(ctx context.Context, c *Client) error {
+		return c.DeleteChirp(ctx, uuid.New(), WithIfMatch(`"v1"`))
+	},
+	"chirpsBookmark": func(ctx context.Context, c *Client) error {
+		return c.BookmarkChirp(ctx, uuid.New())
+	},
+	"chirpsUnbookmark": func(ctx context.Context, c *Client) error {
+		return c.UnbookmarkChirp(ctx, uuid.New())
+	},
+	"chirpsPin": func(ctx context.Context, c *Client) error {
+		return c.PinChirp(ctx, uuid.New())
+	},
+	"chirpsUnpin": func(ctx context.Context, c *Client) error {
+		return c.UnpinChirp(ctx, uuid.New())
+	},
+	"chirpsPollVote": func(ctx context.Context, c *Client) error {
+		_, err := c.VotePoll(ctx, uuid.New(), uuid.New())
+		return err
+	},
+	"chirpsReport": func(ctx context.Context, c *Client) error {
+		return c.ReportChirp(ctx, uuid.New(), CreateReportRequest{Reason: "spam"})
+	},
+	"chirpsRestore": func(ctx context.Context, c *Client) error {
+		_, err := c.RestoreChirp(ctx, uuid.New())
+		return err
+	},
+	"chirpsThread": func(ctx context.Context, c *Client) error {
+		_, err := c.GetThread(ctx, uuid.New())
+		return err
+	},
+	"conversationsList": func(ctx context.Context, c *Client) error {
+		_, err := c.ListConversations(ctx, Page{Limit: 5})
+		return err
+	},
+	"conversationsCreate": func(ctx context.Context, c *Client) error {
+		_, err := c.CreateConversation(ctx, uuid.New(), uuid.New())
+		return err
+	},
+	"conversationsGet": func(ctx context.Context, c *Client) error {
+		_, err := c.GetConversation(ctx, uuid.New())
+		return err
+	},
+	"messagesList": func(ctx context.Context, c *Client) error {
+		_, err := c.ListMessages(ctx, uuid.New(), "cursor", 10)
+		return err
+	},
+	"messagesSend": func(ctx context.Context, c *Client) error {
+		_, err := c.SendMessage(ctx, uuid.New(), "hi")
+		return err
+	},
+	"conversationsMarkRead": func(ctx context.Context, c *Client) error {
+		return c.MarkConversationRead(ctx, uuid.New())
+	},
+	"chirpDraftsList": func(ctx context.Context, c *Client) error {
+		_, err := c.ListDrafts(ctx, "scheduled")
+		return err
+	},
+	"chirpDraftsGet": func(ctx context.Context, c *Client) error {
+		_, err := c.GetDraft(ctx, uuid.New())
+		return err
+	},
+	"chirpDraftsUpdate": func(ctx context.Context, c *Client) error {
+		draft, err := c.UpdateDraft(ctx, uuid.New(), UpdateDraftRequest{Body: "edited"}, WithIfMatch(`"v0"`))
+		if err == nil && draft.ETag != `"v1"` {
+			err = fmt.Errorf("ETag = %q", draft.ETag)
+		}
+		return err
+	},
+	"chirpDraftsDelete": func(ctx context.Context, c *Client) error {
+		return c.DeleteDraft(ctx, uuid.New())
+	},
+	"chirpDraftsPublish": func(ctx context.Context, c *Client) error {
+		_, err := c.PublishDraft(ctx, uuid.New())
+		return err
+	},
+	"readiness": func(ctx context.Context, c *Client) error {
+		return c.Healthz(ctx)
+	},
+	"userLogin": func(ctx context.Context, c *Client) error {
+		// keep the tokens the other calls are checked against
+		defer c.SetTokens(testToken, testRefreshToken)
+		_, err := c.Login(ctx, "user@example.com", "secret")
+		return err
+	},
+	"userLoginV2": func(ctx context.Context, c *Client) error {
+		defer c.SetTokens(testToken, testRefreshToken)
+		_, err := c.LoginV2(ctx, "user@example.com", "secret")
+		return err
+	},
+	"notificationsList": func(ctx context.Context, c *Client) error {
+		_, err := c.ListNotifications(ctx, true, Page{Limit: 50})
+		return err
+	},
+	"notificationPreferencesGet": func(ctx context.Context, c *Client) error {
+		_, err := c.GetNotificationPreferences(ctx)
+		return err
+	},
+	"notificationPreferencesUpdate": func(ctx context.Context, c *Client) error {
+		_, err := c.UpdateNotificationPreferences(ctx, NotificationPreferences{"mention": false})
+		return err
+	},
+	"notificationsMarkAllRead": func(ctx context.Context, c *Client) error {
+		return c.MarkAllNotificationsRead(ctx)
+	},
+	"notificationsMarkRead": func(ctx context.Context, c *Client) error {
+		return c.MarkNotificationRead(ctx, uuid.New())
+	},
+	"openAPISpec": func(ctx context.Context, c *Client) error {
+		_, err := c.OpenAPISpec(ctx)
+		return err
+	},
+	"userUpgradeToRed": func(ctx context.Context, c *Client) error {
+		event := PolkaWebhook{Event: EventUserUpgraded, Data: PolkaWebhookData{UserID: uuid.New()}}
+		return c.SendPolkaWebhook(ctx, testPolkaKey, event, WithIdempotencyKey("evt_1"))
+	},
+	"tokenRefresh": func(ctx context.Context, c *Client) error {
+		defer c.SetTokens(testToken, testRefreshToken)
+		_, err := c.Refresh(ctx)
+		return err
+	},
+	"tokenRevoke": func(ctx context.Context, c *Client) error {
+		defer c.SetTokens(testToken, testRefreshToken)
+		return c.Revoke(ctx)
+	},
+	"userCreate": func(ctx context.Context, c *Client) error {
+		_, err := c.CreateUser(ctx, CreateUserRequest{Email: "user@example.com", Password: "secret"})
+		return err
+	},
+	"userCreateV2": func(ctx context.Context, c *Client) error {
+		_, err := c.CreateUserV2(ctx, CreateUserRequest{Email: "user@example.com", Password: "secret"}, WithIdempotencyKey("key"))
+		return err
+	},
+	"userUpdate": func(ctx context.Context, c *Client) error {
+		_, err := c.UpdateUser(ctx, UpdateUserRequest{Email: "new@example.com", Password: "secret"})
+		return err
+	},
+	"userBlocksList": func(ctx context.Context, c *Client) error {
+		_, err := c.ListBlocks(ctx)
+		return err
+	},
+	"userBookmarksList": func(ctx context.Context, c *Client) error {
+		_, err := c.ListBookmarks(ctx, Page{})
+		return err
+	},
+	"userMutesList": func(ctx context.Context, c *Client) error {
+		_, err := c.ListMutes(ctx)
+		return err
+	},
+	"userFollowersList": func(ctx context.Context, c *Client) error {
+		_, err := c.ListFollowers(ctx, Page{})
+		return err
+	},
+	"userFollowingList": func(ctx context.Context, c *Client) error {
+		_, err := c.ListFollowing(ctx, Page{})
+		return err
+	},
+	"userSecurityLog": func(ctx context.Context, c *Client) error {
+		_, err := c.ListSecurityLog(ctx, Page{})
+		return err
+	},
+	"chirpsTrash": func(ctx context.Context, c *Client) error {
+		_, err := c.ListTrash(ctx)
+		return err
+	},
+	"userBlock": func(ctx context.Context, c *Client) error {
+		return c.BlockUser(ctx, uuid.New())
+	},
+	"userUnblock": func(ctx context.Context, c *Client) error {
+		return c.UnblockUser(ctx, uuid.New())
+	},
+	"userMute": func(ctx context.Context, c *Client) error {
+		return c.MuteUser(ctx, uuid.New())
+	},
+	"userUnmute": func(ctx context.Context, c *Client) error {
+		return c.UnmuteUser(ctx, uuid.New())
+	},
+	"userFollow": func(ctx context.Context, c *Client) error {
+		return c.FollowUser(ctx, uuid.New())
+	},
+	"userUnfollow": func(ctx context.Context, c *Client) error {
+		return c.UnfollowUser(ctx, uuid.New())
+	},
+	"userReport": func(ctx context.Context, c *Client) error {
+		return c.ReportUser(ctx, uuid.New(), CreateReportRequest{Reason: "impersonation", Details: "not them"})
+	},
+	"webSocket": func(ctx context.Context, c *Client) error {
+		sock, err := c.DialWebSocket(ctx)
+		if err != nil {
+			return err
+		}
+		defer sock.Close()
+		if err := sock.Subscribe("notifications"); err != nil {
+			return err
+		}
+		msg, err := sock.Read()
+		if err == nil && (msg.Type != "ack" || msg.Topic != "notifications") {
+			err = fmt.Errorf("unexpected reply %+v", msg)
+		}
+		return err
+	},
+}
+
+// TestClientCoversSpec calls every client method against a server built
+// from openapi.yaml, so the client can't drift from the spec: requests must
+// validate, and every operation must have a method.
+func TestClientCoversSpec(t *testing.T) {
+	doc := loadSpec(t)
+	srv, called := fakeServer(t, doc)
+	c := New(srv.URL, WithTokens(testToken, testRefreshToken))
+	ctx := context.Background()
+
+	for id, call := range calls {
+		if err := call(ctx, c); err != nil {
+			t.Errorf("%s: %v", id, err)
+		}
+	}
+
+	ids := make(map[string]bool)
+	for _, item := range doc.Paths {
+		for _, op := range item.Operations() {
+			ids[op.OperationID] = true
+			if calls[op.OperationID] == nil {
+				t.Errorf("operation %s has no client method", op.OperationID)
+			}
+		}
+	}
+	for id := range calls {
+		if !ids[id] {
+			t.Errorf("%s is not an operation in openapi.yaml", id)
+		} else if !called()[id] {
+			t.Errorf("calling %s didn't reach the server", id)
+		}
+	}
+}
+
+// TestTypesMatchSchemas checks that the JSON fields of the client types are
+// exactly the properties of their schemas.
+func TestTypesMatchSchemas(t *testing.T) {
+	doc := loadSpec(t)
+	types := map[string]any{
+		"AuditEvent":                AuditEvent{},
+		"BatchRequest":              BatchRequest{},
+		"BatchResponse":             BatchResponse{},
+		"BatchSubRequest":           BatchSubRequest{},
+		"BookmarkedChirp":           BookmarkedChirp{},
+		"Chirp":                     Chirp{},
+		"ChirpDraft":                ChirpDraft{},
+		"Conversation":              Conversation{},
+		"ConversationParticipant":   ConversationParticipant{},
+		"CreateChirpRequest":        CreateChirpRequest{},
+		"CreateConversationRequest": CreateConversationRequest{},
+		"CreateReportRequest":       CreateReportRequest{},
+		"CreateUserRequest":         CreateUserRequest{},
+		"FieldError":                FieldError{},
+		"GraphQLError":              GraphQLError{},
+		"GraphQLRequest":            GraphQLRequest{},
+		"GraphQLResponse":           GraphQLResponse{},
+		"LinkPreview":               LinkPreview{},
+		"LoginRequest":              LoginRequest{},
+		"LoginResponse":             LoginResponse{},
+		"LoginResponseV2":           LoginResponseV2{},
+		"Message":                   Message{},
+		"MessagesResponse":          MessagesResponse{},
+		"ModerationAction":          ModerationAction{},
+		"Notification":              Notification{},
+		"NotificationsResponse":     NotificationsResponse{},
+		"PolkaWebhook":              PolkaWebhook{},
+		"Poll":                      Poll{},
+		"PollOption":                PollOption{},
+		"PollRequest":               PollRequest{},
+		"PollVoteRequest":           PollVoteRequest{},
+		"Problem":                   Problem{},
+		"RefreshResponse":           RefreshResponse{},
+		"Report":                    Report{},
+		"ResolveReportRequest":      ResolveReportRequest{},
+		"SendMessageRequest":        SendMessageRequest{},
+		"SetRoleRequest":            SetRoleRequest{},
+		"SuspendUserRequest":        SuspendUserRequest{},
+		"Suspension":                Suspension{},
+		"TrashedChirp":              TrashedChirp{},
+		"UnsuspendUserRequest":      UnsuspendUserRequest{},
+		"UpdateDraftRequest":        UpdateDraftRequest{},
+		"UpdateUserRequest":         UpdateUserRequest{},
+		"UpdateUserResponse":        UpdateUserResponse{},
+		"User":                      User{},
+		"UserRelation":              UserRelation{},
+		"UserV2":                    UserV2{},
+		"UserRole":                  UserRole{},
+	}
+
+	for name, schema := range doc.Components.Schemas {
+		if schema.AdditionalProperties != nil {
+			// maps, e.g. NotificationPreferences
+			continue
+		}
+		v, ok := types[name]
+		if !ok {
+			t.Errorf("schema %s has no client type", name)
+			continue
+		}
+		want := schemaProperties(doc, schema)
+		got := jsonFields(reflect.TypeOf(v))
+		sort.Strings(want)
+		sort.Strings(got)
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: fields %v, schema has %v", name, got, want)
+		}
+	}
+}
+
+func schemaProperties(doc *openapi.Document, s *openapi.Schema) []string {
+	s = resolve(doc, s)
+	var names []string
+	for _, sub := range s.AllOf {
+		names = append(names, schemaProperties(doc, sub)...)
+	}
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	return names
+}
+
+func jsonFields(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			names = append(names, jsonFields(f.Type)...)
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func TestRefreshOnExpiredToken(t *testing.T) {
+	var refreshes atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/refresh", func(w http.ResponseWriter, r *http.Request) {
+		refreshes.Add(1)
+		if r.Header.Get("Authorization") != "Bearer "+testRefreshToken {
+			t.Errorf("refresh sent %q", r.Header.Get("Authorization"))
+		}
+		// make concurrent callers pile up behind this refresh
+		time.Sleep(20 * time.Millisecond)
+		json.NewEncoder(w).Encode(RefreshResponse{Token: "fresh"})
+	})
+	mux.HandleFunc("GET /api/users/me/blocks", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(Problem{Type: "about:blank", Status: 401, Code: CodeInvalidToken})
+			return
+		}
+		w.Write([]byte("[]"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var hooked atomic.Value
+	c := New(srv.URL, WithTokens("expired", testRefreshToken), WithRefreshHook(func(token string) { hooked.Store(token) }))
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.ListBlocks(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := refreshes.Load(); n != 1 {
+		t.Errorf("refreshed %d times, want 1", n)
+	}
+	if token, _ := c.Tokens(); token != "fresh" {
+		t.Errorf("token = %q", token)
+	}
+	if hooked.Load() != "fresh" {
+		t.Errorf("refresh hook got %v", hooked.Load())
+	}
+}
+
+func TestRefreshFailureReportsOriginalError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(Problem{Status: 401, Code: CodeInvalidToken, Detail: r.URL.Path})
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithTokens("expired", "revoked"))
+	_, err := c.ListMutes(context.Background())
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Detail != "/api/users/me/mutes" {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestErrorDecoding(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/users" {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"type":"about:blank","title":"Unprocessable Entity","status":422,"code":"validation_failed","errors":[{"field":"email","code":"invalid","message":"must be an email"}]}`))
+			return
+		}
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	c := New(srv.URL)
+
+	_, err := c.CreateUser(context.Background(), CreateUserRequest{Email: "nope", Password: "x"})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 422 || len(apiErr.Errors) != 1 || apiErr.Errors[0].Field != "email" {
+		t.Fatalf("err = %#v", err)
+	}
+	if !IsCode(err, CodeValidationFailed) {
+		t.Errorf("IsCode(%v, validation_failed) = false", err)
+	}
+
+	err = c.Healthz(context.Background())
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway || apiErr.Code != "" {
+		t.Fatalf("err = %#v", err)
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"id":"` + uuid.NewString() + `"}`))
+	}))
+	defer srv.Close()
+	c := New(srv.URL)
+
+	chirp, err := c.GetChirp(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetChirp(context.Background(), chirp.ID, WithIfNoneMatch(chirp.ETag)); !errors.Is(err, ErrNotModified) {
+		t.Fatalf("err = %v, want ErrNotModified", err)
+	}
+}
+
+func TestPaginationIterators(t *testing.T) {
+	const total = 7
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var limit, offset int
+		fmt.Sscan(r.URL.Query().Get("limit"), &limit)
+		fmt.Sscan(r.URL.Query().Get("offset"), &offset)
+		page := []UserRelation{}
+		for i := offset; i < min(offset+limit, total); i++ {
+			page = append(page, UserRelation{UserID: uuid.New()})
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer srv.Close()
+	c := New(srv.URL, WithTokens(testToken, ""))
+
+	var n int
+	for _, err := range c.Bookmarks(context.Background(), 3) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+	if n != total || requests.Load() != 3 {
+		t.Errorf("got %d items in %d requests, want %d in 3", n, requests.Load(), total)
+	}
+
+	// stopping early doesn't fetch more pages
+	requests.Store(0)
+	for range c.Bookmarks(context.Background(), 3) {
+		break
+	}
+	if requests.Load() != 1 {
+		t.Errorf("made %d requests after break", requests.Load())
+	}
+}
+
+func TestMessagesFollowsCursor(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := MessagesResponse{Messages: []Message{{Body: r.URL.Query().Get("before")}}}
+		if r.URL.Query().Get("before") == "" {
+			resp.NextCursor = "page2"
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+	c := New(srv.URL, WithTokens(testToken, ""))
+
+	var bodies []string
+	for msg, err := range c.Messages(context.Background(), uuid.New(), 10) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, msg.Body)
+	}
+	if !slices.Equal(bodies, []string{"", "page2"}) {
+		t.Errorf("bodies = %q", bodies)
+	}
+}
+
+func TestCreateChirpRejectsDrafts(t *testing.T) {
+	c := New("http://unused")
+	if _, err := c.CreateChirp(context.Background(), CreateChirpRequest{Body: "x", Draft: true}); err == nil {
+		t.Fatal("expected drafts to be rejected")
+	}
+}
+
+func TestVerifyPolkaWebhook(t *testing.T) {
+	userID := uuid.New()
+	tests := []struct {
+		name    string
+		auth    string
+		body    string
+		wantErr error
+	}{
+		{"valid", "ApiKey " + testPolkaKey, `{"event":"user.upgraded","data":{"user_id":"` + userID.String() + `"}}`, nil},
+		{"other event", "ApiKey " + testPolkaKey, `{"event":"user.downgraded","data":{}}`, nil},
+		{"missing key", "", `{}`, ErrWebhookUnauthorized},
+		{"wrong key", "ApiKey nope", `{}`, ErrWebhookUnauthorized},
+		{"bearer", "Bearer " + testPolkaKey, `{}`, ErrWebhookUnauthorized},
+		{"bad json", "ApiKey " + testPolkaKey, `{`, ErrWebhookInvalid},
+		{"missing user", "ApiKey " + testPolkaKey, `{"event":"user.upgraded","data":{}}`, ErrWebhookInvalid},
+		{"too large", "ApiKey " + testPolkaKey, `{"event":"` + strings.Repeat("x", maxWebhookBytes) + `"}`, ErrWebhookInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/webhooks/polka", strings.NewReader(tt.body))
+			if tt.auth != "" {
+				r.Header.Set("Authorization", tt.auth)
+			}
+			event, err := VerifyPolkaWebhook(r, testPolkaKey)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && tt.name == "valid" && event.Data.UserID != userID {
+				t.Errorf("user_id = %s", event.Data.UserID)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// GraphQL runs a query against /graphql, with the access token if the
+// client has one. GraphQL errors don't fail the call, they are in the
+// response's Errors; see GraphQLResponse.Decode.
+func (c *Client) GraphQL(ctx context.Context, req GraphQLRequest) (*GraphQLResponse, error) {
+	var resp GraphQLResponse
+	if _, err := c.do(ctx, &request{method: http.MethodPost, path: "/graphql", body: req, auth: authOptional}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GraphQLGet is GraphQL over GET, for persisted queries that caches in
+// between should be able to answer.
+func (c *Client) GraphQLGet(ctx context.Context, req GraphQLRequest) (*GraphQLResponse, error) {
+	q := url.Values{}
+	if req.Query != "" {
+		q.Set("query", req.Query)
+	}
+	if req.OperationName != "" {
+		q.Set("operationName", req.OperationName)
+	}
+	if req.Variables != nil {
+		b, err := json.Marshal(req.Variables)
+		if err != nil {
+			return nil, err
+		}
+		q.Set("variables", string(b))
+	}
+	if req.Extensions != nil {
+		b, err := json.Marshal(req.Extensions)
+		if err != nil {
+			return nil, err
+		}
+		q.Set("extensions", string(b))
+	}
+
+	var resp GraphQLResponse
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: "/graphql", query: q, auth: authOptional}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Decode unmarshals the data into out, and returns the first error of the
+// response if it has any. out may be nil to only check for errors.
+func (r *GraphQLResponse) Decode(out any) error {
+	if len(r.Errors) > 0 {
+		return &r.Errors[0]
+	}
+	if out == nil || len(r.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(r.Data, out)
+}
+
+// Code is the error code from the extensions, e.g. query_too_complex.
+func (e *GraphQLError) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+func (e *GraphQLError) Error() string {
+	if code := e.Code(); code != "" {
+		return "chirpy: graphql " + code + ": " + e.Message
+	}
+	return "chirpy: graphql: " + e.Message
+}
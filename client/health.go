@@ -0,0 +1,21 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Healthz reports whether the server is ready.
+func (c *Client) Healthz(ctx context.Context) error {
+	_, err := c.doText(ctx, &request{method: http.MethodGet, path: "/api/healthz"})
+	return err
+}
+
+// OpenAPISpec returns the server's OpenAPI description as YAML.
+func (c *Client) OpenAPISpec(ctx context.Context) ([]byte, error) {
+	s, err := c.doText(ctx, &request{method: http.MethodGet, path: "/api/openapi.yaml"})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
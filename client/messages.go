@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// CreateConversation starts a conversation with the given users. For a
+// one-to-one conversation that already exists, the existing one is
+// returned.
+func (c *Client) CreateConversation(ctx context.Context, participantIDs ...uuid.UUID) (*Conversation, error) {
+	var conv Conversation
+	r := &request{method: http.MethodPost, path: "/api/conversations", body: CreateConversationRequest{ParticipantIDs: participantIDs}, auth: authBearer}
+	if _, err := c.do(ctx, r, &conv); err != nil {
+		return nil, err
+	}
+	return &conv, nil
+}
+
+// ListConversations returns a page of your conversations, most recently
+// active first.
+func (c *Client) ListConversations(ctx context.Context, page Page) ([]Conversation, error) {
+	var convs []Conversation
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: "/api/conversations", query: page.query(nil), auth: authBearer}, &convs); err != nil {
+		return nil, err
+	}
+	return convs, nil
+}
+
+// Conversations iterates over all your conversations, pageSize at a time.
+func (c *Client) Conversations(ctx context.Context, pageSize int) iter.Seq2[Conversation, error] {
+	return paginate(ctx, pageSize, c.ListConversations)
+}
+
+func (c *Client) GetConversation(ctx context.Context, conversationID uuid.UUID) (*Conversation, error) {
+	var conv Conversation
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: pathf("/api/conversations/%s", conversationID), auth: authBearer}, &conv); err != nil {
+		return nil, err
+	}
+	return &conv, nil
+}
+
+// MarkConversationRead moves your read receipt to now.
+func (c *Client) MarkConversationRead(ctx context.Context, conversationID uuid.UUID) error {
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: pathf("/api/conversations/%s/read", conversationID), auth: authBearer}, nil)
+	return err
+}
+
+func (c *Client) SendMessage(ctx context.Context, conversationID uuid.UUID, body string) (*Message, error) {
+	var msg Message
+	r := &request{method: http.MethodPost, path: pathf("/api/conversations/%s/messages", conversationID), body: SendMessageRequest{Body: body}, auth: authBearer}
+	if _, err := c.do(ctx, r, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// ListMessages returns up to limit messages, newest first, older than the
+// before cursor. Pass NextCursor of the result to get the next page; it is
+// empty on the last one.
+func (c *Client) ListMessages(ctx context.Context, conversationID uuid.UUID, before string, limit int) (*MessagesResponse, error) {
+	q := make(url.Values)
+	if before != "" {
+		q.Set("before", before)
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var resp MessagesResponse
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: pathf("/api/conversations/%s/messages", conversationID), query: q, auth: authBearer}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Messages iterates over the whole conversation, newest first, following
+// the cursors pageSize messages at a time.
+func (c *Client) Messages(ctx context.Context, conversationID uuid.UUID, pageSize int) iter.Seq2[Message, error] {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	return func(yield func(Message, error) bool) {
+		before := ""
+		for {
+			resp, err := c.ListMessages(ctx, conversationID, before, pageSize)
+			if err != nil {
+				yield(Message{}, err)
+				return
+			}
+			for _, msg := range resp.Messages {
+				if !yield(msg, nil) {
+					return
+				}
+			}
+			if resp.NextCursor == "" {
+				return
+			}
+			before = resp.NextCursor
+		}
+	}
+}
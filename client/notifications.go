@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// ListNotifications returns a page of your notifications, newest first,
+// with the unread count. unreadOnly leaves out the ones already read.
+func (c *Client) ListNotifications(ctx context.Context, unreadOnly bool, page Page) (*NotificationsResponse, error) {
+	q := page.query(nil)
+	if unreadOnly {
+		q.Set("unread", "true")
+	}
+	var resp NotificationsResponse
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: "/api/notifications", query: q, auth: authBearer}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Notifications iterates over all your notifications, pageSize at a time.
+func (c *Client) Notifications(ctx context.Context, unreadOnly bool, pageSize int) iter.Seq2[Notification, error] {
+	return paginate(ctx, pageSize, func(ctx context.Context, p Page) ([]Notification, error) {
+		resp, err := c.ListNotifications(ctx, unreadOnly, p)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Notifications, nil
+	})
+}
+
+func (c *Client) MarkNotificationRead(ctx context.Context, notificationID uuid.UUID) error {
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: pathf("/api/notifications/%s/read", notificationID), auth: authBearer}, nil)
+	return err
+}
+
+func (c *Client) MarkAllNotificationsRead(ctx context.Context) error {
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: "/api/notifications/read", auth: authBearer}, nil)
+	return err
+}
+
+// GetNotificationPreferences returns which notification types are enabled.
+func (c *Client) GetNotificationPreferences(ctx context.Context) (NotificationPreferences, error) {
+	var prefs NotificationPreferences
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: "/api/notifications/preferences", auth: authBearer}, &prefs); err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// UpdateNotificationPreferences changes the types in prefs and returns all
+// of them; types left out keep their setting.
+func (c *Client) UpdateNotificationPreferences(ctx context.Context, prefs NotificationPreferences) (NotificationPreferences, error) {
+	var updated NotificationPreferences
+	if _, err := c.do(ctx, &request{method: http.MethodPut, path: "/api/notifications/preferences", body: prefs, auth: authBearer}, &updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/url"
+	"strconv"
+)
+
+// Page selects a page of a list. Zero values use the server defaults: 20
+// items from the start. Limit can be at most 100.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+func (p Page) query(q url.Values) url.Values {
+	if q == nil {
+		q = make(url.Values)
+	}
+	if p.Limit > 0 {
+		q.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.Offset > 0 {
+		q.Set("offset", strconv.Itoa(p.Offset))
+	}
+	return q
+}
+
+// DefaultPageSize is the page size iterators use when given 0.
+const DefaultPageSize = 100
+
+// paginate walks an offset paginated list, fetching pageSize items at a
+// time until a short page. Iteration stops at the first error, which is
+// yielded with a zero item.
+func paginate[T any](ctx context.Context, pageSize int, fetch func(ctx context.Context, p Page) ([]T, error)) iter.Seq2[T, error] {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	return func(yield func(T, error) bool) {
+		for offset := 0; ; offset += pageSize {
+			items, err := fetch(ctx, Page{Limit: pageSize, Offset: offset})
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if len(items) < pageSize {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// Event types of the chirp stream and WebSocket.
+const (
+	EventChirpCreated = "chirp.created"
+	EventChirpDeleted = "chirp.deleted"
+	EventUserOnline   = "presence.online"
+	EventUserOffline  = "presence.offline"
+	EventNotification = "notification"
+)
+
+// ChirpEvent is one event of the chirp stream.
+type ChirpEvent struct {
+	// ID resumes the stream with StreamParams.LastEventID.
+	ID    uint64
+	Type  string
+	Chirp Chirp
+}
+
+type StreamParams struct {
+	// AuthorID only streams the author's chirps.
+	AuthorID uuid.UUID
+	// LastEventID resumes after this event, if the server still has it.
+	LastEventID uint64
+}
+
+// ChirpStream reads the live timeline. Close it when done.
+type ChirpStream struct {
+	resp   *http.Response
+	reader *bufio.Reader
+	lastID uint64
+}
+
+// StreamChirps opens the live timeline (Server-Sent Events).
+func (c *Client) StreamChirps(ctx context.Context, params StreamParams) (*ChirpStream, error) {
+	q := make(url.Values)
+	if params.AuthorID != uuid.Nil {
+		q.Set("author_id", params.AuthorID.String())
+	}
+	r := &request{method: http.MethodGet, path: "/api/chirps/stream", query: q}
+	if params.LastEventID > 0 {
+		r.setHeader("Last-Event-ID", strconv.FormatUint(params.LastEventID, 10))
+	}
+	r.setHeader("Accept", "text/event-stream")
+
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+	return &ChirpStream{resp: resp, reader: bufio.NewReader(resp.Body), lastID: params.LastEventID}, nil
+}
+
+// Next blocks until the next event. It fails once the stream ends; open a
+// new one with LastEventID to resume.
+func (s *ChirpStream) Next() (ChirpEvent, error) {
+	var e ChirpEvent
+	var data strings.Builder
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return ChirpEvent{}, fmt.Errorf("chirpy: chirp stream: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			// heartbeats and other events without data end here too
+			if data.Len() == 0 {
+				e = ChirpEvent{}
+				continue
+			}
+			if err := json.Unmarshal([]byte(data.String()), &e.Chirp); err != nil {
+				return ChirpEvent{}, fmt.Errorf("chirpy: chirp stream: %w", err)
+			}
+			s.lastID = e.ID
+			return e, nil
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			e.ID, _ = strconv.ParseUint(value, 10, 64)
+		case "event":
+			e.Type = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+}
+
+// LastEventID is the ID of the last event returned by Next.
+func (s *ChirpStream) LastEventID() uint64 {
+	return s.lastID
+}
+
+func (s *ChirpStream) Close() error {
+	return s.resp.Body.Close()
+}
+
+// SocketEvent is an event delivered over the WebSocket. Data holds the
+// payload, a Chirp for chirp events.
+type SocketEvent struct {
+	ID          uint64          `json:"id"`
+	Type        string          `json:"type"`
+	AuthorID    uuid.UUID       `json:"author_id"`
+	ChirpID     uuid.UUID       `json:"chirp_id"`
+	RecipientID uuid.UUID       `json:"recipient_id"`
+	Data        json.RawMessage `json:"data,omitempty"`
+}
+
+// SocketMessage is a message from the server: an "ack" or "error" reply to
+// Subscribe and Unsubscribe, or an "event".
+type SocketMessage struct {
+	Type  string       `json:"type"`
+	Topic string       `json:"topic,omitempty"`
+	Error string       `json:"error,omitempty"`
+	Event *SocketEvent `json:"event,omitempty"`
+}
+
+// Socket is a WebSocket connection for notifications and presence. Read
+// from one goroutine; Subscribe and Unsubscribe may be called from
+// another.
+type Socket struct {
+	conn *websocket.Conn
+}
+
+// DialWebSocket connects to /api/ws as the logged in user, or anonymously
+// without a token.
+func (c *Client) DialWebSocket(ctx context.Context) (*Socket, error) {
+	u, err := url.Parse(c.baseURL + "/api/ws")
+	if err != nil {
+		return nil, fmt.Errorf("chirpy: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+
+	header := make(http.Header)
+	if token, _ := c.Tokens(); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, u.String(), header)
+	if err != nil {
+		if resp != nil && resp.StatusCode >= 400 {
+			defer resp.Body.Close()
+			return nil, decodeError(resp)
+		}
+		return nil, fmt.Errorf("chirpy: %w", err)
+	}
+	return &Socket{conn: conn}, nil
+}
+
+// Subscribe asks for events of a topic: "notifications",
+// "chirp:<uuid>", "author:<uuid>" or "presence:<uuid>". The server replies
+// with an ack or error message.
+func (s *Socket) Subscribe(topic string) error {
+	return s.conn.WriteJSON(map[string]string{"type": "subscribe", "topic": topic})
+}
+
+func (s *Socket) Unsubscribe(topic string) error {
+	return s.conn.WriteJSON(map[string]string{"type": "unsubscribe", "topic": topic})
+}
+
+// Read blocks until the next message from the server.
+func (s *Socket) Read() (*SocketMessage, error) {
+	var msg SocketMessage
+	if err := s.conn.ReadJSON(&msg); err != nil {
+		return nil, fmt.Errorf("chirpy: websocket: %w", err)
+	}
+	return &msg, nil
+}
+
+func (s *Socket) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,385 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The types below mirror components/schemas in openapi.yaml, field for
+// field. Optional fields are pointers or omitempty, nullable ones pointers.
+
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Code      string       `json:"code"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type CreateUserRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type User struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Email       string    `json:"email"`
+	Token       string    `json:"token"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+}
+
+// UserV2 is the API v2 user object, without token or email.
+type UserV2 struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+}
+
+type UpdateUserRequest struct {
+	Email    string `json:"email,omitempty"`
+	Password string `json:"password"`
+}
+
+type UpdateUserResponse struct {
+	Email string `json:"email"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type LoginResponse struct {
+	ID           uuid.UUID `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Email        string    `json:"email"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	IsChirpyRed  bool      `json:"is_chirpy_red"`
+}
+
+type LoginResponseV2 struct {
+	User         UserV2 `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RefreshResponse struct {
+	Token string `json:"token"`
+}
+
+type UserRelation struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Chirp struct {
+	ID            uuid.UUID     `json:"id"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	Body          string        `json:"body"`
+	UserID        uuid.UUID     `json:"user_id"`
+	Poll          *Poll         `json:"poll,omitempty"`
+	Pinned        bool          `json:"pinned,omitempty"`
+	QuotedChirpID *uuid.UUID    `json:"quoted_chirp_id,omitempty"`
+	QuotedChirp   *Chirp        `json:"quoted_chirp,omitempty"`
+	LinkPreviews  []LinkPreview `json:"link_previews,omitempty"`
+	ReplyToID     *uuid.UUID    `json:"reply_to_id,omitempty"`
+	ThreadID      *uuid.UUID    `json:"thread_id,omitempty"`
+
+	// ETag is set from the response header by GetChirp.
+	ETag string `json:"-"`
+}
+
+type BookmarkedChirp struct {
+	Chirp
+	BookmarkedAt time.Time `json:"bookmarked_at"`
+}
+
+type TrashedChirp struct {
+	Chirp
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+type BatchRequest struct {
+	Requests []BatchSubRequest `json:"requests"`
+	// Atomic runs the requests in one transaction, rolled back when one of
+	// them fails.
+	Atomic bool `json:"atomic,omitempty"`
+}
+
+type BatchSubRequest struct {
+	Method string `json:"method"`
+	// Path is the path and query, e.g. /api/chirps?sort=desc.
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body is marshalled to JSON.
+	Body any `json:"body,omitempty"`
+}
+
+type BatchResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body is the JSON body, or a JSON string for other content types.
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+type GraphQLRequest struct {
+	Query         string             `json:"query,omitempty"`
+	OperationName string             `json:"operationName,omitempty"`
+	Variables     map[string]any     `json:"variables,omitempty"`
+	Extensions    *GraphQLExtensions `json:"extensions,omitempty"`
+}
+
+// GraphQLExtensions carries a persisted query: set PersistedQuery and leave
+// GraphQLRequest.Query empty, then send the query too if the error code is
+// PERSISTED_QUERY_NOT_FOUND.
+type GraphQLExtensions struct {
+	PersistedQuery *PersistedQuery `json:"persistedQuery,omitempty"`
+}
+
+type PersistedQuery struct {
+	Version    int    `json:"version"`
+	Sha256Hash string `json:"sha256Hash"`
+}
+
+type GraphQLResponse struct {
+	Data   json.RawMessage `json:"data,omitempty"`
+	Errors []GraphQLError  `json:"errors,omitempty"`
+}
+
+type GraphQLError struct {
+	Message    string            `json:"message"`
+	Locations  []GraphQLLocation `json:"locations,omitempty"`
+	Path       []any             `json:"path,omitempty"`
+	Extensions map[string]any    `json:"extensions,omitempty"`
+}
+
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type LinkPreview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+type CreateChirpRequest struct {
+	Body string `json:"body"`
+	// UserID is ignored by the server, the author is the token's user.
+	UserID        string       `json:"user_id,omitempty"`
+	PublishAt     *time.Time   `json:"publish_at,omitempty"`
+	Draft         bool         `json:"draft,omitempty"`
+	Poll          *PollRequest `json:"poll,omitempty"`
+	QuotedChirpID *uuid.UUID   `json:"quoted_chirp_id,omitempty"`
+	ReplyToID     *uuid.UUID   `json:"reply_to_id,omitempty"`
+}
+
+type ChirpDraft struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Body      string    `json:"body"`
+	UserID    uuid.UUID `json:"user_id"`
+	// PublishAt is nil for drafts and set for scheduled chirps.
+	PublishAt *time.Time `json:"publish_at"`
+
+	// ETag is set from the response header by GetDraft and UpdateDraft.
+	ETag string `json:"-"`
+}
+
+type UpdateDraftRequest struct {
+	Body string `json:"body"`
+	// PublishAt nil turns a scheduled chirp back into a draft.
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+type Poll struct {
+	ID            uuid.UUID    `json:"id"`
+	ExpiresAt     time.Time    `json:"expires_at"`
+	Closed        bool         `json:"closed"`
+	TotalVotes    int64        `json:"total_votes"`
+	Options       []PollOption `json:"options"`
+	VotedOptionID *uuid.UUID   `json:"voted_option_id,omitempty"`
+}
+
+type PollOption struct {
+	ID    uuid.UUID `json:"id"`
+	Label string    `json:"label"`
+	Votes int64     `json:"votes"`
+}
+
+type PollRequest struct {
+	Options   []string  `json:"options"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type PollVoteRequest struct {
+	OptionID uuid.UUID `json:"option_id"`
+}
+
+type Conversation struct {
+	ID           uuid.UUID                 `json:"id"`
+	CreatedAt    time.Time                 `json:"created_at"`
+	UpdatedAt    time.Time                 `json:"updated_at"`
+	CreatedBy    uuid.UUID                 `json:"created_by"`
+	Participants []ConversationParticipant `json:"participants"`
+}
+
+type ConversationParticipant struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	JoinedAt   time.Time  `json:"joined_at"`
+	LastReadAt *time.Time `json:"last_read_at"`
+}
+
+type CreateConversationRequest struct {
+	ParticipantIDs []uuid.UUID `json:"participant_ids"`
+}
+
+type Message struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Body           string    `json:"body"`
+}
+
+type MessagesResponse struct {
+	Messages   []Message `json:"messages"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+type SendMessageRequest struct {
+	Body string `json:"body"`
+}
+
+type Notification struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Type      string     `json:"type"`
+	ActorID   *uuid.UUID `json:"actor_id"`
+	ChirpID   *uuid.UUID `json:"chirp_id"`
+	ReadAt    *time.Time `json:"read_at"`
+}
+
+type NotificationsResponse struct {
+	Notifications []Notification `json:"notifications"`
+	UnreadCount   int64          `json:"unread_count"`
+}
+
+// NotificationPreferences maps a notification type (reply, mention, follow,
+// chirpy_red) to whether it is enabled.
+type NotificationPreferences map[string]bool
+
+type CreateReportRequest struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details,omitempty"`
+}
+
+type Report struct {
+	ID             uuid.UUID  `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ReporterID     uuid.UUID  `json:"reporter_id"`
+	TargetType     string     `json:"target_type"`
+	TargetID       uuid.UUID  `json:"target_id"`
+	ReportedUserID uuid.UUID  `json:"reported_user_id"`
+	Reason         string     `json:"reason"`
+	Details        string     `json:"details"`
+	Status         string     `json:"status"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy     *uuid.UUID `json:"resolved_by,omitempty"`
+	Resolution     string     `json:"resolution,omitempty"`
+}
+
+type ResolveReportRequest struct {
+	Action string `json:"action"`
+	Note   string `json:"note,omitempty"`
+	// SuspendUntil is only used by suspend_user, nil suspends for good.
+	SuspendUntil *time.Time `json:"suspend_until,omitempty"`
+}
+
+type ModerationAction struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ModeratorID uuid.UUID  `json:"moderator_id"`
+	ReportID    *uuid.UUID `json:"report_id,omitempty"`
+	Action      string     `json:"action"`
+	TargetType  string     `json:"target_type"`
+	TargetID    uuid.UUID  `json:"target_id"`
+	Note        string     `json:"note"`
+}
+
+type AuditEvent struct {
+	ID         uuid.UUID       `json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	EventType  string          `json:"event_type"`
+	ActorID    *uuid.UUID      `json:"actor_id,omitempty"`
+	SubjectID  *uuid.UUID      `json:"subject_id,omitempty"`
+	TargetType string          `json:"target_type,omitempty"`
+	TargetID   *uuid.UUID      `json:"target_id,omitempty"`
+	IP         string          `json:"ip"`
+	UserAgent  string          `json:"user_agent"`
+	RequestID  string          `json:"request_id"`
+	Details    json.RawMessage `json:"details"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type UserRole struct {
+	UserID uuid.UUID `json:"user_id"`
+	Email  string    `json:"email"`
+	Role   string    `json:"role"`
+}
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason"`
+	// Until nil suspends for good.
+	Until      *time.Time `json:"until"`
+	HideChirps bool       `json:"hide_chirps,omitempty"`
+}
+
+type UnsuspendUserRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+type Suspension struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UserID      uuid.UUID  `json:"user_id"`
+	SuspendedBy uuid.UUID  `json:"suspended_by"`
+	Reason      string     `json:"reason"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	HideChirps  bool       `json:"hide_chirps"`
+	LiftedAt    *time.Time `json:"lifted_at,omitempty"`
+	LiftedBy    *uuid.UUID `json:"lifted_by,omitempty"`
+}
+
+type PolkaWebhook struct {
+	Event string           `json:"event"`
+	Data  PolkaWebhookData `json:"data"`
+}
+
+type PolkaWebhookData struct {
+	UserID uuid.UUID `json:"user_id"`
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// BlockUser hides your chirps from the user and theirs from you.
+func (c *Client) BlockUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: pathf("/api/users/%s/block", userID), auth: authBearer}, nil)
+	return err
+}
+
+func (c *Client) UnblockUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := c.do(ctx, &request{method: http.MethodDelete, path: pathf("/api/users/%s/block", userID), auth: authBearer}, nil)
+	return err
+}
+
+func (c *Client) ListBlocks(ctx context.Context) ([]UserRelation, error) {
+	var users []UserRelation
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: "/api/users/me/blocks", auth: authBearer}, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// MuteUser hides the user's chirps from your listings.
+func (c *Client) MuteUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: pathf("/api/users/%s/mute", userID), auth: authBearer}, nil)
+	return err
+}
+
+func (c *Client) UnmuteUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := c.do(ctx, &request{method: http.MethodDelete, path: pathf("/api/users/%s/mute", userID), auth: authBearer}, nil)
+	return err
+}
+
+func (c *Client) ListMutes(ctx context.Context) ([]UserRelation, error) {
+	var users []UserRelation
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: "/api/users/me/mutes", auth: authBearer}, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// FollowUser follows the user, who gets a follow notification.
+func (c *Client) FollowUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: pathf("/api/users/%s/follow", userID), auth: authBearer}, nil)
+	return err
+}
+
+func (c *Client) UnfollowUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := c.do(ctx, &request{method: http.MethodDelete, path: pathf("/api/users/%s/follow", userID), auth: authBearer}, nil)
+	return err
+}
+
+// ListFollowers returns a page of the users following you, newest first.
+func (c *Client) ListFollowers(ctx context.Context, page Page) ([]UserRelation, error) {
+	var users []UserRelation
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: "/api/users/me/followers", query: page.query(nil), auth: authBearer}, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// ListFollowing returns a page of the users you follow, newest first.
+func (c *Client) ListFollowing(ctx context.Context, page Page) ([]UserRelation, error) {
+	var users []UserRelation
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: "/api/users/me/following", query: page.query(nil), auth: authBearer}, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// ListSecurityLog returns a page of the security events of your account,
+// newest first.
+func (c *Client) ListSecurityLog(ctx context.Context, page Page) ([]AuditEvent, error) {
+	var events []AuditEvent
+	if _, err := c.do(ctx, &request{method: http.MethodGet, path: "/api/users/me/security-log", query: page.query(nil), auth: authBearer}, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// SecurityLog iterates over the whole security log, pageSize at a time.
+func (c *Client) SecurityLog(ctx context.Context, pageSize int) iter.Seq2[AuditEvent, error] {
+	return paginate(ctx, pageSize, c.ListSecurityLog)
+}
+
+// ReportChirp puts a chirp in the moderation queue.
+func (c *Client) ReportChirp(ctx context.Context, chirpID uuid.UUID, req CreateReportRequest) error {
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: pathf("/api/chirps/%s/report", chirpID), body: req, auth: authBearer}, nil)
+	return err
+}
+
+// ReportUser puts a user in the moderation queue.
+func (c *Client) ReportUser(ctx context.Context, userID uuid.UUID, req CreateReportRequest) error {
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: pathf("/api/users/%s/report", userID), body: req, auth: authBearer}, nil)
+	return err
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// EventUserUpgraded is the Polka event that makes a user Chirpy Red. The
+// server acknowledges other events without acting on them.
+const EventUserUpgraded = "user.upgraded"
+
+// SendPolkaWebhook delivers a Polka event to the server, authenticated
+// with the Polka API key. Services relaying Polka events use it; add
+// WithIdempotencyKey so a redelivery doesn't act twice.
+func (c *Client) SendPolkaWebhook(ctx context.Context, apiKey string, event PolkaWebhook, opts ...CallOption) error {
+	r := &request{method: http.MethodPost, path: "/api/polka/webhooks", body: event}
+	r.setHeader("Authorization", "ApiKey "+apiKey)
+	for _, opt := range opts {
+		opt(r)
+	}
+	_, err := c.do(ctx, r, nil)
+	return err
+}
+
+var (
+	ErrWebhookUnauthorized = errors.New("chirpy: webhook API key missing or wrong")
+	ErrWebhookInvalid      = errors.New("chirpy: invalid webhook payload")
+)
+
+// maxWebhookBytes is far more than a Polka event needs.
+const maxWebhookBytes = 64 << 10
+
+// VerifyPolkaWebhook checks that r carries apiKey the way Polka sends it,
+// "Authorization: ApiKey <key>", and decodes the event. It is for services
+// that receive Polka webhooks themselves, e.g. to relay them with
+// SendPolkaWebhook. The key is compared in constant time; the body is only
+// read once the key matched.
+func VerifyPolkaWebhook(r *http.Request, apiKey string) (*PolkaWebhook, error) {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "ApiKey ")
+	if !ok || apiKey == "" || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), []byte(apiKey)) != 1 {
+		return nil, ErrWebhookUnauthorized
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWebhookInvalid, err)
+	}
+	if len(body) > maxWebhookBytes {
+		return nil, fmt.Errorf("%w: body too large", ErrWebhookInvalid)
+	}
+
+	var event PolkaWebhook
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWebhookInvalid, err)
+	}
+	if event.Event == "" {
+		return nil, fmt.Errorf("%w: missing event", ErrWebhookInvalid)
+	}
+	if event.Event == EventUserUpgraded && event.Data.UserID == uuid.Nil {
+		return nil, fmt.Errorf("%w: missing data.user_id", ErrWebhookInvalid)
+	}
+	return &event, nil
+}
@@ -0,0 +1,172 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: audit.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createAuditEvent = `-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (id, created_at, event_type, actor_id, subject_id, target_type, target_id, ip, user_agent, request_id, details)
+VALUES (
+  gen_random_uuid(),
+  NOW(),
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  $7,
+  $8,
+  $9
+)
+`
+
+type CreateAuditEventParams struct {
+	EventType  string
+	ActorID    uuid.NullUUID
+	SubjectID  uuid.NullUUID
+	TargetType sql.NullString
+	TargetID   uuid.NullUUID
+	IP         string
+	UserAgent  string
+	RequestID  string
+	Details    json.RawMessage
+}
+
+func (q *Queries) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error {
+	_, err := q.db.ExecContext(ctx, createAuditEvent,
+		arg.EventType,
+		arg.ActorID,
+		arg.SubjectID,
+		arg.TargetType,
+		arg.TargetID,
+		arg.IP,
+		arg.UserAgent,
+		arg.RequestID,
+		arg.Details,
+	)
+	return err
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+SELECT id, created_at, event_type, actor_id, subject_id, target_type, target_id, ip, user_agent, request_id, details FROM audit_events
+WHERE ($1::text = '' OR event_type = $1::text)
+  AND ($2::uuid = '00000000-0000-0000-0000-000000000000'::uuid OR actor_id = $2::uuid)
+  AND ($3::uuid = '00000000-0000-0000-0000-000000000000'::uuid OR subject_id = $3::uuid)
+  AND created_at >= $4::timestamp
+  AND created_at < $5::timestamp
+ORDER BY created_at DESC
+LIMIT $6 OFFSET $7
+`
+
+type ListAuditEventsParams struct {
+	EventType string
+	ActorID   uuid.UUID
+	SubjectID uuid.UUID
+	Since     time.Time
+	Until     time.Time
+	RowLimit  int32
+	RowOffset int32
+}
+
+// Empty strings and uuid.Nil disable the matching filter.
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEvents,
+		arg.EventType,
+		arg.ActorID,
+		arg.SubjectID,
+		arg.Since,
+		arg.Until,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditEvent
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.EventType,
+			&i.ActorID,
+			&i.SubjectID,
+			&i.TargetType,
+			&i.TargetID,
+			&i.IP,
+			&i.UserAgent,
+			&i.RequestID,
+			&i.Details,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserSecurityEvents = `-- name: ListUserSecurityEvents :many
+SELECT id, created_at, event_type, actor_id, subject_id, target_type, target_id, ip, user_agent, request_id, details FROM audit_events
+WHERE subject_id = $1::uuid
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListUserSecurityEventsParams struct {
+	SubjectID uuid.UUID
+	RowLimit  int32
+	RowOffset int32
+}
+
+func (q *Queries) ListUserSecurityEvents(ctx context.Context, arg ListUserSecurityEventsParams) ([]AuditEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listUserSecurityEvents, arg.SubjectID, arg.RowLimit, arg.RowOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditEvent
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.EventType,
+			&i.ActorID,
+			&i.SubjectID,
+			&i.TargetType,
+			&i.TargetID,
+			&i.IP,
+			&i.UserAgent,
+			&i.RequestID,
+			&i.Details,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,188 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: blocks.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const blockUser = `-- name: BlockUser :exec
+INSERT INTO user_blocks (blocker_id, blocked_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING
+`
+
+type BlockUserParams struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+}
+
+func (q *Queries) BlockUser(ctx context.Context, arg BlockUserParams) error {
+	_, err := q.db.ExecContext(ctx, blockUser, arg.BlockerID, arg.BlockedID)
+	return err
+}
+
+const hasBlockWith = `-- name: HasBlockWith :one
+SELECT EXISTS (
+  SELECT 1 FROM user_blocks b
+  WHERE (b.blocker_id = $1 AND b.blocked_id = ANY($2::uuid[]))
+     OR (b.blocked_id = $1 AND b.blocker_id = ANY($2::uuid[]))
+)
+`
+
+type HasBlockWithParams struct {
+	UserID   uuid.UUID
+	OtherIds []uuid.UUID
+}
+
+// Whether the user blocked, or was blocked by, any of the others.
+func (q *Queries) HasBlockWith(ctx context.Context, arg HasBlockWithParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, hasBlockWith, arg.UserID, pq.Array(arg.OtherIds))
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listBlockRelations = `-- name: ListBlockRelations :many
+SELECT CASE WHEN b.blocker_id = $1 THEN b.blocked_id ELSE b.blocker_id END::uuid AS other_id
+FROM user_blocks b
+WHERE b.blocker_id = $1
+   OR b.blocked_id = $1
+`
+
+// Users the user blocked or was blocked by, hidden both ways in live
+// streams.
+func (q *Queries) ListBlockRelations(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listBlockRelations, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var other_id uuid.UUID
+		if err := rows.Scan(&other_id); err != nil {
+			return nil, err
+		}
+		items = append(items, other_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBlockedUsers = `-- name: ListBlockedUsers :many
+SELECT blocker_id, blocked_id, created_at FROM user_blocks
+WHERE blocker_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListBlockedUsers(ctx context.Context, blockerID uuid.UUID) ([]UserBlock, error) {
+	rows, err := q.db.QueryContext(ctx, listBlockedUsers, blockerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserBlock
+	for rows.Next() {
+		var i UserBlock
+		if err := rows.Scan(&i.BlockerID, &i.BlockedID, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMutedUsers = `-- name: ListMutedUsers :many
+SELECT muter_id, muted_id, created_at FROM user_mutes
+WHERE muter_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListMutedUsers(ctx context.Context, muterID uuid.UUID) ([]UserMute, error) {
+	rows, err := q.db.QueryContext(ctx, listMutedUsers, muterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserMute
+	for rows.Next() {
+		var i UserMute
+		if err := rows.Scan(&i.MuterID, &i.MutedID, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const muteUser = `-- name: MuteUser :exec
+INSERT INTO user_mutes (muter_id, muted_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING
+`
+
+type MuteUserParams struct {
+	MuterID uuid.UUID
+	MutedID uuid.UUID
+}
+
+func (q *Queries) MuteUser(ctx context.Context, arg MuteUserParams) error {
+	_, err := q.db.ExecContext(ctx, muteUser, arg.MuterID, arg.MutedID)
+	return err
+}
+
+const unblockUser = `-- name: UnblockUser :exec
+DELETE FROM user_blocks
+WHERE blocker_id = $1
+  AND blocked_id = $2
+`
+
+type UnblockUserParams struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+}
+
+func (q *Queries) UnblockUser(ctx context.Context, arg UnblockUserParams) error {
+	_, err := q.db.ExecContext(ctx, unblockUser, arg.BlockerID, arg.BlockedID)
+	return err
+}
+
+const unmuteUser = `-- name: UnmuteUser :exec
+DELETE FROM user_mutes
+WHERE muter_id = $1
+  AND muted_id = $2
+`
+
+type UnmuteUserParams struct {
+	MuterID uuid.UUID
+	MutedID uuid.UUID
+}
+
+func (q *Queries) UnmuteUser(ctx context.Context, arg UnmuteUserParams) error {
+	_, err := q.db.ExecContext(ctx, unmuteUser, arg.MuterID, arg.MutedID)
+	return err
+}
@@ -0,0 +1,126 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: bookmarks.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createBookmark = `-- name: CreateBookmark :exec
+INSERT INTO bookmarks (user_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING
+`
+
+type CreateBookmarkParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+func (q *Queries) CreateBookmark(ctx context.Context, arg CreateBookmarkParams) error {
+	_, err := q.db.ExecContext(ctx, createBookmark, arg.UserID, arg.ChirpID)
+	return err
+}
+
+const deleteBookmark = `-- name: DeleteBookmark :exec
+DELETE FROM bookmarks
+WHERE user_id = $1
+  AND chirp_id = $2
+`
+
+type DeleteBookmarkParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+func (q *Queries) DeleteBookmark(ctx context.Context, arg DeleteBookmarkParams) error {
+	_, err := q.db.ExecContext(ctx, deleteBookmark, arg.UserID, arg.ChirpID)
+	return err
+}
+
+const listBookmarkedChirps = `-- name: ListBookmarkedChirps :many
+SELECT c.id, c.created_at, c.updated_at, c.body, c.user_id, c.deleted_at, c.quoted_chirp_id, c.hidden_at, c.reply_to_id, c.thread_id, b.created_at AS bookmarked_at
+FROM bookmarks b
+JOIN chirps c ON c.id = b.chirp_id
+WHERE b.user_id = $1
+  AND c.deleted_at IS NULL
+  AND c.hidden_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM user_suspensions s
+    WHERE s.user_id = c.user_id
+      AND s.hide_chirps
+      AND s.lifted_at IS NULL
+      AND (s.expires_at IS NULL OR s.expires_at > NOW())
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM user_blocks ub
+    WHERE ub.blocker_id = c.user_id
+      AND ub.blocked_id = $1
+  )
+ORDER BY b.created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListBookmarkedChirpsParams struct {
+	UserID    uuid.UUID
+	RowLimit  int32
+	RowOffset int32
+}
+
+type ListBookmarkedChirpsRow struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Body          string
+	UserID        uuid.UUID
+	DeletedAt     sql.NullTime
+	QuotedChirpID uuid.NullUUID
+	HiddenAt      sql.NullTime
+	ReplyToID     uuid.NullUUID
+	ThreadID      uuid.NullUUID
+	BookmarkedAt  time.Time
+}
+
+// Bookmarks of deleted or hidden chirps and of authors that blocked the user are
+// skipped but kept, so they come back if the chirp is restored.
+func (q *Queries) ListBookmarkedChirps(ctx context.Context, arg ListBookmarkedChirpsParams) ([]ListBookmarkedChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listBookmarkedChirps, arg.UserID, arg.RowLimit, arg.RowOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBookmarkedChirpsRow
+	for rows.Next() {
+		var i ListBookmarkedChirpsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.DeletedAt,
+			&i.QuotedChirpID,
+			&i.HiddenAt,
+			&i.ReplyToID,
+			&i.ThreadID,
+			&i.BookmarkedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
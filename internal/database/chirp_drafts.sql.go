@@ -0,0 +1,260 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: chirp_drafts.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const claimDueChirpDrafts = `-- name: ClaimDueChirpDrafts :many
+SELECT id, created_at, updated_at, user_id, body, publish_at FROM chirp_drafts
+WHERE publish_at <= NOW()
+ORDER BY publish_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`
+
+// Locks due drafts for the current transaction. Other instances skip the
+// locked rows, so every draft is published exactly once.
+func (q *Queries) ClaimDueChirpDrafts(ctx context.Context, limit int32) ([]ChirpDraft, error) {
+	rows, err := q.db.QueryContext(ctx, claimDueChirpDrafts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChirpDraft
+	for rows.Next() {
+		var i ChirpDraft
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createChirpDraft = `-- name: CreateChirpDraft :one
+INSERT INTO chirp_drafts (id, created_at, updated_at, user_id, body, publish_at)
+VALUES (
+  gen_random_uuid(),
+  NOW(),
+  NOW(),
+  $1,
+  $2,
+  NOW() + make_interval(secs => $3::float8)
+)
+RETURNING id, created_at, updated_at, user_id, body, publish_at
+`
+
+type CreateChirpDraftParams struct {
+	UserID        uuid.UUID
+	Body          string
+	PublishInSecs sql.NullFloat64
+}
+
+// publish_in_secs is NULL for drafts. The schedule is computed from NOW(),
+// like the scheduler's check, so it doesn't depend on the time zone.
+func (q *Queries) CreateChirpDraft(ctx context.Context, arg CreateChirpDraftParams) (ChirpDraft, error) {
+	row := q.db.QueryRowContext(ctx, createChirpDraft, arg.UserID, arg.Body, arg.PublishInSecs)
+	var i ChirpDraft
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const deleteChirpDraft = `-- name: DeleteChirpDraft :one
+DELETE FROM chirp_drafts
+WHERE id = $1
+  AND user_id = $2
+RETURNING id, created_at, updated_at, user_id, body, publish_at
+`
+
+type DeleteChirpDraftParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+// Removes a draft and returns it, so publishing and cancelling can't race
+// with the scheduler.
+func (q *Queries) DeleteChirpDraft(ctx context.Context, arg DeleteChirpDraftParams) (ChirpDraft, error) {
+	row := q.db.QueryRowContext(ctx, deleteChirpDraft, arg.ID, arg.UserID)
+	var i ChirpDraft
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const deleteClaimedChirpDraft = `-- name: DeleteClaimedChirpDraft :exec
+DELETE FROM chirp_drafts
+WHERE id = $1
+`
+
+func (q *Queries) DeleteClaimedChirpDraft(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteClaimedChirpDraft, id)
+	return err
+}
+
+const getChirpDraft = `-- name: GetChirpDraft :one
+SELECT id, created_at, updated_at, user_id, body, publish_at FROM chirp_drafts
+WHERE id = $1
+  AND user_id = $2
+`
+
+type GetChirpDraftParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetChirpDraft(ctx context.Context, arg GetChirpDraftParams) (ChirpDraft, error) {
+	row := q.db.QueryRowContext(ctx, getChirpDraft, arg.ID, arg.UserID)
+	var i ChirpDraft
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const getChirpDraftForUpdate = `-- name: GetChirpDraftForUpdate :one
+SELECT id, created_at, updated_at, user_id, body, publish_at FROM chirp_drafts
+WHERE id = $1
+  AND user_id = $2
+FOR UPDATE
+`
+
+type GetChirpDraftForUpdateParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetChirpDraftForUpdate(ctx context.Context, arg GetChirpDraftForUpdateParams) (ChirpDraft, error) {
+	row := q.db.QueryRowContext(ctx, getChirpDraftForUpdate, arg.ID, arg.UserID)
+	var i ChirpDraft
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const listChirpDrafts = `-- name: ListChirpDrafts :many
+SELECT id, created_at, updated_at, user_id, body, publish_at FROM chirp_drafts
+WHERE user_id = $1
+  AND (
+    $2::text = ''
+    OR ($2::text = 'draft' AND publish_at IS NULL)
+    OR ($2::text = 'scheduled' AND publish_at IS NOT NULL)
+  )
+ORDER BY publish_at NULLS LAST, updated_at DESC
+`
+
+type ListChirpDraftsParams struct {
+	UserID uuid.UUID
+	Status string
+}
+
+// status is empty for everything, 'draft' for unscheduled drafts or 'scheduled'.
+func (q *Queries) ListChirpDrafts(ctx context.Context, arg ListChirpDraftsParams) ([]ChirpDraft, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpDrafts, arg.UserID, arg.Status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChirpDraft
+	for rows.Next() {
+		var i ChirpDraft
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateChirpDraft = `-- name: UpdateChirpDraft :one
+UPDATE chirp_drafts
+SET body = $1,
+    publish_at = NOW() + make_interval(secs => $2::float8),
+    updated_at = NOW()
+WHERE id = $3
+  AND user_id = $4
+RETURNING id, created_at, updated_at, user_id, body, publish_at
+`
+
+type UpdateChirpDraftParams struct {
+	Body          string
+	PublishInSecs sql.NullFloat64
+	ID            uuid.UUID
+	UserID        uuid.UUID
+}
+
+func (q *Queries) UpdateChirpDraft(ctx context.Context, arg UpdateChirpDraftParams) (ChirpDraft, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpDraft,
+		arg.Body,
+		arg.PublishInSecs,
+		arg.ID,
+		arg.UserID,
+	)
+	var i ChirpDraft
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.PublishAt,
+	)
+	return i, err
+}
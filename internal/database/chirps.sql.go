@@ -7,29 +7,44 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, quoted_chirp_id, reply_to_id, thread_id)
 VALUES (
   gen_random_uuid(),
   NOW(),
   NOW(),
   $1,
-  $2
+  $2,
+  $3,
+  $4,
+  $5
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, deleted_at, quoted_chirp_id, hidden_at, reply_to_id, thread_id
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body          string
+	UserID        uuid.UUID
+	QuotedChirpID uuid.NullUUID
+	ReplyToID     uuid.NullUUID
+	ThreadID      uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.QuotedChirpID,
+		arg.ReplyToID,
+		arg.ThreadID,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +52,11 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.DeletedAt,
+		&i.QuotedChirpID,
+		&i.HiddenAt,
+		&i.ReplyToID,
+		&i.ThreadID,
 	)
 	return i, err
 }
@@ -50,25 +70,68 @@ func (q *Queries) DeleteAllChirps(ctx context.Context) error {
 	return err
 }
 
-const deleteSingleChirp = `-- name: DeleteSingleChirp :exec
-DELETE FROM chirps
-  WHERE id = $1
+const getChirpLastModified = `-- name: GetChirpLastModified :one
+SELECT GREATEST(
+  c.updated_at,
+  (SELECT p.expires_at FROM polls p
+    WHERE p.chirp_id = c.id
+      AND p.expires_at <= NOW()),
+  (SELECT q.updated_at FROM chirps q
+    WHERE q.id = c.quoted_chirp_id),
+  (SELECT MAX(fetched_at) FROM link_previews
+    WHERE url = ANY($1::text[]))
+)::timestamp AS last_modified
+FROM chirps c
+WHERE c.id = $2
 `
 
-func (q *Queries) DeleteSingleChirp(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, deleteSingleChirp, id)
-	return err
+type GetChirpLastModifiedParams struct {
+	Urls    []string
+	ChirpID uuid.UUID
+}
+
+// GetChirpsLastModified for a single chirp.
+func (q *Queries) GetChirpLastModified(ctx context.Context, arg GetChirpLastModifiedParams) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getChirpLastModified, pq.Array(arg.Urls), arg.ChirpID)
+	var last_modified time.Time
+	err := row.Scan(&last_modified)
+	return last_modified, err
 }
 
 const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id
-FROM chirps
-WHERE ($1 = '00000000-0000-0000-0000-000000000000'::uuid OR user_id = $1)
+SELECT id, created_at, updated_at, body, user_id, deleted_at, quoted_chirp_id, hidden_at, reply_to_id, thread_id FROM chirps
+WHERE ($1::uuid = '00000000-0000-0000-0000-000000000000'::uuid OR user_id = $1::uuid)
+  AND deleted_at IS NULL
+  AND hidden_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM user_suspensions s
+    WHERE s.user_id = chirps.user_id
+      AND s.hide_chirps
+      AND s.lifted_at IS NULL
+      AND (s.expires_at IS NULL OR s.expires_at > NOW())
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM user_blocks b
+    WHERE (b.blocker_id = chirps.user_id AND b.blocked_id = $2::uuid)
+       OR (b.blocker_id = $2::uuid AND b.blocked_id = chirps.user_id)
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM user_mutes m
+    WHERE m.muter_id = $2::uuid
+      AND m.muted_id = chirps.user_id
+  )
 ORDER BY created_at
 `
 
-func (q *Queries) GetChirps(ctx context.Context, dollar_1 interface{}) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps, dollar_1)
+type GetChirpsParams struct {
+	AuthorID uuid.UUID
+	ViewerID uuid.UUID
+}
+
+// viewer_id is uuid.Nil for anonymous requests. Chirps are hidden when either
+// side blocked the other or the viewer muted the author.
+func (q *Queries) GetChirps(ctx context.Context, arg GetChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirps, arg.AuthorID, arg.ViewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -82,6 +145,11 @@ func (q *Queries) GetChirps(ctx context.Context, dollar_1 interface{}) ([]Chirp,
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.DeletedAt,
+			&i.QuotedChirpID,
+			&i.HiddenAt,
+			&i.ReplyToID,
+			&i.ThreadID,
 		); err != nil {
 			return nil, err
 		}
@@ -96,10 +164,332 @@ func (q *Queries) GetChirps(ctx context.Context, dollar_1 interface{}) ([]Chirp,
 	return items, nil
 }
 
-const getSingleChirp = `-- name: GetSingleChirp :one
-SELECT id, created_at, updated_at, body, user_id
+const getChirpsByAuthors = `-- name: GetChirpsByAuthors :many
+SELECT id, created_at, updated_at, body, user_id, deleted_at, quoted_chirp_id, hidden_at, reply_to_id, thread_id
+FROM (
+  SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.deleted_at, chirps.quoted_chirp_id, chirps.hidden_at, chirps.reply_to_id, chirps.thread_id, ROW_NUMBER() OVER (
+    PARTITION BY chirps.user_id
+    ORDER BY p.created_at DESC NULLS LAST,
+      CASE WHEN $1::bool THEN chirps.created_at END DESC,
+      chirps.created_at
+  ) AS row_num
+  FROM chirps
+  LEFT JOIN chirp_pins p ON p.chirp_id = chirps.id AND p.user_id = chirps.user_id
+  WHERE chirps.user_id = ANY($2::uuid[])
+    AND chirps.deleted_at IS NULL
+    AND chirps.hidden_at IS NULL
+    AND NOT EXISTS (
+      SELECT 1 FROM user_suspensions s
+      WHERE s.user_id = chirps.user_id
+        AND s.hide_chirps
+        AND s.lifted_at IS NULL
+        AND (s.expires_at IS NULL OR s.expires_at > NOW())
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM user_blocks b
+      WHERE (b.blocker_id = chirps.user_id AND b.blocked_id = $3::uuid)
+         OR (b.blocker_id = $3::uuid AND b.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM user_mutes m
+      WHERE m.muter_id = $3::uuid
+        AND m.muted_id = chirps.user_id
+    )
+) ranked
+WHERE row_num > $4::bigint
+  AND row_num <= $4::bigint + $5::bigint
+ORDER BY user_id, row_num
+`
+
+type GetChirpsByAuthorsParams struct {
+	Descending bool
+	AuthorIds  []uuid.UUID
+	ViewerID   uuid.UUID
+	RowOffset  int64
+	RowLimit   int64
+}
+
+// Batch version of GetChirps for a set of authors, with the same filters.
+// Each author's chirps are paged on their own: pinned chirps first, most
+// recently pinned first, then the rest by created_at.
+func (q *Queries) GetChirpsByAuthors(ctx context.Context, arg GetChirpsByAuthorsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByAuthors,
+		arg.Descending,
+		pq.Array(arg.AuthorIds),
+		arg.ViewerID,
+		arg.RowOffset,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.DeletedAt,
+			&i.QuotedChirpID,
+			&i.HiddenAt,
+			&i.ReplyToID,
+			&i.ThreadID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsLastModified = `-- name: GetChirpsLastModified :one
+SELECT GREATEST(
+  (SELECT MAX(c.updated_at) FROM chirps c
+    WHERE $1::uuid = '00000000-0000-0000-0000-000000000000'::uuid OR c.user_id = $1::uuid),
+  (SELECT MAX(p.expires_at) FROM polls p
+    JOIN chirps c ON c.id = p.chirp_id
+    WHERE ($1::uuid = '00000000-0000-0000-0000-000000000000'::uuid OR c.user_id = $1::uuid)
+      AND p.expires_at <= NOW()),
+  (SELECT MAX(q.updated_at) FROM chirps c
+    JOIN chirps q ON q.id = c.quoted_chirp_id
+    WHERE $1::uuid = '00000000-0000-0000-0000-000000000000'::uuid OR c.user_id = $1::uuid),
+  (SELECT MAX(fetched_at) FROM link_previews
+    WHERE url = ANY($2::text[])),
+  '0001-01-01'::timestamp
+)::timestamp AS last_modified
+`
+
+type GetChirpsLastModifiedParams struct {
+	AuthorID uuid.UUID
+	Urls     []string
+}
+
+// Latest change to the chirps GetChirps could return, including ones that
+// were deleted or hidden since: the chirps themselves (votes and pins bump
+// updated_at), polls that closed, quoted chirps and the link previews of
+// urls. Blocks, mutes and suspensions aren't tracked.
+func (q *Queries) GetChirpsLastModified(ctx context.Context, arg GetChirpsLastModifiedParams) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getChirpsLastModified, arg.AuthorID, pq.Array(arg.Urls))
+	var last_modified time.Time
+	err := row.Scan(&last_modified)
+	return last_modified, err
+}
+
+const getChirpsPage = `-- name: GetChirpsPage :many
+SELECT id, created_at, updated_at, body, user_id, deleted_at, quoted_chirp_id, hidden_at, reply_to_id, thread_id FROM chirps
+WHERE deleted_at IS NULL
+  AND hidden_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM user_suspensions s
+    WHERE s.user_id = chirps.user_id
+      AND s.hide_chirps
+      AND s.lifted_at IS NULL
+      AND (s.expires_at IS NULL OR s.expires_at > NOW())
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM user_blocks b
+    WHERE (b.blocker_id = chirps.user_id AND b.blocked_id = $1::uuid)
+       OR (b.blocker_id = $1::uuid AND b.blocked_id = chirps.user_id)
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM user_mutes m
+    WHERE m.muter_id = $1::uuid
+      AND m.muted_id = chirps.user_id
+  )
+ORDER BY CASE WHEN $2::bool THEN created_at END DESC, created_at
+LIMIT $4 OFFSET $3
+`
+
+type GetChirpsPageParams struct {
+	ViewerID   uuid.UUID
+	Descending bool
+	RowOffset  int32
+	RowLimit   int32
+}
+
+// A page of GetChirps over all authors.
+func (q *Queries) GetChirpsPage(ctx context.Context, arg GetChirpsPageParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsPage,
+		arg.ViewerID,
+		arg.Descending,
+		arg.RowOffset,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.DeletedAt,
+			&i.QuotedChirpID,
+			&i.HiddenAt,
+			&i.ReplyToID,
+			&i.ThreadID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeletedChirps = `-- name: GetDeletedChirps :many
+SELECT id, created_at, updated_at, body, user_id, deleted_at, quoted_chirp_id, hidden_at, reply_to_id, thread_id FROM chirps
+WHERE user_id = $1
+  AND deleted_at > NOW() - make_interval(secs => $2::float8)
+ORDER BY deleted_at DESC
+`
+
+type GetDeletedChirpsParams struct {
+	UserID        uuid.UUID
+	RetentionSecs float64
+}
+
+// Chirps of a user that are still restorable.
+func (q *Queries) GetDeletedChirps(ctx context.Context, arg GetDeletedChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getDeletedChirps, arg.UserID, arg.RetentionSecs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.DeletedAt,
+			&i.QuotedChirpID,
+			&i.HiddenAt,
+			&i.ReplyToID,
+			&i.ThreadID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRepliesByChirps = `-- name: GetRepliesByChirps :many
+SELECT id, created_at, updated_at, body, user_id, deleted_at, quoted_chirp_id, hidden_at, reply_to_id, thread_id
+FROM (
+  SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.deleted_at, chirps.quoted_chirp_id, chirps.hidden_at, chirps.reply_to_id, chirps.thread_id, ROW_NUMBER() OVER (
+    PARTITION BY chirps.reply_to_id
+    ORDER BY chirps.created_at
+  ) AS row_num
   FROM chirps
+  WHERE chirps.reply_to_id = ANY($1::uuid[])
+    AND chirps.deleted_at IS NULL
+    AND chirps.hidden_at IS NULL
+    AND NOT EXISTS (
+      SELECT 1 FROM user_suspensions s
+      WHERE s.user_id = chirps.user_id
+        AND s.hide_chirps
+        AND s.lifted_at IS NULL
+        AND (s.expires_at IS NULL OR s.expires_at > NOW())
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM user_blocks b
+      WHERE (b.blocker_id = chirps.user_id AND b.blocked_id = $2::uuid)
+         OR (b.blocker_id = $2::uuid AND b.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM user_mutes m
+      WHERE m.muter_id = $2::uuid
+        AND m.muted_id = chirps.user_id
+    )
+) ranked
+WHERE row_num > $3::bigint
+  AND row_num <= $3::bigint + $4::bigint
+ORDER BY reply_to_id, row_num
+`
+
+type GetRepliesByChirpsParams struct {
+	ChirpIds  []uuid.UUID
+	ViewerID  uuid.UUID
+	RowOffset int64
+	RowLimit  int64
+}
+
+// The direct replies to each of the chirps, oldest first, with the filters
+// of GetChirps. Each chirp's replies are paged on their own.
+func (q *Queries) GetRepliesByChirps(ctx context.Context, arg GetRepliesByChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getRepliesByChirps,
+		pq.Array(arg.ChirpIds),
+		arg.ViewerID,
+		arg.RowOffset,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.DeletedAt,
+			&i.QuotedChirpID,
+			&i.HiddenAt,
+			&i.ReplyToID,
+			&i.ThreadID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSingleChirp = `-- name: GetSingleChirp :one
+SELECT id, created_at, updated_at, body, user_id, deleted_at, quoted_chirp_id, hidden_at, reply_to_id, thread_id FROM chirps
   WHERE id = $1
+    AND deleted_at IS NULL
 `
 
 func (q *Queries) GetSingleChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -111,6 +501,279 @@ func (q *Queries) GetSingleChirp(ctx context.Context, id uuid.UUID) (Chirp, erro
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.DeletedAt,
+		&i.QuotedChirpID,
+		&i.HiddenAt,
+		&i.ReplyToID,
+		&i.ThreadID,
+	)
+	return i, err
+}
+
+const getThread = `-- name: GetThread :many
+SELECT id, created_at, updated_at, body, user_id, deleted_at, quoted_chirp_id, hidden_at, reply_to_id, thread_id FROM chirps
+WHERE (id = $1::uuid OR thread_id = $1::uuid)
+  AND deleted_at IS NULL
+  AND hidden_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM user_suspensions s
+    WHERE s.user_id = chirps.user_id
+      AND s.hide_chirps
+      AND s.lifted_at IS NULL
+      AND (s.expires_at IS NULL OR s.expires_at > NOW())
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM user_blocks b
+    WHERE (b.blocker_id = chirps.user_id AND b.blocked_id = $2::uuid)
+       OR (b.blocker_id = $2::uuid AND b.blocked_id = chirps.user_id)
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM user_mutes m
+    WHERE m.muter_id = $2::uuid
+      AND m.muted_id = chirps.user_id
+  )
+ORDER BY created_at
+LIMIT $4::int OFFSET $3::int
+`
+
+type GetThreadParams struct {
+	ThreadID  uuid.UUID
+	ViewerID  uuid.UUID
+	RowOffset int32
+	RowLimit  sql.NullInt32
+}
+
+// The first chirp of a thread and its replies, oldest first, with the
+// filters of GetChirps. A NULL row_limit returns the whole thread.
+func (q *Queries) GetThread(ctx context.Context, arg GetThreadParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getThread,
+		arg.ThreadID,
+		arg.ViewerID,
+		arg.RowOffset,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.DeletedAt,
+			&i.QuotedChirpID,
+			&i.HiddenAt,
+			&i.ReplyToID,
+			&i.ThreadID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getVisibleChirp = `-- name: GetVisibleChirp :one
+SELECT id, created_at, updated_at, body, user_id, deleted_at, quoted_chirp_id, hidden_at, reply_to_id, thread_id FROM chirps
+  WHERE chirps.id = $1
+    AND deleted_at IS NULL
+    AND hidden_at IS NULL
+    AND NOT EXISTS (
+      SELECT 1 FROM user_suspensions s
+      WHERE s.user_id = chirps.user_id
+        AND s.hide_chirps
+        AND s.lifted_at IS NULL
+        AND (s.expires_at IS NULL OR s.expires_at > NOW())
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM user_blocks b
+      WHERE b.blocker_id = chirps.user_id
+        AND b.blocked_id = $2::uuid
+    )
+`
+
+type GetVisibleChirpParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.UUID
+}
+
+// Like GetSingleChirp, but hides chirps of users that blocked the viewer,
+// chirps hidden by a moderator and chirps of suspended users whose chirps
+// were hidden with the suspension.
+func (q *Queries) GetVisibleChirp(ctx context.Context, arg GetVisibleChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getVisibleChirp, arg.ID, arg.ViewerID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.DeletedAt,
+		&i.QuotedChirpID,
+		&i.HiddenAt,
+		&i.ReplyToID,
+		&i.ThreadID,
+	)
+	return i, err
+}
+
+const getVisibleChirpsByIDs = `-- name: GetVisibleChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, deleted_at, quoted_chirp_id, hidden_at, reply_to_id, thread_id FROM chirps
+  WHERE id = ANY($1::uuid[])
+    AND deleted_at IS NULL
+    AND hidden_at IS NULL
+    AND NOT EXISTS (
+      SELECT 1 FROM user_suspensions s
+      WHERE s.user_id = chirps.user_id
+        AND s.hide_chirps
+        AND s.lifted_at IS NULL
+        AND (s.expires_at IS NULL OR s.expires_at > NOW())
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM user_blocks b
+      WHERE b.blocker_id = chirps.user_id
+        AND b.blocked_id = $2::uuid
+    )
+`
+
+type GetVisibleChirpsByIDsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.UUID
+}
+
+// Batch version of GetVisibleChirp, used to embed quoted chirps.
+func (q *Queries) GetVisibleChirpsByIDs(ctx context.Context, arg GetVisibleChirpsByIDsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getVisibleChirpsByIDs, pq.Array(arg.Ids), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.DeletedAt,
+			&i.QuotedChirpID,
+			&i.HiddenAt,
+			&i.ReplyToID,
+			&i.ThreadID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hideChirp = `-- name: HideChirp :one
+UPDATE chirps
+SET hidden_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+  AND hidden_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, deleted_at, quoted_chirp_id, hidden_at, reply_to_id, thread_id
+`
+
+func (q *Queries) HideChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, hideChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.DeletedAt,
+		&i.QuotedChirpID,
+		&i.HiddenAt,
+		&i.ReplyToID,
+		&i.ThreadID,
 	)
 	return i, err
 }
+
+const purgeDeletedChirps = `-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE deleted_at < NOW() - make_interval(secs => $1::float8)
+`
+
+func (q *Queries) PurgeDeletedChirps(ctx context.Context, retentionSecs float64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedChirps, retentionSecs)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND user_id = $2
+  AND deleted_at > NOW() - make_interval(secs => $3::float8)
+RETURNING id, created_at, updated_at, body, user_id, deleted_at, quoted_chirp_id, hidden_at, reply_to_id, thread_id
+`
+
+type RestoreChirpParams struct {
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	RetentionSecs float64
+}
+
+func (q *Queries) RestoreChirp(ctx context.Context, arg RestoreChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, arg.ID, arg.UserID, arg.RetentionSecs)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.DeletedAt,
+		&i.QuotedChirpID,
+		&i.HiddenAt,
+		&i.ReplyToID,
+		&i.ThreadID,
+	)
+	return i, err
+}
+
+const softDeleteChirp = `-- name: SoftDeleteChirp :execrows
+UPDATE chirps
+SET deleted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+  AND deleted_at IS NULL
+`
+
+func (q *Queries) SoftDeleteChirp(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, softDeleteChirp, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,226 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: follows.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const followUser = `-- name: FollowUser :execrows
+INSERT INTO user_follows (follower_id, followed_id, created_at)
+SELECT $1::uuid, $2::uuid, NOW()
+WHERE NOT EXISTS (
+  SELECT 1 FROM user_blocks b
+  WHERE (b.blocker_id = $1 AND b.blocked_id = $2)
+     OR (b.blocker_id = $2 AND b.blocked_id = $1)
+)
+ON CONFLICT DO NOTHING
+`
+
+type FollowUserParams struct {
+	FollowerID uuid.UUID
+	FollowedID uuid.UUID
+}
+
+// No row when the user already follows them or either blocked the other.
+func (q *Queries) FollowUser(ctx context.Context, arg FollowUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, followUser, arg.FollowerID, arg.FollowedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFollowersByUsers = `-- name: GetFollowersByUsers :many
+SELECT followed_id, follower_id
+FROM (
+  SELECT f.follower_id, f.followed_id, f.created_at, ROW_NUMBER() OVER (
+    PARTITION BY f.followed_id
+    ORDER BY f.created_at DESC
+  ) AS row_num
+  FROM user_follows f
+  WHERE f.followed_id = ANY($1::uuid[])
+) ranked
+WHERE row_num > $2::bigint
+  AND row_num <= $2::bigint + $3::bigint
+ORDER BY followed_id, row_num
+`
+
+type GetFollowersByUsersParams struct {
+	UserIds   []uuid.UUID
+	RowOffset int64
+	RowLimit  int64
+}
+
+type GetFollowersByUsersRow struct {
+	FollowedID uuid.UUID
+	FollowerID uuid.UUID
+}
+
+// Batch version of ListFollowers, each user's list is paged on its own.
+func (q *Queries) GetFollowersByUsers(ctx context.Context, arg GetFollowersByUsersParams) ([]GetFollowersByUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowersByUsers, pq.Array(arg.UserIds), arg.RowOffset, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFollowersByUsersRow
+	for rows.Next() {
+		var i GetFollowersByUsersRow
+		if err := rows.Scan(&i.FollowedID, &i.FollowerID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFollowingByUsers = `-- name: GetFollowingByUsers :many
+SELECT follower_id, followed_id
+FROM (
+  SELECT f.follower_id, f.followed_id, f.created_at, ROW_NUMBER() OVER (
+    PARTITION BY f.follower_id
+    ORDER BY f.created_at DESC
+  ) AS row_num
+  FROM user_follows f
+  WHERE f.follower_id = ANY($1::uuid[])
+) ranked
+WHERE row_num > $2::bigint
+  AND row_num <= $2::bigint + $3::bigint
+ORDER BY follower_id, row_num
+`
+
+type GetFollowingByUsersParams struct {
+	UserIds   []uuid.UUID
+	RowOffset int64
+	RowLimit  int64
+}
+
+type GetFollowingByUsersRow struct {
+	FollowerID uuid.UUID
+	FollowedID uuid.UUID
+}
+
+// Batch version of ListFollowing, each user's list is paged on its own.
+func (q *Queries) GetFollowingByUsers(ctx context.Context, arg GetFollowingByUsersParams) ([]GetFollowingByUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowingByUsers, pq.Array(arg.UserIds), arg.RowOffset, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFollowingByUsersRow
+	for rows.Next() {
+		var i GetFollowingByUsersRow
+		if err := rows.Scan(&i.FollowerID, &i.FollowedID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowers = `-- name: ListFollowers :many
+SELECT follower_id, followed_id, created_at FROM user_follows
+WHERE followed_id = $1
+ORDER BY created_at DESC
+LIMIT $3 OFFSET $2
+`
+
+type ListFollowersParams struct {
+	UserID    uuid.UUID
+	RowOffset int32
+	RowLimit  int32
+}
+
+func (q *Queries) ListFollowers(ctx context.Context, arg ListFollowersParams) ([]UserFollow, error) {
+	rows, err := q.db.QueryContext(ctx, listFollowers, arg.UserID, arg.RowOffset, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserFollow
+	for rows.Next() {
+		var i UserFollow
+		if err := rows.Scan(&i.FollowerID, &i.FollowedID, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowing = `-- name: ListFollowing :many
+SELECT follower_id, followed_id, created_at FROM user_follows
+WHERE follower_id = $1
+ORDER BY created_at DESC
+LIMIT $3 OFFSET $2
+`
+
+type ListFollowingParams struct {
+	UserID    uuid.UUID
+	RowOffset int32
+	RowLimit  int32
+}
+
+func (q *Queries) ListFollowing(ctx context.Context, arg ListFollowingParams) ([]UserFollow, error) {
+	rows, err := q.db.QueryContext(ctx, listFollowing, arg.UserID, arg.RowOffset, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserFollow
+	for rows.Next() {
+		var i UserFollow
+		if err := rows.Scan(&i.FollowerID, &i.FollowedID, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const unfollowUser = `-- name: UnfollowUser :exec
+DELETE FROM user_follows
+WHERE follower_id = $1
+  AND followed_id = $2
+`
+
+type UnfollowUserParams struct {
+	FollowerID uuid.UUID
+	FollowedID uuid.UUID
+}
+
+func (q *Queries) UnfollowUser(ctx context.Context, arg UnfollowUserParams) error {
+	_, err := q.db.ExecContext(ctx, unfollowUser, arg.FollowerID, arg.FollowedID)
+	return err
+}
@@ -0,0 +1,131 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: idempotency_keys.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+const claimIdempotencyKey = `-- name: ClaimIdempotencyKey :one
+INSERT INTO idempotency_keys (scope, key, fingerprint, created_at, expires_at)
+VALUES ($1, $2, $3, NOW(), NOW() + INTERVAL '24 hours')
+ON CONFLICT (scope, key) DO UPDATE
+SET fingerprint = EXCLUDED.fingerprint,
+    created_at = EXCLUDED.created_at,
+    expires_at = EXCLUDED.expires_at,
+    response_status = NULL,
+    response_content_type = NULL,
+    response_body = NULL
+WHERE idempotency_keys.expires_at < NOW()
+   OR (idempotency_keys.response_status IS NULL AND idempotency_keys.created_at < NOW() - INTERVAL '1 minute')
+RETURNING scope, key, fingerprint, created_at, expires_at, response_status, response_content_type, response_body
+`
+
+type ClaimIdempotencyKeyParams struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+}
+
+// Takes the key for a new request. An existing key is only taken over when
+// it expired or its request never finished; otherwise there are no rows.
+func (q *Queries) ClaimIdempotencyKey(ctx context.Context, arg ClaimIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, claimIdempotencyKey, arg.Scope, arg.Key, arg.Fingerprint)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Scope,
+		&i.Key,
+		&i.Fingerprint,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.ResponseStatus,
+		&i.ResponseContentType,
+		&i.ResponseBody,
+	)
+	return i, err
+}
+
+const completeIdempotencyKey = `-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET response_status = $1,
+    response_content_type = $2,
+    response_body = $3
+WHERE scope = $4 AND key = $5
+`
+
+type CompleteIdempotencyKeyParams struct {
+	ResponseStatus      sql.NullInt32
+	ResponseContentType sql.NullString
+	ResponseBody        []byte
+	Scope               string
+	Key                 string
+}
+
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) error {
+	_, err := q.db.ExecContext(ctx, completeIdempotencyKey,
+		arg.ResponseStatus,
+		arg.ResponseContentType,
+		arg.ResponseBody,
+		arg.Scope,
+		arg.Key,
+	)
+	return err
+}
+
+const deleteExpiredIdempotencyKeys = `-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE expires_at < NOW()
+`
+
+func (q *Queries) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredIdempotencyKeys)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT scope, key, fingerprint, created_at, expires_at, response_status, response_content_type, response_body FROM idempotency_keys
+WHERE scope = $1 AND key = $2
+`
+
+type GetIdempotencyKeyParams struct {
+	Scope string
+	Key   string
+}
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, getIdempotencyKey, arg.Scope, arg.Key)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Scope,
+		&i.Key,
+		&i.Fingerprint,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.ResponseStatus,
+		&i.ResponseContentType,
+		&i.ResponseBody,
+	)
+	return i, err
+}
+
+const releaseIdempotencyKey = `-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE scope = $1 AND key = $2
+`
+
+type ReleaseIdempotencyKeyParams struct {
+	Scope string
+	Key   string
+}
+
+func (q *Queries) ReleaseIdempotencyKey(ctx context.Context, arg ReleaseIdempotencyKeyParams) error {
+	_, err := q.db.ExecContext(ctx, releaseIdempotencyKey, arg.Scope, arg.Key)
+	return err
+}
@@ -0,0 +1,123 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: link_previews.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+const getLinkPreview = `-- name: GetLinkPreview :one
+SELECT url, fetched_at, ok, title, description, image_url, site_name FROM link_previews
+WHERE url = $1
+  AND fetched_at > NOW() - make_interval(secs => CASE
+    WHEN ok THEN $2::float8
+    ELSE $3::float8
+  END)
+`
+
+type GetLinkPreviewParams struct {
+	URL              string
+	MaxAgeSecs       float64
+	FailedMaxAgeSecs float64
+}
+
+// No row once the preview is older than its TTL, failed fetches have their
+// own.
+func (q *Queries) GetLinkPreview(ctx context.Context, arg GetLinkPreviewParams) (LinkPreview, error) {
+	row := q.db.QueryRowContext(ctx, getLinkPreview, arg.URL, arg.MaxAgeSecs, arg.FailedMaxAgeSecs)
+	var i LinkPreview
+	err := row.Scan(
+		&i.URL,
+		&i.FetchedAt,
+		&i.Ok,
+		&i.Title,
+		&i.Description,
+		&i.ImageURL,
+		&i.SiteName,
+	)
+	return i, err
+}
+
+const getLinkPreviews = `-- name: GetLinkPreviews :many
+SELECT url, fetched_at, ok, title, description, image_url, site_name FROM link_previews
+WHERE url = ANY($1::text[])
+  AND ok = TRUE
+`
+
+// Only successful previews, failed fetches are cached but never shown.
+func (q *Queries) GetLinkPreviews(ctx context.Context, urls []string) ([]LinkPreview, error) {
+	rows, err := q.db.QueryContext(ctx, getLinkPreviews, pq.Array(urls))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LinkPreview
+	for rows.Next() {
+		var i LinkPreview
+		if err := rows.Scan(
+			&i.URL,
+			&i.FetchedAt,
+			&i.Ok,
+			&i.Title,
+			&i.Description,
+			&i.ImageURL,
+			&i.SiteName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertLinkPreview = `-- name: UpsertLinkPreview :exec
+INSERT INTO link_previews (url, fetched_at, ok, title, description, image_url, site_name)
+VALUES (
+  $1,
+  NOW(),
+  $2,
+  $3,
+  $4,
+  $5,
+  $6
+)
+ON CONFLICT (url) DO UPDATE
+SET fetched_at = EXCLUDED.fetched_at,
+    ok = EXCLUDED.ok,
+    title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    image_url = EXCLUDED.image_url,
+    site_name = EXCLUDED.site_name
+`
+
+type UpsertLinkPreviewParams struct {
+	URL         string
+	Ok          bool
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+func (q *Queries) UpsertLinkPreview(ctx context.Context, arg UpsertLinkPreviewParams) error {
+	_, err := q.db.ExecContext(ctx, upsertLinkPreview,
+		arg.URL,
+		arg.Ok,
+		arg.Title,
+		arg.Description,
+		arg.ImageURL,
+		arg.SiteName,
+	)
+	return err
+}
@@ -6,17 +6,167 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type AuditEvent struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	EventType  string
+	ActorID    uuid.NullUUID
+	SubjectID  uuid.NullUUID
+	TargetType sql.NullString
+	TargetID   uuid.NullUUID
+	IP         string
+	UserAgent  string
+	RequestID  string
+	Details    json.RawMessage
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Body          string
+	UserID        uuid.UUID
+	DeletedAt     sql.NullTime
+	QuotedChirpID uuid.NullUUID
+	HiddenAt      sql.NullTime
+	ReplyToID     uuid.NullUUID
+	ThreadID      uuid.NullUUID
+}
+
+type ChirpDraft struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	UserID    uuid.UUID
 	Body      string
+	PublishAt sql.NullTime
+}
+
+type ChirpPin struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Conversation struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	CreatedBy uuid.UUID
+}
+
+type ConversationParticipant struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadAt     sql.NullTime
+}
+
+type IdempotencyKey struct {
+	Scope               string
+	Key                 string
+	Fingerprint         string
+	CreatedAt           time.Time
+	ExpiresAt           time.Time
+	ResponseStatus      sql.NullInt32
+	ResponseContentType sql.NullString
+	ResponseBody        []byte
+}
+
+type LinkPreview struct {
+	URL         string
+	FetchedAt   time.Time
+	Ok          bool
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+type LoginThrottle struct {
+	Scope         string
+	Key           string
+	Failures      int32
+	LastFailureAt time.Time
+	LockedUntil   sql.NullTime
+}
+
+type Message struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+}
+
+type ModerationAction struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	ModeratorID uuid.UUID
+	ReportID    uuid.NullUUID
+	Action      string
+	TargetType  string
+	TargetID    uuid.UUID
+	Note        string
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	ActorID   uuid.NullUUID
+	Type      string
+	ChirpID   uuid.NullUUID
+	ReadAt    sql.NullTime
+}
+
+type NotificationPreference struct {
 	UserID    uuid.UUID
+	Type      string
+	Enabled   bool
+	UpdatedAt time.Time
+}
+
+type Poll struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	ChirpID   uuid.UUID
+	ExpiresAt time.Time
+	FrozenAt  sql.NullTime
+}
+
+type PollOption struct {
+	ID         uuid.UUID
+	PollID     uuid.UUID
+	Position   int32
+	Label      string
+	FinalVotes sql.NullInt64
+}
+
+type PollVote struct {
+	PollID    uuid.UUID
+	UserID    uuid.UUID
+	OptionID  uuid.UUID
+	CreatedAt time.Time
+}
+
+type RateLimitBucket struct {
+	Key       string
+	Tokens    float64
+	UpdatedAt time.Time
+	Allowed   bool
 }
 
 type RefreshToken struct {
@@ -28,6 +178,21 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type Report struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	ReporterID     uuid.UUID
+	TargetType     string
+	TargetID       uuid.UUID
+	ReportedUserID uuid.UUID
+	Reason         string
+	Details        string
+	Status         string
+	ResolvedAt     sql.NullTime
+	ResolvedBy     uuid.NullUUID
+	Resolution     sql.NullString
+}
+
 type User struct {
 	ID             uuid.UUID
 	CreatedAt      time.Time
@@ -35,4 +200,35 @@ type User struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    bool
+	Role           string
+}
+
+type UserBlock struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type UserFollow struct {
+	FollowerID uuid.UUID
+	FollowedID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type UserMute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type UserSuspension struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UserID      uuid.UUID
+	SuspendedBy uuid.UUID
+	Reason      string
+	ExpiresAt   sql.NullTime
+	HideChirps  bool
+	LiftedAt    sql.NullTime
+	LiftedBy    uuid.NullUUID
 }
@@ -0,0 +1,303 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: polls.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createPoll = `-- name: CreatePoll :one
+INSERT INTO polls (id, created_at, chirp_id, expires_at, frozen_at)
+VALUES (
+  gen_random_uuid(),
+  NOW(),
+  $1,
+  NOW() + make_interval(secs => $2::float8),
+  NULL
+)
+RETURNING id, created_at, chirp_id, expires_at, frozen_at
+`
+
+type CreatePollParams struct {
+	ChirpID       uuid.UUID
+	ExpiresInSecs float64
+}
+
+// The expiry is computed from NOW(), like the checks against it, so it
+// doesn't depend on the time zone.
+func (q *Queries) CreatePoll(ctx context.Context, arg CreatePollParams) (Poll, error) {
+	row := q.db.QueryRowContext(ctx, createPoll, arg.ChirpID, arg.ExpiresInSecs)
+	var i Poll
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.ChirpID,
+		&i.ExpiresAt,
+		&i.FrozenAt,
+	)
+	return i, err
+}
+
+const createPollOption = `-- name: CreatePollOption :one
+INSERT INTO poll_options (id, poll_id, position, label, final_votes)
+VALUES (
+  gen_random_uuid(),
+  $1,
+  $2,
+  $3,
+  NULL
+)
+RETURNING id, poll_id, position, label, final_votes
+`
+
+type CreatePollOptionParams struct {
+	PollID   uuid.UUID
+	Position int32
+	Label    string
+}
+
+func (q *Queries) CreatePollOption(ctx context.Context, arg CreatePollOptionParams) (PollOption, error) {
+	row := q.db.QueryRowContext(ctx, createPollOption, arg.PollID, arg.Position, arg.Label)
+	var i PollOption
+	err := row.Scan(
+		&i.ID,
+		&i.PollID,
+		&i.Position,
+		&i.Label,
+		&i.FinalVotes,
+	)
+	return i, err
+}
+
+const freezeExpiredPolls = `-- name: FreezeExpiredPolls :execrows
+WITH frozen AS (
+  UPDATE polls
+  SET frozen_at = NOW()
+  WHERE frozen_at IS NULL
+    AND expires_at <= NOW() - INTERVAL '1 minute'
+  RETURNING polls.id
+)
+UPDATE poll_options
+SET final_votes = (
+  SELECT COUNT(*) FROM poll_votes v
+  WHERE v.option_id = poll_options.id
+)
+FROM frozen
+WHERE poll_options.poll_id = frozen.id
+`
+
+// Stores the final tally of every expired poll so the results no longer
+// change, e.g. when a voter deletes their account. The grace period lets
+// votes that were in flight at expiry commit before counting.
+func (q *Queries) FreezeExpiredPolls(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, freezeExpiredPolls)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getPollByChirpID = `-- name: GetPollByChirpID :one
+SELECT id, created_at, chirp_id, expires_at, frozen_at FROM polls
+WHERE chirp_id = $1
+`
+
+func (q *Queries) GetPollByChirpID(ctx context.Context, chirpID uuid.UUID) (Poll, error) {
+	row := q.db.QueryRowContext(ctx, getPollByChirpID, chirpID)
+	var i Poll
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.ChirpID,
+		&i.ExpiresAt,
+		&i.FrozenAt,
+	)
+	return i, err
+}
+
+const getPollTallies = `-- name: GetPollTallies :many
+SELECT o.id, o.poll_id, o.position, o.label,
+  COALESCE(o.final_votes, (
+    SELECT COUNT(*) FROM poll_votes v
+    WHERE v.option_id = o.id
+  ))::bigint AS votes
+FROM poll_options o
+WHERE o.poll_id = ANY($1::uuid[])
+ORDER BY o.poll_id, o.position
+`
+
+type GetPollTalliesRow struct {
+	ID       uuid.UUID
+	PollID   uuid.UUID
+	Position int32
+	Label    string
+	Votes    int64
+}
+
+// Frozen polls report their final counts, open ones are counted live.
+func (q *Queries) GetPollTallies(ctx context.Context, pollIds []uuid.UUID) ([]GetPollTalliesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPollTallies, pq.Array(pollIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPollTalliesRow
+	for rows.Next() {
+		var i GetPollTalliesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PollID,
+			&i.Position,
+			&i.Label,
+			&i.Votes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPollsByChirpIDs = `-- name: GetPollsByChirpIDs :many
+SELECT id, created_at, chirp_id, expires_at, frozen_at, (frozen_at IS NOT NULL OR expires_at <= NOW())::boolean AS closed
+FROM polls
+WHERE chirp_id = ANY($1::uuid[])
+`
+
+type GetPollsByChirpIDsRow struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	ChirpID   uuid.UUID
+	ExpiresAt time.Time
+	FrozenAt  sql.NullTime
+	Closed    bool
+}
+
+// closed matches the expiry check of VoteInPoll.
+func (q *Queries) GetPollsByChirpIDs(ctx context.Context, chirpIds []uuid.UUID) ([]GetPollsByChirpIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPollsByChirpIDs, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPollsByChirpIDsRow
+	for rows.Next() {
+		var i GetPollsByChirpIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.ChirpID,
+			&i.ExpiresAt,
+			&i.FrozenAt,
+			&i.Closed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserPollVotes = `-- name: GetUserPollVotes :many
+SELECT poll_id, user_id, option_id, created_at FROM poll_votes
+WHERE user_id = $1
+  AND poll_id = ANY($2::uuid[])
+`
+
+type GetUserPollVotesParams struct {
+	UserID  uuid.UUID
+	PollIds []uuid.UUID
+}
+
+func (q *Queries) GetUserPollVotes(ctx context.Context, arg GetUserPollVotesParams) ([]PollVote, error) {
+	rows, err := q.db.QueryContext(ctx, getUserPollVotes, arg.UserID, pq.Array(arg.PollIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PollVote
+	for rows.Next() {
+		var i PollVote
+		if err := rows.Scan(
+			&i.PollID,
+			&i.UserID,
+			&i.OptionID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const voteInPoll = `-- name: VoteInPoll :execrows
+WITH vote AS (
+  INSERT INTO poll_votes (poll_id, user_id, option_id, created_at)
+  SELECT
+    $1,
+    $2,
+    $3,
+    NOW()
+  WHERE EXISTS (
+    SELECT 1 FROM polls
+    WHERE polls.id = $1
+      AND polls.expires_at > NOW()
+  )
+  AND EXISTS (
+    SELECT 1 FROM poll_options
+    WHERE poll_options.id = $3
+      AND poll_options.poll_id = $1
+  )
+  ON CONFLICT (poll_id, user_id) DO NOTHING
+  RETURNING poll_id
+)
+UPDATE chirps
+SET updated_at = NOW()
+FROM polls, vote
+WHERE polls.id = vote.poll_id
+  AND chirps.id = polls.chirp_id
+`
+
+type VoteInPollParams struct {
+	PollID   uuid.UUID
+	UserID   uuid.UUID
+	OptionID uuid.UUID
+}
+
+// The primary key on (poll_id, user_id) makes a second vote a no-op, and
+// votes on expired polls or options of another poll are never inserted.
+// A vote bumps the chirp's updated_at, its Last-Modified.
+func (q *Queries) VoteInPoll(ctx context.Context, arg VoteInPollParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, voteInPoll, arg.PollID, arg.UserID, arg.OptionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,161 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: reports.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createReport = `-- name: CreateReport :execrows
+INSERT INTO reports (id, created_at, reporter_id, target_type, target_id, reported_user_id, reason, details, status)
+VALUES (
+  gen_random_uuid(),
+  NOW(),
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  'open'
+)
+ON CONFLICT (reporter_id, target_type, target_id) WHERE status = 'open' DO NOTHING
+`
+
+type CreateReportParams struct {
+	ReporterID     uuid.UUID
+	TargetType     string
+	TargetID       uuid.UUID
+	ReportedUserID uuid.UUID
+	Reason         string
+	Details        string
+}
+
+func (q *Queries) CreateReport(ctx context.Context, arg CreateReportParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createReport,
+		arg.ReporterID,
+		arg.TargetType,
+		arg.TargetID,
+		arg.ReportedUserID,
+		arg.Reason,
+		arg.Details,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getOpenReportForUpdate = `-- name: GetOpenReportForUpdate :one
+SELECT id, created_at, reporter_id, target_type, target_id, reported_user_id, reason, details, status, resolved_at, resolved_by, resolution FROM reports
+WHERE id = $1
+  AND status = 'open'
+FOR UPDATE
+`
+
+func (q *Queries) GetOpenReportForUpdate(ctx context.Context, id uuid.UUID) (Report, error) {
+	row := q.db.QueryRowContext(ctx, getOpenReportForUpdate, id)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.ReporterID,
+		&i.TargetType,
+		&i.TargetID,
+		&i.ReportedUserID,
+		&i.Reason,
+		&i.Details,
+		&i.Status,
+		&i.ResolvedAt,
+		&i.ResolvedBy,
+		&i.Resolution,
+	)
+	return i, err
+}
+
+const listReports = `-- name: ListReports :many
+SELECT id, created_at, reporter_id, target_type, target_id, reported_user_id, reason, details, status, resolved_at, resolved_by, resolution FROM reports
+WHERE status = $1
+ORDER BY created_at
+LIMIT $2 OFFSET $3
+`
+
+type ListReportsParams struct {
+	Status    string
+	RowLimit  int32
+	RowOffset int32
+}
+
+// The queue is worked oldest first.
+func (q *Queries) ListReports(ctx context.Context, arg ListReportsParams) ([]Report, error) {
+	rows, err := q.db.QueryContext(ctx, listReports, arg.Status, arg.RowLimit, arg.RowOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Report
+	for rows.Next() {
+		var i Report
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.ReporterID,
+			&i.TargetType,
+			&i.TargetID,
+			&i.ReportedUserID,
+			&i.Reason,
+			&i.Details,
+			&i.Status,
+			&i.ResolvedAt,
+			&i.ResolvedBy,
+			&i.Resolution,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const resolveReports = `-- name: ResolveReports :execrows
+UPDATE reports
+SET status = 'resolved',
+    resolved_at = NOW(),
+    resolved_by = $1::uuid,
+    resolution = $2::text
+WHERE target_type = $3
+  AND target_id = $4
+  AND status = 'open'
+`
+
+type ResolveReportsParams struct {
+	ResolvedBy uuid.UUID
+	Resolution string
+	TargetType string
+	TargetID   uuid.UUID
+}
+
+// Resolves every open report about the same target at once.
+func (q *Queries) ResolveReports(ctx context.Context, arg ResolveReportsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, resolveReports,
+		arg.ResolvedBy,
+		arg.Resolution,
+		arg.TargetType,
+		arg.TargetID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -9,8 +9,41 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const bootstrapAdmin = `-- name: BootstrapAdmin :execrows
+UPDATE users
+SET
+  updated_at = NOW(),
+  role = 'admin'
+WHERE email = $1
+  AND NOT EXISTS (
+    SELECT 1 FROM users WHERE role = 'admin'
+  )
+`
+
+// Promotes a user to admin, but only while there is no admin at all.
+func (q *Queries) BootstrapAdmin(ctx context.Context, email string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, bootstrapAdmin, email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countAdmins = `-- name: CountAdmins :one
+SELECT COUNT(*) FROM users
+WHERE role = 'admin'
+`
+
+func (q *Queries) CountAdmins(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, created_at, updated_at, email, hashed_password)
 VALUES (
@@ -20,7 +53,7 @@ VALUES (
     $1,
     $2
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role
 `
 
 type CreateUserParams struct {
@@ -38,6 +71,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
 	)
 	return i, err
 }
@@ -51,8 +85,28 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 	return err
 }
 
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, role FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Role,
+	)
+	return i, err
+}
+
 const getUserIDByEmail = `-- name: GetUserIDByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, role FROM users
 WHERE email = $1
 `
 
@@ -66,11 +120,90 @@ func (q *Queries) GetUserIDByEmail(ctx context.Context, email string) (User, err
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getUsersByIDs = `-- name: GetUsersByIDs :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, role FROM users
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockAdmins = `-- name: LockAdmins :exec
+SELECT id FROM users
+WHERE role = 'admin'
+FOR UPDATE
+`
+
+// Locks the admin rows until the end of the transaction, so concurrent
+// demotions can't both see another admin left.
+func (q *Queries) LockAdmins(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, lockAdmins)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :one
+UPDATE users
+SET
+  updated_at = NOW(),
+  role = $2
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role
+`
+
+type SetUserRoleParams struct {
+	ID   uuid.UUID
+	Role string
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Role,
 	)
 	return i, err
 }
 
-const userUpdatePassword = `-- name: UserUpdatePassword :exec
+const userUpdatePassword = `-- name: UserUpdatePassword :execrows
 UPDATE users
 SET
   updated_at = NOW(),
@@ -83,12 +216,15 @@ type UserUpdatePasswordParams struct {
 	HashedPassword string
 }
 
-func (q *Queries) UserUpdatePassword(ctx context.Context, arg UserUpdatePasswordParams) error {
-	_, err := q.db.ExecContext(ctx, userUpdatePassword, arg.ID, arg.HashedPassword)
-	return err
+func (q *Queries) UserUpdatePassword(ctx context.Context, arg UserUpdatePasswordParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, userUpdatePassword, arg.ID, arg.HashedPassword)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const userUpgradeToChirpRed = `-- name: UserUpgradeToChirpRed :exec
+const userUpgradeToChirpRed = `-- name: UserUpgradeToChirpRed :execrows
 UPDATE users
 SET
   updated_at = NOW(),
@@ -96,7 +232,10 @@ SET
 WHERE id = $1
 `
 
-func (q *Queries) UserUpgradeToChirpRed(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, userUpgradeToChirpRed, id)
-	return err
+func (q *Queries) UserUpgradeToChirpRed(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, userUpgradeToChirpRed, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
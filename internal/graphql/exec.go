@@ -0,0 +1,617 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+// Document is a parsed and validated query document. It only depends on
+// the schema, so it can be cached and run many times.
+type Document struct {
+	doc *ast.QueryDocument
+}
+
+// Response is the result of a query, ready to be encoded as JSON.
+type Response struct {
+	// Data is left out when the query failed before it ran.
+	Data   json.RawMessage `json:"data,omitempty"`
+	Errors gqlerror.List   `json:"errors,omitempty"`
+}
+
+// Parse parses query and validates it against the schema.
+func (s *Schema) Parse(query string) (*Document, gqlerror.List) {
+	doc, errs := gqlparser.LoadQueryWithRules(s.ast, query, nil)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			setCode(err, CodeInvalidQuery)
+		}
+		return nil, errs
+	}
+	return &Document{doc: doc}, nil
+}
+
+// Exec runs the operation of doc named operationName, which may be empty
+// when doc has a single operation.
+func (s *Schema) Exec(ctx context.Context, doc *Document, operationName string, variables map[string]any) *Response {
+	op := doc.doc.Operations.ForName(operationName)
+	if op == nil {
+		if operationName == "" {
+			return ErrorResponse(CodeInvalidQuery, "operationName is required when the document has several operations")
+		}
+		return ErrorResponse(CodeInvalidQuery, "operation %q not found", operationName)
+	}
+	if op.Operation != ast.Query {
+		return ErrorResponse(CodeInvalidQuery, "only query operations are supported")
+	}
+
+	vars, err := validator.VariableValues(s.ast, op, variables)
+	if err != nil {
+		var gqlErr *gqlerror.Error
+		if !errors.As(err, &gqlErr) {
+			gqlErr = gqlerror.Wrap(err)
+		}
+		setCode(gqlErr, CodeInvalidVariables)
+		return &Response{Errors: gqlerror.List{gqlErr}}
+	}
+
+	if s.MaxDepth > 0 {
+		if d := depth(op.SelectionSet); d > s.MaxDepth {
+			return ErrorResponse(CodeTooDeep, "query is %d levels deep, the limit is %d", d, s.MaxDepth)
+		}
+	}
+	if s.MaxComplexity > 0 {
+		if c := s.complexity(op.SelectionSet, vars); c > s.MaxComplexity {
+			return ErrorResponse(CodeTooComplex, "query has a complexity of %d, the limit is %d", c, s.MaxComplexity)
+		}
+	}
+
+	e := &executor{schema: s, vars: vars}
+	root := e.objects(ctx, s.ast.Query, op.SelectionSet, []any{nil}, nil)[0]
+
+	resp := &Response{Errors: e.errs}
+	if root == nil {
+		resp.Data = json.RawMessage("null")
+		return resp
+	}
+	data, err := json.Marshal(root)
+	if err != nil {
+		log.Printf("graphql: encoding response: %v", err)
+		return ErrorResponse(CodeInternal, "internal error")
+	}
+	resp.Data = data
+	return resp
+}
+
+// ErrorResponse is a response with a single error and no data, for requests
+// that fail before they run.
+func ErrorResponse(code, format string, args ...any) *Response {
+	err := gqlerror.Errorf(format, args...)
+	setCode(err, code)
+	return &Response{Errors: gqlerror.List{err}}
+}
+
+func setCode(err *gqlerror.Error, code string) {
+	if err.Extensions == nil {
+		err.Extensions = make(map[string]any)
+	}
+	err.Extensions["code"] = code
+}
+
+type executor struct {
+	schema *Schema
+	vars   map[string]any
+	errs   gqlerror.List
+}
+
+// fieldGroup is the fields of a selection set that share a response key,
+// merged into one.
+type fieldGroup struct {
+	key    string
+	fields []*ast.Field
+}
+
+func (g *fieldGroup) selections() ast.SelectionSet {
+	if len(g.fields) == 1 {
+		return g.fields[0].SelectionSet
+	}
+	var sel ast.SelectionSet
+	for _, f := range g.fields {
+		sel = append(sel, f.SelectionSet...)
+	}
+	return sel
+}
+
+// objects executes sel on every parent, which are objects of type typ. A nil
+// result is an object that turned null because a non-null field in it did.
+//
+// Error paths don't have list indices: a field is resolved for all the
+// elements of a list at once.
+func (e *executor) objects(ctx context.Context, typ *ast.Definition, sel ast.SelectionSet, parents []any, path ast.Path) []*object {
+	out := make([]*object, len(parents))
+	for i := range out {
+		out[i] = &object{}
+	}
+
+	for _, g := range e.collect(typ, sel) {
+		f := g.fields[0]
+		fieldPath := append(path[:len(path):len(path)], ast.PathName(g.key))
+
+		if f.Name == "__typename" {
+			for _, o := range out {
+				if o != nil {
+					o.set(g.key, typ.Name)
+				}
+			}
+			continue
+		}
+
+		values, err := e.resolve(ctx, typ, f, parents)
+		reported := false
+		if err != nil {
+			e.fail(f, fieldPath, err)
+			values = make([]any, len(parents))
+			reported = true
+		}
+
+		completed, ok := e.complete(ctx, f, f.Definition.Type, g.selections(), values, fieldPath, reported)
+		for i, o := range out {
+			if o == nil {
+				continue
+			}
+			if !ok[i] {
+				out[i] = nil
+				continue
+			}
+			o.set(g.key, completed[i])
+		}
+	}
+	return out
+}
+
+// resolve calls the resolver of f for parents.
+func (e *executor) resolve(ctx context.Context, typ *ast.Definition, f *ast.Field, parents []any) (values []any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("resolver panicked: %v", r)
+		}
+	}()
+
+	resolver := e.schema.resolvers[typ.Name][f.Name]
+	if resolver == nil {
+		resolver = property(f.Name)
+	}
+	values, err = resolver(ctx, parents, Args(f.ArgumentMap(e.vars)))
+	if err == nil && len(values) != len(parents) {
+		err = fmt.Errorf("resolver of %s.%s returned %d values for %d objects", typ.Name, f.Name, len(values), len(parents))
+	}
+	return values, err
+}
+
+// complete turns the resolved values of f, of type typ, into response
+// values. ok[i] is false when values[i] turned null where typ doesn't allow
+// it, so the null propagates to the parent. reported says the values are
+// null because of an error that has been reported already.
+func (e *executor) complete(ctx context.Context, f *ast.Field, typ *ast.Type, sel ast.SelectionSet, values []any, path ast.Path, reported bool) ([]any, []bool) {
+	out := make([]any, len(values))
+	ok := make([]bool, len(values))
+	isNull := make([]bool, len(values))
+	// explained[i] means the error that made values[i] null is reported
+	explained := make([]bool, len(values))
+	for i, v := range values {
+		isNull[i] = null(v)
+		explained[i] = reported
+	}
+
+	switch {
+	case typ.Elem != nil:
+		// complete the elements of all the lists as one batch
+		var items []any
+		var owners []int
+		for i, v := range values {
+			if isNull[i] {
+				continue
+			}
+			rv := reflect.ValueOf(v)
+			if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+				e.fail(f, path, fmt.Errorf("%s resolved to %T, not a list", f.Name, v))
+				isNull[i], explained[i] = true, true
+				continue
+			}
+			for j := range rv.Len() {
+				items = append(items, rv.Index(j).Interface())
+				owners = append(owners, i)
+			}
+		}
+
+		completed, itemOK := e.complete(ctx, f, typ.Elem, sel, items, path, false)
+		lists := make([][]any, len(values))
+		for i := range values {
+			if !isNull[i] {
+				lists[i] = []any{}
+			}
+		}
+		for k, owner := range owners {
+			if !itemOK[k] {
+				isNull[owner], explained[owner] = true, true
+				continue
+			}
+			if !isNull[owner] {
+				lists[owner] = append(lists[owner], completed[k])
+			}
+		}
+		for i := range values {
+			if !isNull[i] {
+				out[i], ok[i] = lists[i], true
+			}
+		}
+
+	default:
+		def := e.schema.ast.Types[typ.NamedType]
+		switch def.Kind {
+		case ast.Scalar, ast.Enum:
+			for i, v := range values {
+				if isNull[i] {
+					continue
+				}
+				s, err := serialize(def, v)
+				if err != nil {
+					e.fail(f, path, err)
+					isNull[i], explained[i] = true, true
+					continue
+				}
+				out[i], ok[i] = s, true
+			}
+
+		case ast.Object:
+			var parents []any
+			var idx []int
+			for i, v := range values {
+				if !isNull[i] {
+					parents = append(parents, v)
+					idx = append(idx, i)
+				}
+			}
+			for k, o := range e.objects(ctx, def, sel, parents, path) {
+				if o == nil {
+					// a child has reported why
+					isNull[idx[k]], explained[idx[k]] = true, true
+					continue
+				}
+				out[idx[k]], ok[idx[k]] = o, true
+			}
+
+		default:
+			e.fail(f, path, fmt.Errorf("%s types are not supported", strings.ToLower(string(def.Kind))))
+			for i := range values {
+				isNull[i], explained[i] = true, true
+			}
+		}
+	}
+
+	nullViolation := false
+	for i := range values {
+		if !isNull[i] {
+			continue
+		}
+		out[i] = nil
+		ok[i] = !typ.NonNull
+		if typ.NonNull && !explained[i] {
+			nullViolation = true
+		}
+	}
+	if nullViolation {
+		e.fail(f, path, fmt.Errorf("non-nullable field %s resolved to null", f.Name))
+	}
+	return out, ok
+}
+
+// fail records a field error. Errors other than *Error are logged and
+// hidden from the client.
+func (e *executor) fail(f *ast.Field, path ast.Path, err error) {
+	gqlErr := &gqlerror.Error{Path: path}
+	if f.Position != nil {
+		gqlErr.Locations = []gqlerror.Location{{Line: f.Position.Line, Column: f.Position.Column}}
+	}
+
+	var clientErr *Error
+	if errors.As(err, &clientErr) {
+		gqlErr.Message = clientErr.Message
+		setCode(gqlErr, clientErr.Code)
+	} else {
+		log.Printf("graphql: %s: %v", path, err)
+		gqlErr.Message = "internal error"
+		setCode(gqlErr, CodeInternal)
+	}
+	e.errs = append(e.errs, gqlErr)
+}
+
+// collect groups the fields of sel that apply to typ by response key, in
+// query order, following fragments and @skip/@include.
+func (e *executor) collect(typ *ast.Definition, sel ast.SelectionSet) []*fieldGroup {
+	var groups []*fieldGroup
+	byKey := make(map[string]*fieldGroup)
+
+	var walk func(ast.SelectionSet)
+	walk = func(sel ast.SelectionSet) {
+		for _, s := range sel {
+			switch s := s.(type) {
+			case *ast.Field:
+				if !e.included(s.Directives) {
+					continue
+				}
+				key := s.Alias
+				if key == "" {
+					key = s.Name
+				}
+				g := byKey[key]
+				if g == nil {
+					g = &fieldGroup{key: key}
+					byKey[key] = g
+					groups = append(groups, g)
+				}
+				g.fields = append(g.fields, s)
+			case *ast.InlineFragment:
+				if e.included(s.Directives) && e.applies(s.TypeCondition, typ) {
+					walk(s.SelectionSet)
+				}
+			case *ast.FragmentSpread:
+				if e.included(s.Directives) && e.applies(s.Definition.TypeCondition, typ) {
+					walk(s.Definition.SelectionSet)
+				}
+			}
+		}
+	}
+	walk(sel)
+	return groups
+}
+
+func (e *executor) included(directives ast.DirectiveList) bool {
+	if d := directives.ForName("skip"); d != nil && d.ArgumentMap(e.vars)["if"] == true {
+		return false
+	}
+	if d := directives.ForName("include"); d != nil && d.ArgumentMap(e.vars)["if"] != true {
+		return false
+	}
+	return true
+}
+
+// applies reports whether a fragment on typeCondition applies to objects
+// of type typ.
+func (e *executor) applies(typeCondition string, typ *ast.Definition) bool {
+	if typeCondition == "" || typeCondition == typ.Name {
+		return true
+	}
+	cond := e.schema.ast.Types[typeCondition]
+	if cond == nil || !cond.IsAbstractType() {
+		return false
+	}
+	for _, t := range e.schema.ast.GetPossibleTypes(cond) {
+		if t.Name == typ.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// null reports whether v is null: nil, or a nil pointer or map. Nil slices
+// are empty lists.
+func null(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// serialize converts a leaf value to its JSON representation.
+func serialize(def *ast.Definition, v any) (any, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	v = rv.Interface()
+
+	switch def.Name {
+	case "Int":
+		var n int64
+		switch {
+		case rv.CanInt():
+			n = rv.Int()
+		case rv.CanUint() && rv.Uint() <= math.MaxInt64:
+			n = int64(rv.Uint())
+		default:
+			return nil, fmt.Errorf("can't serialize %T as Int", v)
+		}
+		if n < math.MinInt32 || n > math.MaxInt32 {
+			return nil, fmt.Errorf("%d doesn't fit in an Int", n)
+		}
+		return n, nil
+	case "Float":
+		switch {
+		case rv.CanFloat():
+			return rv.Float(), nil
+		case rv.CanInt():
+			return float64(rv.Int()), nil
+		}
+		return nil, fmt.Errorf("can't serialize %T as Float", v)
+	case "Boolean":
+		if rv.Kind() == reflect.Bool {
+			return rv.Bool(), nil
+		}
+		return nil, fmt.Errorf("can't serialize %T as Boolean", v)
+	case "String", "ID":
+		return stringValue(def, v)
+	}
+
+	if def.Kind == ast.Enum {
+		s, err := stringValue(def, v)
+		if err != nil {
+			return nil, err
+		}
+		if def.EnumValues.ForName(s.(string)) == nil {
+			return nil, fmt.Errorf("%q is not a value of %s", s, def.Name)
+		}
+		return s, nil
+	}
+
+	// custom scalars
+	switch v := v.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case json.Marshaler, string, bool:
+		return v, nil
+	}
+	if rv.CanInt() || rv.CanUint() || rv.CanFloat() {
+		return v, nil
+	}
+	return nil, fmt.Errorf("can't serialize %T as %s", v, def.Name)
+}
+
+func stringValue(def *ast.Definition, v any) (any, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.String {
+		return rv.String(), nil
+	}
+	if def.Name == "ID" && rv.CanInt() {
+		return fmt.Sprint(rv.Int()), nil
+	}
+	return nil, fmt.Errorf("can't serialize %T as %s", v, def.Name)
+}
+
+// property is the resolver of fields that don't have one.
+func property(name string) FieldFunc {
+	key := snakeCase(name)
+	return func(_ context.Context, parents []any, _ Args) ([]any, error) {
+		out := make([]any, len(parents))
+		for i, p := range parents {
+			rv := reflect.ValueOf(p)
+			for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+				rv = rv.Elem()
+			}
+
+			switch rv.Kind() {
+			case reflect.Struct:
+				index, ok := jsonFields(rv.Type())[key]
+				if !ok {
+					return nil, fmt.Errorf("%s has no field tagged %q", rv.Type(), key)
+				}
+				out[i] = rv.FieldByIndex(index).Interface()
+			case reflect.Map:
+				if rv.Type().Key().Kind() != reflect.String {
+					return nil, fmt.Errorf("can't read %q from %s", key, rv.Type())
+				}
+				if v := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key())); v.IsValid() {
+					out[i] = v.Interface()
+				}
+			default:
+				return nil, fmt.Errorf("can't read %q from %T", key, p)
+			}
+		}
+		return out, nil
+	}
+}
+
+var fieldCache sync.Map // reflect.Type -> map[string][]int
+
+// jsonFields maps the json names of the fields of struct type t, embedded
+// ones included, to their index.
+func jsonFields(t reflect.Type) map[string][]int {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.(map[string][]int)
+	}
+
+	fields := make(map[string][]int)
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if f.Anonymous && tag == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if _, ok := fields[name]; !ok {
+			fields[name] = f.Index
+		}
+	}
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+func snakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if 'A' <= r && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// object is a response object, which keeps its fields in query order.
+type object struct {
+	keys   []string
+	values []any
+}
+
+func (o *object) set(key string, value any) {
+	o.keys = append(o.keys, key)
+	o.values = append(o.values, value)
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
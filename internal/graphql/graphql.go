@@ -0,0 +1,161 @@
+// Package graphql executes GraphQL queries against resolvers that work on
+// batches. Queries are parsed and validated by gqlparser; execution goes
+// breadth first, so a field is resolved once for all the objects at the same
+// place in the result: the authors of 50 chirps are one resolver call, not
+// 50. Only queries are supported, there are no mutations or subscriptions.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// FieldFunc resolves a field for a batch of parent objects and returns one
+// value per parent, in the same order. Objects are any Go value the
+// resolvers of their type understand; leaf values are strings, numbers,
+// booleans, fmt.Stringers (IDs) and time.Time.
+type FieldFunc func(ctx context.Context, parents []any, args Args) ([]any, error)
+
+// Schema is a GraphQL schema with its resolvers. Fields without a resolver
+// are read from the parent: a struct field with the json tag of the field
+// name in snake_case (createdAt reads `json:"created_at"`), or a map key.
+type Schema struct {
+	ast       *ast.Schema
+	resolvers map[string]map[string]FieldFunc
+
+	// MaxDepth rejects queries nesting fields deeper than this, 0 means no
+	// limit. Introspection fields don't count.
+	MaxDepth int
+	// MaxComplexity rejects queries that may resolve more fields than this,
+	// 0 means no limit. A list field counts its children once per element:
+	// its limit argument if it has one, ListSize otherwise.
+	MaxComplexity int
+	ListSize      int
+}
+
+// NewSchema parses the schema in SDL. It must define a Query type.
+func NewSchema(sdl string) (*Schema, error) {
+	doc, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: sdl})
+	if err != nil {
+		return nil, err
+	}
+	if doc.Query == nil {
+		return nil, fmt.Errorf("graphql: schema has no Query type")
+	}
+
+	s := &Schema{
+		ast:       doc,
+		resolvers: make(map[string]map[string]FieldFunc),
+		ListSize:  10,
+	}
+	s.resolveIntrospection()
+	return s, nil
+}
+
+// Resolve sets the resolver of a field. It panics if the schema has no such
+// field, a mismatch between schema and resolvers is a programming error.
+func (s *Schema) Resolve(typeName, field string, f FieldFunc) {
+	def := s.ast.Types[typeName]
+	if def == nil || def.Fields.ForName(field) == nil {
+		panic(fmt.Sprintf("graphql: %s.%s is not in the schema", typeName, field))
+	}
+	if s.resolvers[typeName] == nil {
+		s.resolvers[typeName] = make(map[string]FieldFunc)
+	}
+	s.resolvers[typeName][field] = f
+}
+
+// Each makes a FieldFunc out of a function resolving one parent at a time,
+// for fields that don't need batching. The root Query parent is nil.
+func Each[T any](f func(ctx context.Context, parent T, args Args) (any, error)) FieldFunc {
+	return Batch(func(ctx context.Context, parents []T, args Args) ([]any, error) {
+		out := make([]any, len(parents))
+		for i, p := range parents {
+			v, err := f(ctx, p, args)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = v
+		}
+		return out, nil
+	})
+}
+
+// Batch makes a FieldFunc out of a function that resolves a typed batch.
+func Batch[T any](f func(ctx context.Context, parents []T, args Args) ([]any, error)) FieldFunc {
+	return func(ctx context.Context, parents []any, args Args) ([]any, error) {
+		typed := make([]T, len(parents))
+		for i, p := range parents {
+			if p == nil {
+				continue
+			}
+			t, ok := p.(T)
+			if !ok {
+				return nil, fmt.Errorf("parent is %T, want %T", p, typed[i])
+			}
+			typed[i] = t
+		}
+		return f(ctx, typed, args)
+	}
+}
+
+// Args are the coerced arguments of a field, with defaults filled in.
+type Args map[string]any
+
+// String returns a String or ID argument, "" when it is null or missing.
+func (a Args) String(name string) string {
+	s, _ := a[name].(string)
+	return s
+}
+
+// Int returns an Int argument, 0 when it is null or missing.
+func (a Args) Int(name string) int {
+	switch v := a[name].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case json.Number:
+		n, _ := strconv.Atoi(string(v))
+		return n
+	}
+	return 0
+}
+
+// Bool returns a Boolean argument, false when it is null or missing.
+func (a Args) Bool(name string) bool {
+	b, _ := a[name].(bool)
+	return b
+}
+
+// Error is a resolver error whose message is meant for clients. Any other
+// error a resolver returns is logged and reported as "internal error".
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Errorf returns an Error with the given code.
+func Errorf(code, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Error codes, in the extensions.code of response errors.
+const (
+	CodeInvalidQuery     = "invalid_query"
+	CodeInvalidVariables = "invalid_variables"
+	CodeTooDeep          = "query_too_deep"
+	CodeTooComplex       = "query_too_complex"
+	CodeInternal         = "internal_error"
+)
@@ -0,0 +1,284 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSDL = `
+scalar Time
+
+type Query {
+  post(id: ID!): Post
+  posts(limit: Int = 10): [Post!]!
+  broken: String
+}
+
+type Post {
+  id: ID!
+  title: String!
+  createdAt: Time!
+  status: Status!
+  author: Author!
+  tags: [String!]!
+  editor: Author
+}
+
+type Author {
+  id: ID!
+  name: String!
+  posts(limit: Int = 10): [Post!]!
+}
+
+enum Status { DRAFT PUBLISHED }
+`
+
+type testPost struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+	Status    string    `json:"status"`
+	AuthorID  string    `json:"-"`
+	Tags      []string  `json:"tags"`
+}
+
+type testAuthor struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// newTestSchema returns a schema over a few posts and counts the calls of
+// the author resolver.
+func newTestSchema(t *testing.T) (*Schema, *int) {
+	t.Helper()
+	s, err := NewSchema(testSDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	posts := []testPost{
+		{ID: "p1", Title: "one", CreatedAt: created, Status: "DRAFT", AuthorID: "a1", Tags: []string{"x"}},
+		{ID: "p2", Title: "two", CreatedAt: created, Status: "PUBLISHED", AuthorID: "a2"},
+		{ID: "p3", Title: "three", CreatedAt: created, Status: "PUBLISHED", AuthorID: "a1"},
+	}
+	authors := map[string]testAuthor{"a1": {"a1", "Ann"}, "a2": {"a2", "Bob"}}
+
+	s.Resolve("Query", "post", Each(func(_ context.Context, _ any, args Args) (any, error) {
+		for _, p := range posts {
+			if p.ID == args.String("id") {
+				return p, nil
+			}
+		}
+		return nil, nil
+	}))
+	s.Resolve("Query", "posts", Each(func(_ context.Context, _ any, args Args) (any, error) {
+		return posts[:min(args.Int("limit"), len(posts))], nil
+	}))
+	s.Resolve("Query", "broken", Each(func(context.Context, any, Args) (any, error) {
+		return nil, errors.New("database is down")
+	}))
+
+	authorCalls := 0
+	s.Resolve("Post", "author", Batch(func(_ context.Context, parents []testPost, _ Args) ([]any, error) {
+		authorCalls++
+		out := make([]any, len(parents))
+		for i, p := range parents {
+			out[i] = authors[p.AuthorID]
+		}
+		return out, nil
+	}))
+	s.Resolve("Post", "editor", Each(func(context.Context, testPost, Args) (any, error) {
+		return (*testAuthor)(nil), nil
+	}))
+	s.Resolve("Author", "posts", Each(func(_ context.Context, a testAuthor, args Args) (any, error) {
+		var out []testPost
+		for _, p := range posts {
+			if p.AuthorID == a.ID && len(out) < args.Int("limit") {
+				out = append(out, p)
+			}
+		}
+		return out, nil
+	}))
+	return s, &authorCalls
+}
+
+func run(t *testing.T, s *Schema, query string, vars map[string]any) *Response {
+	t.Helper()
+	doc, errs := s.Parse(query)
+	if errs != nil {
+		return &Response{Errors: errs}
+	}
+	return s.Exec(context.Background(), doc, "", vars)
+}
+
+func TestExecBatchesFields(t *testing.T) {
+	s, authorCalls := newTestSchema(t)
+
+	resp := run(t, s, `{ posts { id title author { name posts { id } } } }`, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatal(resp.Errors)
+	}
+	want := `{"posts":[` +
+		`{"id":"p1","title":"one","author":{"name":"Ann","posts":[{"id":"p1"},{"id":"p3"}]}},` +
+		`{"id":"p2","title":"two","author":{"name":"Bob","posts":[{"id":"p2"}]}},` +
+		`{"id":"p3","title":"three","author":{"name":"Ann","posts":[{"id":"p1"},{"id":"p3"}]}}]}`
+	if string(resp.Data) != want {
+		t.Fatalf("got  %s\nwant %s", resp.Data, want)
+	}
+	if *authorCalls != 1 {
+		t.Fatalf("author resolved in %d calls, want 1 for the whole list", *authorCalls)
+	}
+}
+
+func TestExecFieldsAndFragments(t *testing.T) {
+	s, _ := newTestSchema(t)
+
+	query := `query($id: ID!, $skip: Boolean!) {
+		first: post(id: $id) { ...fields tags createdAt status }
+		second: post(id: "p2") { id ... on Post { title } __typename author @skip(if: $skip) { id } }
+		missing: post(id: "nope") { id }
+	}
+	fragment fields on Post { id title editor { id } }`
+	resp := run(t, s, query, map[string]any{"id": "p1", "skip": true})
+	if len(resp.Errors) > 0 {
+		t.Fatal(resp.Errors)
+	}
+	want := `{"first":{"id":"p1","title":"one","editor":null,"tags":["x"],"createdAt":"2026-01-02T03:04:05Z","status":"DRAFT"},` +
+		`"second":{"id":"p2","title":"two","__typename":"Post"},"missing":null}`
+	if string(resp.Data) != want {
+		t.Fatalf("got  %s\nwant %s", resp.Data, want)
+	}
+}
+
+func TestExecErrors(t *testing.T) {
+	s, _ := newTestSchema(t)
+
+	resp := run(t, s, `{ broken post(id: "p1") { id } }`, nil)
+	if string(resp.Data) != `{"broken":null,"post":{"id":"p1"}}` {
+		t.Fatalf("unexpected data %s", resp.Data)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Message != "internal error" || resp.Errors[0].Path.String() != "broken" {
+		t.Fatalf("internal errors must be reported without details, got %v", resp.Errors)
+	}
+
+	resp = run(t, s, `{ post(id: "p1") { nope } }`, nil)
+	if resp.Data != nil || len(resp.Errors) == 0 || resp.Errors[0].Extensions["code"] != CodeInvalidQuery {
+		t.Fatalf("expected a validation error without data, got %s %v", resp.Data, resp.Errors)
+	}
+
+	resp = run(t, s, `query($id: ID!) { post(id: $id) { id } }`, nil)
+	if resp.Data != nil || len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != CodeInvalidVariables {
+		t.Fatalf("expected a variables error, got %s %v", resp.Data, resp.Errors)
+	}
+}
+
+func TestExecNullPropagation(t *testing.T) {
+	s, _ := newTestSchema(t)
+	s.Resolve("Post", "title", Each(func(_ context.Context, p testPost, _ Args) (any, error) {
+		if p.ID == "p2" {
+			return nil, Errorf("gone", "title of %s is gone", p.ID)
+		}
+		return p.Title, nil
+	}))
+
+	// a null in a non-null list element nulls the list, which is non-null
+	// too, so the whole data turns null
+	resp := run(t, s, `{ posts { title } }`, nil)
+	if string(resp.Data) != "null" {
+		t.Fatalf("expected null data, got %s", resp.Data)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Message != "title of p2 is gone" || resp.Errors[0].Extensions["code"] != "gone" {
+		t.Fatalf("expected the resolver error once, got %v", resp.Errors)
+	}
+
+	// post is nullable, so only it turns null
+	resp = run(t, s, `{ post(id: "p2") { id title } }`, nil)
+	if string(resp.Data) != `{"post":null}` {
+		t.Fatalf("expected post to be null, got %s", resp.Data)
+	}
+}
+
+func TestExecLimits(t *testing.T) {
+	s, _ := newTestSchema(t)
+	s.MaxDepth = 3
+	s.MaxComplexity = 50
+
+	tests := []struct {
+		query string
+		code  string
+	}{
+		{`{ posts { author { posts { id } } } }`, CodeTooDeep},
+		{`{ posts(limit: 5) { author { name } } }`, ""},
+		{`{ posts(limit: 100) { id } }`, CodeTooComplex},
+		{`query($n: Int) { posts(limit: $n) { id title } }`, CodeTooComplex},
+		{`{ __schema { types { fields { type { ofType { ofType { name } } } } } } }`, ""},
+	}
+	for _, tt := range tests {
+		resp := run(t, s, tt.query, map[string]any{"n": 30})
+		code := ""
+		if len(resp.Errors) > 0 {
+			code, _ = resp.Errors[0].Extensions["code"].(string)
+		}
+		if code != tt.code {
+			t.Errorf("%s: got code %q, want %q (%v)", tt.query, code, tt.code, resp.Errors)
+		}
+	}
+}
+
+func TestIntrospection(t *testing.T) {
+	s, _ := newTestSchema(t)
+
+	resp := run(t, s, `{
+		__type(name: "Post") { kind name fields { name type { kind ofType { name kind } } } }
+		__schema { queryType { name } mutationType { name } }
+	}`, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatal(resp.Errors)
+	}
+
+	var data struct {
+		Type struct {
+			Kind   string
+			Name   string
+			Fields []struct {
+				Name string
+				Type struct {
+					Kind   string
+					OfType struct{ Name, Kind string }
+				}
+			}
+		} `json:"__type"`
+		Schema struct {
+			QueryType    struct{ Name string }
+			MutationType *struct{ Name string }
+		} `json:"__schema"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Type.Kind != "OBJECT" || data.Type.Name != "Post" || len(data.Type.Fields) != 7 {
+		t.Fatalf("unexpected Post type %+v", data.Type)
+	}
+	author := data.Type.Fields[4]
+	if author.Name != "author" || author.Type.Kind != "NON_NULL" || author.Type.OfType.Name != "Author" {
+		t.Fatalf("unexpected author field %+v", author)
+	}
+	if data.Schema.QueryType.Name != "Query" || data.Schema.MutationType != nil {
+		t.Fatalf("unexpected root types %+v", data.Schema)
+	}
+}
+
+func TestResolveUnknownFieldPanics(t *testing.T) {
+	s, _ := newTestSchema(t)
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "Post.nope") {
+			t.Fatalf("expected a panic naming the field, got %v", r)
+		}
+	}()
+	s.Resolve("Post", "nope", nil)
+}
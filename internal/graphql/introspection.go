@@ -0,0 +1,269 @@
+package graphql
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// typeRef is an introspected type: a named definition, or a LIST or
+// NON_NULL wrapper around of.
+type typeRef struct {
+	def  *ast.Definition
+	kind string
+	of   *ast.Type
+}
+
+// inputValue is an argument or an input object field.
+type inputValue struct {
+	name         string
+	description  string
+	typ          *ast.Type
+	defaultValue *ast.Value
+	directives   ast.DirectiveList
+}
+
+func (s *Schema) typeOf(t *ast.Type) typeRef {
+	switch {
+	case t.NonNull:
+		inner := *t
+		inner.NonNull = false
+		return typeRef{kind: "NON_NULL", of: &inner}
+	case t.Elem != nil:
+		return typeRef{kind: "LIST", of: t.Elem}
+	}
+	return s.named(s.ast.Types[t.NamedType])
+}
+
+func (s *Schema) named(def *ast.Definition) typeRef {
+	return typeRef{def: def, kind: string(def.Kind)}
+}
+
+// resolveIntrospection sets the resolvers of __schema, __type and the
+// introspection types.
+func (s *Schema) resolveIntrospection() {
+	s.Resolve("Query", "__schema", Each(func(context.Context, any, Args) (any, error) {
+		return s.ast, nil
+	}))
+	s.Resolve("Query", "__type", Each(func(_ context.Context, _ any, args Args) (any, error) {
+		def := s.ast.Types[args.String("name")]
+		if def == nil {
+			return nil, nil
+		}
+		return s.named(def), nil
+	}))
+
+	on(s, "__Schema", "description", func(sc *ast.Schema, _ Args) any { return optional(sc.Description) })
+	on(s, "__Schema", "types", func(sc *ast.Schema, _ Args) any {
+		names := make([]string, 0, len(sc.Types))
+		for name := range sc.Types {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		types := make([]typeRef, len(names))
+		for i, name := range names {
+			types[i] = s.named(sc.Types[name])
+		}
+		return types
+	})
+	on(s, "__Schema", "queryType", func(sc *ast.Schema, _ Args) any { return s.named(sc.Query) })
+	on(s, "__Schema", "mutationType", func(sc *ast.Schema, _ Args) any { return s.optionalType(sc.Mutation) })
+	on(s, "__Schema", "subscriptionType", func(sc *ast.Schema, _ Args) any { return s.optionalType(sc.Subscription) })
+	on(s, "__Schema", "directives", func(sc *ast.Schema, _ Args) any {
+		names := make([]string, 0, len(sc.Directives))
+		for name := range sc.Directives {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		directives := make([]*ast.DirectiveDefinition, len(names))
+		for i, name := range names {
+			directives[i] = sc.Directives[name]
+		}
+		return directives
+	})
+
+	on(s, "__Type", "kind", func(t typeRef, _ Args) any { return t.kind })
+	on(s, "__Type", "name", func(t typeRef, _ Args) any {
+		if t.def == nil {
+			return nil
+		}
+		return t.def.Name
+	})
+	on(s, "__Type", "description", func(t typeRef, _ Args) any {
+		if t.def == nil {
+			return nil
+		}
+		return optional(t.def.Description)
+	})
+	on(s, "__Type", "specifiedByURL", func(t typeRef, _ Args) any {
+		if t.def == nil {
+			return nil
+		}
+		if d := t.def.Directives.ForName("specifiedBy"); d != nil {
+			return d.ArgumentMap(nil)["url"]
+		}
+		return nil
+	})
+	on(s, "__Type", "fields", func(t typeRef, args Args) any {
+		if t.def == nil || (t.def.Kind != ast.Object && t.def.Kind != ast.Interface) {
+			return nil
+		}
+		var fields []*ast.FieldDefinition
+		for _, f := range t.def.Fields {
+			if strings.HasPrefix(f.Name, "__") || (deprecated(f.Directives) && !args.Bool("includeDeprecated")) {
+				continue
+			}
+			fields = append(fields, f)
+		}
+		return fields
+	})
+	on(s, "__Type", "interfaces", func(t typeRef, _ Args) any {
+		if t.def == nil || (t.def.Kind != ast.Object && t.def.Kind != ast.Interface) {
+			return nil
+		}
+		interfaces := make([]typeRef, len(t.def.Interfaces))
+		for i, name := range t.def.Interfaces {
+			interfaces[i] = s.named(s.ast.Types[name])
+		}
+		return interfaces
+	})
+	on(s, "__Type", "possibleTypes", func(t typeRef, _ Args) any {
+		if t.def == nil || !t.def.IsAbstractType() {
+			return nil
+		}
+		possible := s.ast.GetPossibleTypes(t.def)
+		types := make([]typeRef, len(possible))
+		for i, def := range possible {
+			types[i] = s.named(def)
+		}
+		return types
+	})
+	on(s, "__Type", "enumValues", func(t typeRef, args Args) any {
+		if t.def == nil || t.def.Kind != ast.Enum {
+			return nil
+		}
+		var values []*ast.EnumValueDefinition
+		for _, v := range t.def.EnumValues {
+			if deprecated(v.Directives) && !args.Bool("includeDeprecated") {
+				continue
+			}
+			values = append(values, v)
+		}
+		return values
+	})
+	on(s, "__Type", "inputFields", func(t typeRef, args Args) any {
+		if t.def == nil || t.def.Kind != ast.InputObject {
+			return nil
+		}
+		var fields []inputValue
+		for _, f := range t.def.Fields {
+			if deprecated(f.Directives) && !args.Bool("includeDeprecated") {
+				continue
+			}
+			fields = append(fields, inputValue{f.Name, f.Description, f.Type, f.DefaultValue, f.Directives})
+		}
+		return fields
+	})
+	on(s, "__Type", "ofType", func(t typeRef, _ Args) any {
+		if t.of == nil {
+			return nil
+		}
+		return s.typeOf(t.of)
+	})
+	on(s, "__Type", "isOneOf", func(t typeRef, _ Args) any {
+		if t.def == nil || t.def.Kind != ast.InputObject {
+			return nil
+		}
+		return t.def.Directives.ForName("oneOf") != nil
+	})
+
+	on(s, "__Field", "name", func(f *ast.FieldDefinition, _ Args) any { return f.Name })
+	on(s, "__Field", "description", func(f *ast.FieldDefinition, _ Args) any { return optional(f.Description) })
+	on(s, "__Field", "args", func(f *ast.FieldDefinition, args Args) any {
+		return arguments(f.Arguments, args.Bool("includeDeprecated"))
+	})
+	on(s, "__Field", "type", func(f *ast.FieldDefinition, _ Args) any { return s.typeOf(f.Type) })
+	on(s, "__Field", "isDeprecated", func(f *ast.FieldDefinition, _ Args) any { return deprecated(f.Directives) })
+	on(s, "__Field", "deprecationReason", func(f *ast.FieldDefinition, _ Args) any { return deprecationReason(f.Directives) })
+
+	on(s, "__InputValue", "name", func(v inputValue, _ Args) any { return v.name })
+	on(s, "__InputValue", "description", func(v inputValue, _ Args) any { return optional(v.description) })
+	on(s, "__InputValue", "type", func(v inputValue, _ Args) any { return s.typeOf(v.typ) })
+	on(s, "__InputValue", "defaultValue", func(v inputValue, _ Args) any {
+		if v.defaultValue == nil {
+			return nil
+		}
+		return v.defaultValue.String()
+	})
+	on(s, "__InputValue", "isDeprecated", func(v inputValue, _ Args) any { return deprecated(v.directives) })
+	on(s, "__InputValue", "deprecationReason", func(v inputValue, _ Args) any { return deprecationReason(v.directives) })
+
+	on(s, "__EnumValue", "name", func(v *ast.EnumValueDefinition, _ Args) any { return v.Name })
+	on(s, "__EnumValue", "description", func(v *ast.EnumValueDefinition, _ Args) any { return optional(v.Description) })
+	on(s, "__EnumValue", "isDeprecated", func(v *ast.EnumValueDefinition, _ Args) any { return deprecated(v.Directives) })
+	on(s, "__EnumValue", "deprecationReason", func(v *ast.EnumValueDefinition, _ Args) any { return deprecationReason(v.Directives) })
+
+	on(s, "__Directive", "name", func(d *ast.DirectiveDefinition, _ Args) any { return d.Name })
+	on(s, "__Directive", "description", func(d *ast.DirectiveDefinition, _ Args) any { return optional(d.Description) })
+	on(s, "__Directive", "isRepeatable", func(d *ast.DirectiveDefinition, _ Args) any { return d.IsRepeatable })
+	on(s, "__Directive", "locations", func(d *ast.DirectiveDefinition, _ Args) any {
+		locations := make([]string, len(d.Locations))
+		for i, l := range d.Locations {
+			locations[i] = string(l)
+		}
+		return locations
+	})
+	on(s, "__Directive", "args", func(d *ast.DirectiveDefinition, args Args) any {
+		return arguments(d.Arguments, args.Bool("includeDeprecated"))
+	})
+}
+
+// on sets a resolver that can't fail on a type whose parents are T.
+func on[T any](s *Schema, typeName, field string, f func(T, Args) any) {
+	s.Resolve(typeName, field, Each(func(_ context.Context, parent T, args Args) (any, error) {
+		return f(parent, args), nil
+	}))
+}
+
+func (s *Schema) optionalType(def *ast.Definition) any {
+	if def == nil {
+		return nil
+	}
+	return s.named(def)
+}
+
+func arguments(defs ast.ArgumentDefinitionList, includeDeprecated bool) []inputValue {
+	args := []inputValue{}
+	for _, a := range defs {
+		if deprecated(a.Directives) && !includeDeprecated {
+			continue
+		}
+		args = append(args, inputValue{a.Name, a.Description, a.Type, a.DefaultValue, a.Directives})
+	}
+	return args
+}
+
+func deprecated(directives ast.DirectiveList) bool {
+	return directives.ForName("deprecated") != nil
+}
+
+func deprecationReason(directives ast.DirectiveList) any {
+	d := directives.ForName("deprecated")
+	if d == nil {
+		return nil
+	}
+	if reason, ok := d.ArgumentMap(nil)["reason"].(string); ok {
+		return reason
+	}
+	return nil
+}
+
+// optional turns an empty description into null.
+func optional(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,79 @@
+package graphql
+
+import (
+	"math"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// depth returns how deeply sel nests fields. Validation has rejected
+// fragment cycles already.
+func depth(sel ast.SelectionSet) int {
+	deepest := 0
+	for _, s := range sel {
+		d := 0
+		switch s := s.(type) {
+		case *ast.Field:
+			if introspection(s) {
+				continue
+			}
+			d = 1 + depth(s.SelectionSet)
+		case *ast.InlineFragment:
+			d = depth(s.SelectionSet)
+		case *ast.FragmentSpread:
+			d = depth(s.Definition.SelectionSet)
+		}
+		deepest = max(deepest, d)
+	}
+	return deepest
+}
+
+// complexity is an upper bound on the number of fields resolving sel may
+// produce. @skip and @include are ignored, a skipped field still counts.
+func (s *Schema) complexity(sel ast.SelectionSet, vars map[string]any) int {
+	total := 0
+	for _, sel := range sel {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if introspection(sel) {
+				continue
+			}
+			children := s.complexity(sel.SelectionSet, vars)
+			if sel.Definition.Type.Elem != nil {
+				size := s.ListSize
+				if sel.Definition.Arguments.ForName("limit") != nil {
+					size = Args(sel.ArgumentMap(vars)).Int("limit")
+				}
+				children = mul(children, max(size, 1))
+			}
+			total = add(total, add(1, children))
+		case *ast.InlineFragment:
+			total = add(total, s.complexity(sel.SelectionSet, vars))
+		case *ast.FragmentSpread:
+			total = add(total, s.complexity(sel.Definition.SelectionSet, vars))
+		}
+	}
+	return total
+}
+
+// add and mul saturate instead of overflowing, for limit: 2147483647.
+func add(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+func mul(a, b int) int {
+	if a != 0 && b > math.MaxInt/a {
+		return math.MaxInt
+	}
+	return a * b
+}
+
+// introspection reports whether f is __schema or __type, which tools send
+// deeply nested and aren't counted against the limits. __typename is.
+func introspection(f *ast.Field) bool {
+	return f.Name != "__typename" && strings.HasPrefix(f.Name, "__")
+}
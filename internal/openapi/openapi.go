@@ -0,0 +1,237 @@
+// Package openapi loads an OpenAPI 3.1 description and validates requests
+// and responses against it. It understands the parts of the format the
+// Chirpy spec uses, not every corner of OpenAPI and JSON Schema.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Document is a parsed OpenAPI description.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Components struct {
+	Schemas    map[string]*Schema    `json:"schemas"`
+	Parameters map[string]*Parameter `json:"parameters"`
+	Responses  map[string]*Response  `json:"responses"`
+}
+
+type PathItem struct {
+	Get    *Operation `json:"get"`
+	Put    *Operation `json:"put"`
+	Post   *Operation `json:"post"`
+	Delete *Operation `json:"delete"`
+	Patch  *Operation `json:"patch"`
+}
+
+// Operations returns the operations of the path keyed by upper case method.
+func (p *PathItem) Operations() map[string]*Operation {
+	ops := make(map[string]*Operation)
+	for method, op := range map[string]*Operation{
+		"GET":    p.Get,
+		"PUT":    p.Put,
+		"POST":   p.Post,
+		"DELETE": p.Delete,
+		"PATCH":  p.Patch,
+	} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Tags        []string              `json:"tags"`
+	Security    []map[string][]string `json:"security"`
+	Parameters  []*Parameter          `json:"parameters"`
+	RequestBody *RequestBody          `json:"requestBody"`
+	Responses   map[string]*Response  `json:"responses"`
+}
+
+type Parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+	// MaxBytes overrides Validator.MaxBodyBytes (x-max-bytes).
+	MaxBytes int64 `json:"x-max-bytes"`
+}
+
+type Response struct {
+	Ref         string                `json:"$ref"`
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Load parses a YAML (or JSON) description and resolves its parameter and
+// response references. Schema references are checked here and followed
+// during validation, so recursive schemas work.
+func Load(data []byte) (*Document, error) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	// yaml.v2 decodes maps with interface{} keys, go through JSON to get
+	// the typed document
+	b, err := json.Marshal(jsonCompatible(raw))
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	doc := &Document{}
+	if err := json.Unmarshal(b, doc); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.1") {
+		return nil, fmt.Errorf("openapi: unsupported version %q, want 3.1", doc.OpenAPI)
+	}
+	if err := doc.resolve(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func jsonCompatible(v any) any {
+	switch v := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, x := range v {
+			m[fmt.Sprint(k)] = jsonCompatible(x)
+		}
+		return m
+	case []any:
+		for i, x := range v {
+			v[i] = jsonCompatible(x)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func (d *Document) resolve() error {
+	for path, item := range d.Paths {
+		for method, op := range item.Operations() {
+			where := method + " " + path
+			for i, p := range op.Parameters {
+				if p.Ref == "" {
+					continue
+				}
+				name, ok := strings.CutPrefix(p.Ref, "#/components/parameters/")
+				resolved := d.Components.Parameters[name]
+				if !ok || resolved == nil {
+					return fmt.Errorf("openapi: %s: unresolved reference %s", where, p.Ref)
+				}
+				op.Parameters[i] = resolved
+			}
+			for status, resp := range op.Responses {
+				if resp.Ref == "" {
+					continue
+				}
+				name, ok := strings.CutPrefix(resp.Ref, "#/components/responses/")
+				resolved := d.Components.Responses[name]
+				if !ok || resolved == nil {
+					return fmt.Errorf("openapi: %s: unresolved reference %s", where, resp.Ref)
+				}
+				op.Responses[status] = resolved
+			}
+		}
+	}
+
+	var err error
+	d.walkSchemas(func(where string, s *Schema) {
+		if err != nil || s.Ref == "" {
+			return
+		}
+		if _, lookupErr := d.schema(s.Ref); lookupErr != nil {
+			err = fmt.Errorf("openapi: %s: %w", where, lookupErr)
+		}
+	})
+	return err
+}
+
+// walkSchemas calls fn for every schema in the document, nested ones
+// included.
+func (d *Document) walkSchemas(fn func(where string, s *Schema)) {
+	var walk func(where string, s *Schema)
+	walk = func(where string, s *Schema) {
+		if s == nil {
+			return
+		}
+		fn(where, s)
+		for name, p := range s.Properties {
+			walk(where+"."+name, p)
+		}
+		walk(where+"[]", s.Items)
+		walk(where+"{}", s.AdditionalProperties)
+		walk(where, s.PropertyNames)
+		for _, sub := range s.AllOf {
+			walk(where, sub)
+		}
+	}
+
+	for name, s := range d.Components.Schemas {
+		walk("#/components/schemas/"+name, s)
+	}
+	for name, p := range d.Components.Parameters {
+		walk("#/components/parameters/"+name, p.Schema)
+	}
+	for path, item := range d.Paths {
+		for method, op := range item.Operations() {
+			where := method + " " + path
+			for _, p := range op.Parameters {
+				walk(where+" "+p.Name, p.Schema)
+			}
+			if op.RequestBody != nil {
+				for ct, mt := range op.RequestBody.Content {
+					walk(where+" request "+ct, mt.Schema)
+				}
+			}
+			for status, resp := range op.Responses {
+				for ct, mt := range resp.Content {
+					walk(where+" "+status+" "+ct, mt.Schema)
+				}
+			}
+		}
+	}
+}
+
+func (d *Document) schema(ref string) (*Schema, error) {
+	name, ok := strings.CutPrefix(ref, "#/components/schemas/")
+	if s := d.Components.Schemas[name]; ok && s != nil {
+		return s, nil
+	}
+	return nil, fmt.Errorf("unresolved reference %s", ref)
+}
+
+// Operation returns the operation for method and path template, as in the
+// ServeMux pattern "GET /api/chirps/{chirpID}", or nil.
+func (d *Document) Operation(method, path string) *Operation {
+	item := d.Paths[path]
+	if item == nil {
+		return nil
+	}
+	return item.Operations()[strings.ToUpper(method)]
+}
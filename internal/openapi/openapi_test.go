@@ -0,0 +1,325 @@
+package openapi
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const testSpec = `
+openapi: 3.1.0
+info:
+  title: test
+  version: '1'
+paths:
+  /things:
+    get:
+      operationId: listThings
+      parameters:
+        - $ref: '#/components/parameters/limit'
+        - name: sort
+          in: query
+          schema:
+            type: string
+            enum: [asc, desc]
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Thing'
+        default:
+          $ref: '#/components/responses/Problem'
+    post:
+      operationId: createThing
+      requestBody:
+        required: true
+        x-max-bytes: 64
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/NewThing'
+      responses:
+        '204':
+          description: No Content
+  /things/{thingID}:
+    get:
+      operationId: getThing
+      parameters:
+        - name: thingID
+          in: path
+          required: true
+          schema:
+            type: string
+            format: uuid
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Thing'
+components:
+  parameters:
+    limit:
+      name: limit
+      in: query
+      schema:
+        type: integer
+        minimum: 1
+        maximum: 100
+  responses:
+    Problem:
+      description: Error
+      content:
+        application/problem+json:
+          schema:
+            type: object
+            required: [code]
+            properties:
+              code:
+                type: string
+  schemas:
+    Thing:
+      type: object
+      required: [id, name]
+      properties:
+        id:
+          type: string
+          format: uuid
+        name:
+          type: string
+        parent:
+          $ref: '#/components/schemas/Thing'
+        deleted_at:
+          type: [string, 'null']
+          format: date-time
+    NewThing:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+          minLength: 1
+          maxLength: 10
+        tags:
+          type: array
+          maxItems: 2
+          items:
+            type: string
+`
+
+func testValidator(t *testing.T) *Validator {
+	t.Helper()
+	doc, err := Load([]byte(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewValidator(doc)
+}
+
+func fieldErrors(t *testing.T, err error) []FieldError {
+	t.Helper()
+	var specErr *Error
+	if !errors.As(err, &specErr) {
+		t.Fatalf("expected *Error, got %v", err)
+	}
+	if specErr.Kind != KindInvalid {
+		t.Fatalf("expected KindInvalid, got %v", specErr.Kind)
+	}
+	return specErr.Fields
+}
+
+func TestValidateRequestParams(t *testing.T) {
+	v := testValidator(t)
+
+	r := httptest.NewRequest("GET", "/things?limit=20&sort=desc", nil)
+	if err := v.Route("GET", "/things").ValidateRequest(r); err != nil {
+		t.Fatalf("valid request rejected: %v", err)
+	}
+
+	r = httptest.NewRequest("GET", "/things?limit=500&sort=up", nil)
+	fields := fieldErrors(t, v.Route("GET", "/things").ValidateRequest(r))
+	if len(fields) != 2 {
+		t.Fatalf("expected 2 errors, got %+v", fields)
+	}
+	if fields[0].Field != "limit" || fields[0].Code != CodeOutOfRange || fields[0].Message != "must be between 1 and 100" {
+		t.Fatalf("unexpected limit error %+v", fields[0])
+	}
+	if fields[1].Field != "sort" || fields[1].Code != CodeInvalid {
+		t.Fatalf("unexpected sort error %+v", fields[1])
+	}
+
+	r = httptest.NewRequest("GET", "/things?limit=ten", nil)
+	fields = fieldErrors(t, v.Route("GET", "/things").ValidateRequest(r))
+	if fields[0].Message != "must be an integer" {
+		t.Fatalf("unexpected error %+v", fields[0])
+	}
+
+	r = httptest.NewRequest("GET", "/things/not-a-uuid", nil)
+	fields = fieldErrors(t, v.Route("GET", "/things/{thingID}").ValidateRequest(r))
+	if fields[0].In != "path" || fields[0].Field != "thingID" {
+		t.Fatalf("unexpected error %+v", fields[0])
+	}
+}
+
+func TestValidateRequestBody(t *testing.T) {
+	v := testValidator(t)
+	route := v.Route("POST", "/things")
+
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		kind        ErrorKind
+		fields      []string
+	}{
+		{name: "valid", contentType: "application/json", body: `{"name":"box","tags":["a"]}`},
+		{name: "no content type", body: `{"name":"box"}`},
+		{name: "missing name", contentType: "application/json", body: `{}`, kind: KindInvalid, fields: []string{"name"}},
+		{name: "long name and tags", contentType: "application/json", body: `{"name":"a very long name","tags":["a","b","c"]}`, kind: KindInvalid, fields: []string{"name", "tags"}},
+		{name: "wrong type", contentType: "application/json", body: `{"name":1}`, kind: KindInvalid, fields: []string{"name"}},
+		{name: "not an object", contentType: "application/json", body: `[]`, kind: KindInvalid, fields: []string{"$"}},
+		{name: "empty", contentType: "application/json", body: ``, kind: KindInvalidJSON},
+		{name: "broken", contentType: "application/json", body: `{"name":`, kind: KindInvalidJSON},
+		{name: "trailing data", contentType: "application/json", body: `{"name":"box"} {}`, kind: KindInvalidJSON},
+		{name: "form", contentType: "application/x-www-form-urlencoded", body: `name=box`, kind: KindUnsupportedMediaType},
+		{name: "too large", contentType: "application/json", body: `{"name":"` + strings.Repeat("x", 100) + `"}`, kind: KindTooLarge},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/things", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				r.Header.Set("Content-Type", tc.contentType)
+			}
+			err := route.ValidateRequest(r)
+
+			if tc.kind == KindInvalid && tc.fields == nil {
+				if err != nil {
+					t.Fatalf("valid body rejected: %v", err)
+				}
+				// handlers still get the body
+				b, _ := io.ReadAll(r.Body)
+				if string(b) != tc.body {
+					t.Fatalf("body not restored, got %q", b)
+				}
+				return
+			}
+
+			var specErr *Error
+			if !errors.As(err, &specErr) || specErr.Kind != tc.kind {
+				t.Fatalf("expected kind %v, got %v", tc.kind, err)
+			}
+			var got []string
+			for _, f := range specErr.Fields {
+				got = append(got, f.Field)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.fields, ",") {
+				t.Fatalf("expected fields %v, got %v", tc.fields, got)
+			}
+		})
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	v := testValidator(t)
+	list := v.Route("GET", "/things")
+	jsonHeader := http.Header{"Content-Type": {"application/json"}}
+
+	body := `[{"id":"2f1b3c4d-5e6f-4a1b-8c9d-0e1f2a3b4c5d","name":"box","deleted_at":null,
+		"parent":{"id":"2f1b3c4d-5e6f-4a1b-8c9d-0e1f2a3b4c5e","name":"shelf"}}]`
+	if err := list.ValidateResponse(200, jsonHeader, []byte(body)); err != nil {
+		t.Fatalf("valid response rejected: %v", err)
+	}
+
+	// the recursive parent is checked too
+	body = `[{"id":"2f1b3c4d-5e6f-4a1b-8c9d-0e1f2a3b4c5d","name":"box","parent":{"name":"shelf"}}]`
+	fields := fieldErrors(t, list.ValidateResponse(200, jsonHeader, []byte(body)))
+	if len(fields) != 1 || fields[0].Field != "$[0].parent.id" {
+		t.Fatalf("unexpected errors %+v", fields)
+	}
+
+	problem := http.Header{"Content-Type": {"application/problem+json"}}
+	if err := list.ValidateResponse(400, problem, []byte(`{"code":"bad_request"}`)); err != nil {
+		t.Fatalf("problem rejected: %v", err)
+	}
+	if err := list.ValidateResponse(400, jsonHeader, []byte(`{"error":"nope"}`)); err == nil {
+		t.Fatal("expected an undocumented content type to be rejected")
+	}
+
+	get := v.Route("GET", "/things/{thingID}")
+	if err := get.ValidateResponse(404, problem, []byte(`{"code":"not_found"}`)); err == nil {
+		t.Fatal("expected an undocumented status to be rejected")
+	}
+
+	create := v.Route("POST", "/things")
+	if err := create.ValidateResponse(204, http.Header{}, nil); err != nil {
+		t.Fatalf("empty 204 rejected: %v", err)
+	}
+	if err := create.ValidateResponse(204, jsonHeader, []byte(`null`)); err == nil {
+		t.Fatal("expected a 204 with a body to be rejected")
+	}
+}
+
+func TestLoadRejectsBrokenReferences(t *testing.T) {
+	spec := strings.Replace(testSpec, "'#/components/schemas/NewThing'", "'#/components/schemas/Missing'", 1)
+	if _, err := Load([]byte(spec)); err == nil {
+		t.Fatal("expected an unresolved reference to fail")
+	}
+}
+
+// TestSpecCoversRoutes keeps openapi.yaml and the routes registered in
+// main.go in sync.
+func TestSpecCoversRoutes(t *testing.T) {
+	data, err := os.ReadFile("../../openapi.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := Load(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mainGo, err := os.ReadFile("../../main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registered := make(map[string]bool)
+	for _, m := range regexp.MustCompile(`mux\.HandleFunc\("([A-Z]+) ([^"]+)"`).FindAllStringSubmatch(string(mainGo), -1) {
+		registered[m[1]+" "+m[2]] = true
+		if doc.Operation(m[1], m[2]) == nil {
+			t.Errorf("%s %s is registered but not in openapi.yaml", m[1], m[2])
+		}
+	}
+	if len(registered) == 0 {
+		t.Fatal("found no routes in main.go")
+	}
+
+	ids := make(map[string]string)
+	for path, item := range doc.Paths {
+		for method, op := range item.Operations() {
+			route := method + " " + path
+			if !registered[route] {
+				t.Errorf("%s is in openapi.yaml but not registered", route)
+			}
+			if op.OperationID == "" {
+				t.Errorf("%s has no operationId", route)
+			} else if other, ok := ids[op.OperationID]; ok {
+				t.Errorf("%s and %s share operationId %s", route, other, op.OperationID)
+			}
+			ids[op.OperationID] = route
+			if _, ok := op.Responses["default"]; !ok {
+				t.Errorf("%s doesn't document its error responses", route)
+			}
+		}
+	}
+}
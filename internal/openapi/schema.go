@@ -0,0 +1,328 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Schema is the subset of JSON Schema 2020-12 the spec uses.
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Description          string             `json:"description"`
+	Type                 Types              `json:"type"`
+	Format               string             `json:"format"`
+	Enum                 []any              `json:"enum"`
+	Default              any                `json:"default"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *Schema            `json:"additionalProperties"`
+	PropertyNames        *Schema            `json:"propertyNames"`
+	Items                *Schema            `json:"items"`
+	AllOf                []*Schema          `json:"allOf"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MinItems             *int               `json:"minItems"`
+	MaxItems             *int               `json:"maxItems"`
+
+	// never is the false schema, nothing validates against it
+	never bool
+}
+
+// UnmarshalJSON also accepts the boolean schemas true and false.
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	switch string(bytes.TrimSpace(b)) {
+	case "true":
+		*s = Schema{}
+		return nil
+	case "false":
+		*s = Schema{never: true}
+		return nil
+	}
+	type plain Schema
+	return json.Unmarshal(b, (*plain)(s))
+}
+
+// Types is the type keyword, a single type or a list such as
+// ["string", "null"].
+type Types []string
+
+func (t *Types) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*t = Types{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+// Field level error codes, the same ones the API uses in problem details.
+const (
+	CodeRequired   = "required"
+	CodeInvalid    = "invalid"
+	CodeTooShort   = "too_short"
+	CodeTooLong    = "too_long"
+	CodeOutOfRange = "out_of_range"
+)
+
+// FieldError is one validation failure. Field is the parameter name or the
+// path into the JSON body, e.g. poll.options[1]; the body itself is "$".
+type FieldError struct {
+	In      string
+	Field   string
+	Code    string
+	Message string
+}
+
+const rootField = "$"
+
+func joinField(parent, name string) string {
+	if parent == rootField {
+		return name
+	}
+	return parent + "." + name
+}
+
+type validation struct {
+	doc    *Document
+	in     string
+	errors []FieldError
+}
+
+func (v *validation) fail(field, code, msg string) {
+	v.errors = append(v.errors, FieldError{In: v.in, Field: field, Code: code, Message: msg})
+}
+
+// validate checks value, as decoded by encoding/json with UseNumber,
+// against s.
+func (v *validation) validate(s *Schema, value any, field string) {
+	if s == nil {
+		return
+	}
+	if s.never {
+		v.fail(field, CodeInvalid, "is not allowed")
+		return
+	}
+	if s.Ref != "" {
+		resolved, err := v.doc.schema(s.Ref)
+		if err != nil {
+			v.fail(field, CodeInvalid, err.Error())
+			return
+		}
+		v.validate(resolved, value, field)
+	}
+	for _, sub := range s.AllOf {
+		v.validate(sub, value, field)
+	}
+
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return hasType(value, t) }) {
+		v.fail(field, CodeInvalid, "must be "+describeTypes(s.Type))
+		return
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return sameJSON(e, value) }) {
+		v.fail(field, CodeInvalid, "must be one of "+describeEnum(s.Enum))
+		return
+	}
+
+	switch value := value.(type) {
+	case string:
+		v.validateString(s, value, field)
+	case json.Number:
+		v.validateNumber(s, value, field)
+	case []any:
+		v.validateArray(s, value, field)
+	case map[string]any:
+		v.validateObject(s, value, field)
+	}
+}
+
+func (v *validation) validateString(s *Schema, value, field string) {
+	n := utf8.RuneCountInString(value)
+	if s.MinLength != nil && n < *s.MinLength {
+		if *s.MinLength == 1 {
+			v.fail(field, CodeRequired, "must not be empty")
+		} else {
+			v.fail(field, CodeTooShort, fmt.Sprintf("must be at least %d characters", *s.MinLength))
+		}
+		return
+	}
+	if s.MaxLength != nil && n > *s.MaxLength {
+		v.fail(field, CodeTooLong, fmt.Sprintf("must be at most %d characters", *s.MaxLength))
+		return
+	}
+
+	if msg := checkFormat(s.Format, value); msg != "" {
+		v.fail(field, CodeInvalid, msg)
+	}
+}
+
+func checkFormat(format, value string) string {
+	switch format {
+	case "uuid":
+		if _, err := uuid.Parse(value); err != nil {
+			return "must be a UUID"
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return "must be an RFC 3339 time"
+		}
+	case "email":
+		if addr, err := mail.ParseAddress(value); err != nil || addr.Address != value {
+			return "must be an email address"
+		}
+	case "uri":
+		if u, err := url.Parse(value); err != nil || !u.IsAbs() {
+			return "must be an absolute URL"
+		}
+	}
+	return ""
+}
+
+func (v *validation) validateNumber(s *Schema, value json.Number, field string) {
+	f, err := value.Float64()
+	if err != nil {
+		v.fail(field, CodeInvalid, "must be a number")
+		return
+	}
+	tooSmall := s.Minimum != nil && f < *s.Minimum
+	tooLarge := s.Maximum != nil && f > *s.Maximum
+	if !tooSmall && !tooLarge {
+		return
+	}
+
+	switch {
+	case s.Minimum != nil && s.Maximum != nil:
+		v.fail(field, CodeOutOfRange, fmt.Sprintf("must be between %s and %s", formatFloat(*s.Minimum), formatFloat(*s.Maximum)))
+	case tooSmall:
+		v.fail(field, CodeOutOfRange, "must be at least "+formatFloat(*s.Minimum))
+	default:
+		v.fail(field, CodeOutOfRange, "must be at most "+formatFloat(*s.Maximum))
+	}
+}
+
+func (v *validation) validateArray(s *Schema, value []any, field string) {
+	if s.MinItems != nil && len(value) < *s.MinItems {
+		v.fail(field, CodeTooShort, fmt.Sprintf("must have at least %d items", *s.MinItems))
+		return
+	}
+	if s.MaxItems != nil && len(value) > *s.MaxItems {
+		v.fail(field, CodeTooLong, fmt.Sprintf("must have at most %d items", *s.MaxItems))
+		return
+	}
+	for i, item := range value {
+		v.validate(s.Items, item, fmt.Sprintf("%s[%d]", field, i))
+	}
+}
+
+func (v *validation) validateObject(s *Schema, value map[string]any, field string) {
+	for _, name := range s.Required {
+		if _, ok := value[name]; !ok {
+			v.fail(joinField(field, name), CodeRequired, "is required")
+		}
+	}
+
+	// sorted so errors come out in a stable order
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		child := joinField(field, name)
+		if s.PropertyNames != nil {
+			keys := validation{doc: v.doc, in: v.in}
+			keys.validate(s.PropertyNames, name, child)
+			if len(keys.errors) > 0 {
+				v.fail(child, CodeInvalid, "is not allowed")
+				continue
+			}
+		}
+		if prop, ok := s.Properties[name]; ok {
+			v.validate(prop, value[name], child)
+		} else if s.AdditionalProperties != nil {
+			v.validate(s.AdditionalProperties, value[name], child)
+		}
+	}
+}
+
+func hasType(value any, t string) bool {
+	switch value := value.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case json.Number:
+		if t == "number" {
+			return true
+		}
+		if t != "integer" {
+			return false
+		}
+		if _, err := value.Int64(); err == nil {
+			return true
+		}
+		f, err := value.Float64()
+		return err == nil && f == float64(int64(f))
+	case []any:
+		return t == "array"
+	case map[string]any:
+		return t == "object"
+	}
+	return false
+}
+
+var typeNames = map[string]string{
+	"string":  "a string",
+	"integer": "an integer",
+	"number":  "a number",
+	"boolean": "a boolean",
+	"array":   "an array",
+	"object":  "an object",
+	"null":    "null",
+}
+
+func describeTypes(types Types) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = typeNames[t]
+	}
+	return strings.Join(names, " or ")
+}
+
+func describeEnum(enum []any) string {
+	values := make([]string, len(enum))
+	for i, e := range enum {
+		values[i] = fmt.Sprint(e)
+	}
+	return strings.Join(values, ", ")
+}
+
+func sameJSON(a, b any) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
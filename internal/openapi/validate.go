@@ -0,0 +1,303 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxBodyBytes caps request bodies whose spec doesn't set
+// x-max-bytes.
+const DefaultMaxBodyBytes = 64 << 10
+
+// ErrorKind says what was wrong with a request.
+type ErrorKind int
+
+const (
+	// KindInvalid means parameters or the body failed validation, see
+	// Error.Fields.
+	KindInvalid ErrorKind = iota
+	// KindInvalidJSON means the body isn't JSON, or is missing.
+	KindInvalidJSON
+	KindUnsupportedMediaType
+	KindTooLarge
+)
+
+// Error is returned by ValidateRequest and ValidateResponse.
+type Error struct {
+	Kind   ErrorKind
+	Fields []FieldError
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return "openapi: " + e.Err.Error()
+	}
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = fmt.Sprintf("%s %s %s", f.In, f.Field, f.Message)
+	}
+	return "openapi: " + strings.Join(msgs, "; ")
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Route is an operation together with its path template.
+type Route struct {
+	Method    string
+	Path      string
+	Operation *Operation
+
+	doc          *Document
+	maxBodyBytes int64
+}
+
+// Validator looks up routes in a Document.
+type Validator struct {
+	doc *Document
+	// MaxBodyBytes caps request bodies without an x-max-bytes of their own.
+	MaxBodyBytes int64
+}
+
+func NewValidator(doc *Document) *Validator {
+	return &Validator{doc: doc, MaxBodyBytes: DefaultMaxBodyBytes}
+}
+
+// Route returns the route for method and path template, nil when the spec
+// doesn't describe it.
+func (v *Validator) Route(method, path string) *Route {
+	op := v.doc.Operation(method, path)
+	if op == nil {
+		return nil
+	}
+	return &Route{
+		Method:       strings.ToUpper(method),
+		Path:         path,
+		Operation:    op,
+		doc:          v.doc,
+		maxBodyBytes: v.MaxBodyBytes,
+	}
+}
+
+// Streams reports whether the route upgrades the connection or streams
+// events; those responses can't be buffered for ValidateResponse.
+func (rt *Route) Streams() bool {
+	for status, resp := range rt.Operation.Responses {
+		if status == "101" {
+			return true
+		}
+		if _, ok := resp.Content["text/event-stream"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRequest checks the path, query and header parameters and the
+// body of r. The body is read and replaced, so handlers can still decode
+// it. Unknown query parameters and headers are allowed.
+func (rt *Route) ValidateRequest(r *http.Request) error {
+	v := &validation{doc: rt.doc}
+
+	pathValues := matchPath(rt.Path, r.URL.Path)
+	query := r.URL.Query()
+	for _, p := range rt.Operation.Parameters {
+		var raw string
+		var present bool
+		switch p.In {
+		case "path":
+			raw, present = pathValues[p.Name]
+		case "query":
+			if values, ok := query[p.Name]; ok && len(values) > 0 {
+				raw, present = values[0], true
+			}
+		case "header":
+			if values := r.Header.Values(p.Name); len(values) > 0 {
+				raw, present = values[0], true
+			}
+		default:
+			continue
+		}
+
+		v.in = p.In
+		if !present {
+			if p.Required {
+				v.fail(p.Name, CodeRequired, "is required")
+			}
+			continue
+		}
+		v.validate(p.Schema, coerceParam(p.Schema, raw), p.Name)
+	}
+
+	if rt.Operation.RequestBody != nil {
+		if err := rt.validateBody(r, v); err != nil {
+			return err
+		}
+	}
+
+	if len(v.errors) > 0 {
+		return &Error{Kind: KindInvalid, Fields: v.errors}
+	}
+	return nil
+}
+
+func (rt *Route) validateBody(r *http.Request, v *validation) error {
+	rb := rt.Operation.RequestBody
+	limit := rb.MaxBytes
+	if limit <= 0 {
+		limit = rt.maxBodyBytes
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return &Error{Kind: KindInvalidJSON, Err: err}
+	}
+	if int64(len(body)) > limit {
+		return &Error{Kind: KindTooLarge, Err: fmt.Errorf("request body is larger than %d bytes", limit)}
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(body) == 0 {
+		if rb.Required {
+			return &Error{Kind: KindInvalidJSON, Err: errors.New("request body is required")}
+		}
+		return nil
+	}
+
+	// a missing Content-Type is taken to be JSON, plenty of clients skip it
+	mediaType := "application/json"
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err = mime.ParseMediaType(ct)
+		if err != nil {
+			return &Error{Kind: KindUnsupportedMediaType, Err: err}
+		}
+	}
+	mt, ok := rb.Content[mediaType]
+	if !ok {
+		return &Error{Kind: KindUnsupportedMediaType, Err: fmt.Errorf("content type %s is not supported", mediaType)}
+	}
+	if !isJSON(mediaType) {
+		return nil
+	}
+
+	value, err := decodeJSON(body)
+	if err != nil {
+		return &Error{Kind: KindInvalidJSON, Err: err}
+	}
+	v.in = "body"
+	v.validate(mt.Schema, value, rootField)
+	return nil
+}
+
+// ValidateResponse checks that status is documented for the route and
+// that the body matches the schema for its content type. It is meant for
+// tests and development, not the hot path.
+func (rt *Route) ValidateResponse(status int, header http.Header, body []byte) error {
+	resp, ok := rt.Operation.Responses[strconv.Itoa(status)]
+	if !ok {
+		resp, ok = rt.Operation.Responses["default"]
+	}
+	if !ok {
+		return &Error{Kind: KindInvalid, Err: fmt.Errorf("status %d is not documented", status)}
+	}
+
+	if len(resp.Content) == 0 {
+		if len(body) > 0 {
+			return &Error{Kind: KindInvalid, Err: fmt.Errorf("status %d must not have a body", status)}
+		}
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return &Error{Kind: KindUnsupportedMediaType, Err: fmt.Errorf("status %d: bad Content-Type: %w", status, err)}
+	}
+	mt, ok := resp.Content[mediaType]
+	if !ok {
+		return &Error{Kind: KindUnsupportedMediaType, Err: fmt.Errorf("status %d: content type %s is not documented", status, mediaType)}
+	}
+	if !isJSON(mediaType) {
+		return nil
+	}
+
+	value, err := decodeJSON(body)
+	if err != nil {
+		return &Error{Kind: KindInvalidJSON, Err: fmt.Errorf("status %d: %w", status, err)}
+	}
+	v := &validation{doc: rt.doc, in: "body"}
+	v.validate(mt.Schema, value, rootField)
+	if len(v.errors) > 0 {
+		return &Error{Kind: KindInvalid, Fields: v.errors}
+	}
+	return nil
+}
+
+// matchPath returns the values of the {name} segments of template in
+// path. The router has already matched the two, so segments line up.
+func matchPath(template, path string) map[string]string {
+	values := make(map[string]string)
+	tsegs := strings.Split(strings.Trim(template, "/"), "/")
+	psegs := strings.Split(strings.Trim(path, "/"), "/")
+	for i, seg := range tsegs {
+		if i >= len(psegs) {
+			break
+		}
+		if name, ok := strings.CutPrefix(seg, "{"); ok {
+			name = strings.TrimSuffix(name, "}")
+			value, err := url.PathUnescape(psegs[i])
+			if err != nil {
+				value = psegs[i]
+			}
+			values[name] = value
+		}
+	}
+	return values
+}
+
+// coerceParam turns a raw parameter into the JSON value its schema
+// expects. Values that don't parse stay strings and fail the type check.
+func coerceParam(s *Schema, raw string) any {
+	if s == nil {
+		return raw
+	}
+	for _, t := range s.Type {
+		switch t {
+		case "integer", "number":
+			if _, err := strconv.ParseFloat(raw, 64); err == nil {
+				return json.Number(raw)
+			}
+		case "boolean":
+			if raw == "true" || raw == "false" {
+				return raw == "true"
+			}
+		}
+	}
+	return raw
+}
+
+func decodeJSON(body []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return value, nil
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,150 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventChirpCreated = "chirp.created"
+	EventChirpDeleted = "chirp.deleted"
+	EventUserOnline   = "presence.online"
+	EventUserOffline  = "presence.offline"
+	EventNotification = "notification"
+)
+
+// Event is a single message fanned out to subscribers. ID is assigned once
+// when the event is published: by the Postgres NOTIFY adapter from a
+// sequence every instance shares, or by the Broker for events published to
+// it directly. Resuming with it works against any instance that saw the
+// event.
+//
+// AuthorID is the user that caused the event, ChirpID the chirp it concerns
+// (if any) and ThreadID the first chirp of its thread when it is a reply.
+// RecipientID is set for events addressed to a single user.
+type Event struct {
+	ID          uint64          `json:"id"`
+	Type        string          `json:"type"`
+	AuthorID    uuid.UUID       `json:"author_id"`
+	ChirpID     uuid.UUID       `json:"chirp_id"`
+	ThreadID    uuid.UUID       `json:"thread_id"`
+	RecipientID uuid.UUID       `json:"recipient_id"`
+	Data        json.RawMessage `json:"data,omitempty"`
+}
+
+// Publisher is implemented by anything the handlers can hand an event to:
+// the in-process Broker or the Postgres NOTIFY adapter.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+type Subscription struct {
+	C <-chan Event
+
+	c      chan Event
+	filter func(Event) bool
+	broker *Broker
+	once   sync.Once
+}
+
+// Close detaches the subscription from the broker. It is safe to call more
+// than once.
+func (s *Subscription) Close() {
+	s.broker.remove(s)
+}
+
+type Broker struct {
+	mu      sync.Mutex
+	nextID  uint64
+	replay  []Event
+	maxSize int
+	subs    map[*Subscription]struct{}
+}
+
+func NewBroker(replaySize int) *Broker {
+	return &Broker{
+		maxSize: replaySize,
+		subs:    make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish assigns the next event ID unless the event already has one, stores
+// the event in the replay buffer and delivers it to every matching
+// subscriber. Subscribers that can't keep
+// up are dropped; they are expected to reconnect with Last-Event-ID.
+func (b *Broker) Publish(ctx context.Context, e Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if e.ID == 0 {
+		b.nextID++
+		e.ID = b.nextID
+	}
+	// local IDs continue after the shared ones
+	b.nextID = max(b.nextID, e.ID)
+
+	b.replay = append(b.replay, e)
+	if len(b.replay) > b.maxSize {
+		b.replay = b.replay[len(b.replay)-b.maxSize:]
+	}
+
+	for s := range b.subs {
+		if s.filter != nil && !s.filter(e) {
+			continue
+		}
+		select {
+		case s.c <- e:
+		default:
+			b.closeLocked(s)
+		}
+	}
+	return nil
+}
+
+// Subscribe registers a new subscriber. Buffered events with an ID greater
+// than lastID that pass the filter are returned so the caller can send them
+// before reading from the subscription channel.
+func (b *Broker) Subscribe(lastID uint64, filter func(Event) bool) (*Subscription, []Event) {
+	c := make(chan Event, 64)
+	s := &Subscription{
+		C:      c,
+		c:      c,
+		filter: filter,
+		broker: b,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var missed []Event
+	if lastID > 0 {
+		for _, e := range b.replay {
+			if e.ID <= lastID {
+				continue
+			}
+			if filter != nil && !filter(e) {
+				continue
+			}
+			missed = append(missed, e)
+		}
+	}
+
+	b.subs[s] = struct{}{}
+	return s, missed
+}
+
+func (b *Broker) remove(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closeLocked(s)
+}
+
+func (b *Broker) closeLocked(s *Subscription) {
+	delete(b.subs, s)
+	s.once.Do(func() {
+		close(s.c)
+	})
+}
@@ -0,0 +1,74 @@
+package pubsub
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PostgresNotifier publishes events through LISTEN/NOTIFY so every server
+// instance connected to the same database sees them. Each instance runs
+// Listen to feed the notifications into its local Broker. Event IDs come
+// from the event_ids sequence, so they are the same on every instance.
+type PostgresNotifier struct {
+	db      *sql.DB
+	channel string
+}
+
+func NewPostgresNotifier(db *sql.DB, channel string) *PostgresNotifier {
+	return &PostgresNotifier{
+		db:      db,
+		channel: channel,
+	}
+}
+
+func (n *PostgresNotifier) Publish(ctx context.Context, e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = n.db.ExecContext(ctx,
+		"SELECT pg_notify($1, jsonb_set($2::jsonb, '{id}', to_jsonb(nextval('event_ids')))::text)",
+		n.channel, string(payload))
+	return err
+}
+
+// Listen blocks until ctx is cancelled, forwarding every notification on the
+// channel to the broker.
+func (n *PostgresNotifier) Listen(ctx context.Context, dbURL string, b *Broker) error {
+	listener := pq.NewListener(dbURL, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Println("pubsub listener:", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(n.channel); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case notification := <-listener.Notify:
+			// nil is sent after the connection was re-established
+			if notification == nil {
+				continue
+			}
+			var e Event
+			if err := json.Unmarshal([]byte(notification.Extra), &e); err != nil {
+				log.Println("pubsub: couldn't decode notification:", err)
+				continue
+			}
+			b.Publish(ctx, e)
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPublishDeliversToSubscriber(t *testing.T) {
+	b := NewBroker(8)
+	sub, missed := b.Subscribe(0, nil)
+	defer sub.Close()
+
+	if len(missed) != 0 {
+		t.Fatalf("expected no replayed events, got %d", len(missed))
+	}
+
+	b.Publish(context.Background(), Event{Type: EventChirpCreated})
+
+	e := <-sub.C
+	if e.ID != 1 {
+		t.Fatalf("expected event ID 1, got %d", e.ID)
+	}
+	if e.Type != EventChirpCreated {
+		t.Fatalf("expected type %q, got %q", EventChirpCreated, e.Type)
+	}
+}
+
+func TestSubscribeFilter(t *testing.T) {
+	b := NewBroker(8)
+	author := uuid.New()
+	sub, _ := b.Subscribe(0, func(e Event) bool {
+		return e.AuthorID == author
+	})
+	defer sub.Close()
+
+	b.Publish(context.Background(), Event{Type: EventChirpCreated, AuthorID: uuid.New()})
+	b.Publish(context.Background(), Event{Type: EventChirpCreated, AuthorID: author})
+
+	e := <-sub.C
+	if e.AuthorID != author {
+		t.Fatalf("expected event from %v, got %v", author, e.AuthorID)
+	}
+	if e.ID != 2 {
+		t.Fatalf("expected event ID 2, got %d", e.ID)
+	}
+}
+
+func TestSubscribeReplaysFromLastID(t *testing.T) {
+	b := NewBroker(3)
+	for i := 0; i < 5; i++ {
+		b.Publish(context.Background(), Event{Type: EventChirpCreated})
+	}
+
+	sub, missed := b.Subscribe(3, nil)
+	defer sub.Close()
+
+	if len(missed) != 2 {
+		t.Fatalf("expected 2 replayed events, got %d", len(missed))
+	}
+	if missed[0].ID != 4 || missed[1].ID != 5 {
+		t.Fatalf("unexpected replayed IDs %d, %d", missed[0].ID, missed[1].ID)
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	b := NewBroker(1)
+	sub, _ := b.Subscribe(0, nil)
+
+	for i := 0; i < 100; i++ {
+		b.Publish(context.Background(), Event{Type: EventChirpCreated})
+	}
+
+	count := 0
+	for range sub.C {
+		count++
+	}
+	if count == 100 {
+		t.Fatal("expected slow subscriber to be dropped")
+	}
+
+	// closing an already dropped subscription must not panic
+	sub.Close()
+}
+
+func TestPublishKeepsAssignedIDs(t *testing.T) {
+	// two instances fed by the same NOTIFY channel
+	a, b := NewBroker(10), NewBroker(10)
+	b.Publish(context.Background(), Event{Type: EventChirpCreated})
+	for id := uint64(41); id <= 43; id++ {
+		a.Publish(context.Background(), Event{ID: id, Type: EventChirpCreated})
+		b.Publish(context.Background(), Event{ID: id, Type: EventChirpCreated})
+	}
+
+	_, missed := b.Subscribe(41, nil)
+	if len(missed) != 2 || missed[0].ID != 42 || missed[1].ID != 43 {
+		t.Fatalf("expected events 42 and 43 replayed, got %+v", missed)
+	}
+
+	b.Publish(context.Background(), Event{Type: EventChirpCreated})
+	_, missed = b.Subscribe(43, nil)
+	if len(missed) != 1 || missed[0].ID != 44 {
+		t.Fatalf("expected a local event after 43, got %+v", missed)
+	}
+}
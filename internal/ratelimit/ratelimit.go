@@ -0,0 +1,65 @@
+// Package ratelimit implements token bucket rate limiting with pluggable
+// stores, so limits can be kept in memory for a single instance or in a
+// shared store when several instances serve the same clients.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit allows Burst requests at once, refilling an empty bucket over Period.
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// Rate is the refill rate in tokens per second.
+func (l Limit) Rate() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed bool
+	Limit   int
+	// Remaining is the number of whole tokens left after this request.
+	Remaining int
+	// RetryAfter is how long until a token is available, 0 when allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Store takes a token from the bucket named key. Implementations must do
+// the refill and take atomically: a single SQL statement or a Redis script,
+// never a read followed by a write.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Evaluate builds the Result for a bucket holding tokens after the request,
+// for stores that only keep the token count.
+func Evaluate(limit Limit, tokens float64, allowed bool) Result {
+	rate := limit.Rate()
+	res := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: max(int(math.Floor(tokens)), 0),
+		Reset:     seconds((float64(limit.Burst) - tokens) / rate),
+	}
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Max(s, 0) * float64(time.Second))
+}
+
+// refill returns the tokens in a bucket that held tokens elapsed ago.
+func refill(limit Limit, tokens float64, elapsed time.Duration) float64 {
+	return math.Min(float64(limit.Burst), tokens+elapsed.Seconds()*limit.Rate())
+}
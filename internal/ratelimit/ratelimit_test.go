@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestStore() (*MemoryStore, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := NewMemoryStore()
+	s.now = clock.now
+	return s, clock
+}
+
+func TestMemoryStoreBurstThenDeny(t *testing.T) {
+	s, _ := newTestStore()
+	limit := Limit{Burst: 3, Period: 3 * time.Second}
+
+	for i := 0; i < 3; i++ {
+		res, err := s.Take(context.Background(), "k", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed {
+			t.Fatalf("request %d denied", i+1)
+		}
+		if res.Remaining != 2-i {
+			t.Fatalf("request %d: expected %d remaining, got %d", i+1, 2-i, res.Remaining)
+		}
+	}
+
+	res, _ := s.Take(context.Background(), "k", limit)
+	if res.Allowed {
+		t.Fatal("expected the fourth request to be denied")
+	}
+	if res.RetryAfter != time.Second {
+		t.Fatalf("expected retry after 1s, got %v", res.RetryAfter)
+	}
+	if res.Reset != 3*time.Second {
+		t.Fatalf("expected reset in 3s, got %v", res.Reset)
+	}
+}
+
+func TestMemoryStoreRefills(t *testing.T) {
+	s, clock := newTestStore()
+	limit := Limit{Burst: 2, Period: 2 * time.Second}
+
+	s.Take(context.Background(), "k", limit)
+	s.Take(context.Background(), "k", limit)
+
+	clock.t = clock.t.Add(500 * time.Millisecond)
+	if res, _ := s.Take(context.Background(), "k", limit); res.Allowed {
+		t.Fatal("half a token shouldn't be enough")
+	}
+
+	clock.t = clock.t.Add(500 * time.Millisecond)
+	if res, _ := s.Take(context.Background(), "k", limit); !res.Allowed {
+		t.Fatal("expected a token after a second")
+	}
+
+	// a long pause never refills past the burst
+	clock.t = clock.t.Add(time.Hour)
+	res, _ := s.Take(context.Background(), "k", limit)
+	if res.Remaining != 1 {
+		t.Fatalf("expected 1 remaining, got %d", res.Remaining)
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	s, _ := newTestStore()
+	limit := Limit{Burst: 1, Period: time.Minute}
+
+	if res, _ := s.Take(context.Background(), "a", limit); !res.Allowed {
+		t.Fatal("expected a to be allowed")
+	}
+	if res, _ := s.Take(context.Background(), "b", limit); !res.Allowed {
+		t.Fatal("expected b to be allowed")
+	}
+	if res, _ := s.Take(context.Background(), "a", limit); res.Allowed {
+		t.Fatal("expected a to be denied")
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	s, clock := newTestStore()
+	limit := Limit{Burst: 2, Period: 2 * time.Second}
+
+	s.Take(context.Background(), "idle", limit)
+	clock.t = clock.t.Add(time.Second)
+	s.Take(context.Background(), "busy", limit)
+
+	if n := s.Sweep(); n != 1 {
+		t.Fatalf("expected 1 bucket swept, got %d", n)
+	}
+	if _, ok := s.buckets["busy"]; !ok {
+		t.Fatal("busy bucket was swept")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	limit := Limit{Burst: 10, Period: 10 * time.Second}
+
+	res := Evaluate(limit, 0.25, false)
+	if res.Allowed || res.Remaining != 0 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if res.RetryAfter != 750*time.Millisecond {
+		t.Fatalf("expected retry after 750ms, got %v", res.RetryAfter)
+	}
+
+	res = Evaluate(limit, 9, true)
+	if res.Remaining != 9 || res.RetryAfter != 0 || res.Reset != time.Second {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
@@ -0,0 +1,83 @@
+package rpc
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Metrics counts calls per method and status code. The zero value is ready
+// to use and it is safe for concurrent use.
+type Metrics struct {
+	mu    sync.Mutex
+	calls map[callKey]*MethodStats
+}
+
+type callKey struct {
+	method string
+	code   codes.Code
+}
+
+// MethodStats are the calls of one method that ended with one code.
+type MethodStats struct {
+	Method string
+	Code   codes.Code
+	Calls  int64
+	// Total is the time spent in the calls, for streams until they ended.
+	Total time.Duration
+}
+
+// Unary counts unary calls.
+func (m *Metrics) Unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	m.record(info.FullMethod, status.Code(err), time.Since(start))
+	return resp, err
+}
+
+// Stream counts streams once they ended.
+func (m *Metrics) Stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	m.record(info.FullMethod, status.Code(err), time.Since(start))
+	return err
+}
+
+func (m *Metrics) record(method string, code codes.Code, took time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.calls == nil {
+		m.calls = make(map[callKey]*MethodStats)
+	}
+	key := callKey{method, code}
+	stats, ok := m.calls[key]
+	if !ok {
+		stats = &MethodStats{Method: method, Code: code}
+		m.calls[key] = stats
+	}
+	stats.Calls++
+	stats.Total += took
+}
+
+// Snapshot returns the counts so far, by method and then code.
+func (m *Metrics) Snapshot() []MethodStats {
+	m.mu.Lock()
+	out := make([]MethodStats, 0, len(m.calls))
+	for _, stats := range m.calls {
+		out = append(out, *stats)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Method != out[j].Method {
+			return out[i].Method < out[j].Method
+		}
+		return out[i].Code < out[j].Code
+	})
+	return out
+}
@@ -0,0 +1,179 @@
+// Package rpc has the interceptors of the gRPC server: request IDs,
+// logging, per-method metrics and bearer token authentication. They work
+// the same for unary and streaming calls; ServerOptions chains them in the
+// order the server needs.
+package rpc
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	requestIDHeader = "x-request-id"
+	maxRequestIDLen = 64
+)
+
+// Authenticator validates an access token and returns its user. An error
+// that carries a gRPC status is returned as is, any other error is reported
+// as Unauthenticated.
+type Authenticator func(token string) (uuid.UUID, error)
+
+type (
+	userKey      struct{}
+	requestIDKey struct{}
+)
+
+// ServerOptions chains the interceptors: the request ID comes first so the
+// others can log it, and logging and metrics see calls that failed auth.
+func ServerOptions(validate Authenticator, metrics *Metrics) []grpc.ServerOption {
+	authUnary, authStream := Auth(validate)
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(RequestIDUnary, LoggingUnary, metrics.Unary, authUnary),
+		grpc.ChainStreamInterceptor(RequestIDStream, LoggingStream, metrics.Stream, authStream),
+	}
+}
+
+// UserFrom returns the user of the call's bearer token, false for
+// anonymous calls.
+func UserFrom(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(userKey{}).(uuid.UUID)
+	return id, ok
+}
+
+// RequireUser is UserFrom for methods that need a token.
+func RequireUser(ctx context.Context) (uuid.UUID, error) {
+	id, ok := UserFrom(ctx)
+	if !ok {
+		return uuid.Nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	return id, nil
+}
+
+// RequestIDFrom returns the ID of the call, see RequestIDUnary.
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Auth returns interceptors that validate the bearer token in the
+// authorization metadata. Calls without one go through anonymously, methods
+// that need a user check with RequireUser.
+func Auth(validate Authenticator) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	authenticate := func(ctx context.Context) (context.Context, error) {
+		values := metadata.ValueFromIncomingContext(ctx, "authorization")
+		if len(values) == 0 {
+			return ctx, nil
+		}
+		token, ok := strings.CutPrefix(values[0], "Bearer ")
+		token = strings.TrimSpace(token)
+		if !ok || token == "" {
+			return nil, status.Error(codes.Unauthenticated, "malformed authorization metadata")
+		}
+
+		userID, err := validate(token)
+		if err != nil {
+			if _, ok := status.FromError(err); ok {
+				return nil, err
+			}
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+		}
+		return context.WithValue(ctx, userKey{}, userID), nil
+	}
+
+	unary := func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	stream := func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+	return unary, stream
+}
+
+// RequestIDUnary tags the call with an ID, reusing the caller's
+// x-request-id when it looks sane like the HTTP middleware does, and sends
+// it back in the response header.
+func RequestIDUnary(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(withRequestID(ctx), req)
+}
+
+// RequestIDStream is RequestIDUnary for streams.
+func RequestIDStream(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &serverStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+}
+
+func withRequestID(ctx context.Context) context.Context {
+	id := ""
+	if values := metadata.ValueFromIncomingContext(ctx, requestIDHeader); len(values) > 0 {
+		id = values[0]
+	}
+	if !validRequestID(id) {
+		id = uuid.NewString()
+	}
+	// fails only outside a real call, e.g. in tests
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, id))
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// LoggingUnary logs every call with its status code and duration.
+func LoggingUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, err, time.Since(start))
+	return resp, err
+}
+
+// LoggingStream logs every stream when it ends.
+func LoggingStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	logCall(ss.Context(), info.FullMethod, err, time.Since(start))
+	return err
+}
+
+func logCall(ctx context.Context, method string, err error, took time.Duration) {
+	code := status.Code(err)
+	if code == codes.Internal || code == codes.Unknown {
+		log.Printf("grpc %s %s %s %v: %v", method, code, RequestIDFrom(ctx), took, err)
+		return
+	}
+	log.Printf("grpc %s %s %s %v", method, code, RequestIDFrom(ctx), took)
+}
+
+// serverStream replaces the context of a stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,125 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var testUser = uuid.MustParse("6f1c5a4e-0a44-4b8e-9c1d-2f3e4a5b6c7d")
+
+func testValidate(token string) (uuid.UUID, error) {
+	switch token {
+	case "good":
+		return testUser, nil
+	case "suspended":
+		return uuid.Nil, status.Error(codes.PermissionDenied, "account suspended")
+	}
+	return uuid.Nil, errors.New("signature is invalid")
+}
+
+func TestAuth(t *testing.T) {
+	unary, _ := Auth(testValidate)
+
+	tests := []struct {
+		name          string
+		authorization string
+		code          codes.Code
+		user          uuid.UUID
+	}{
+		{"anonymous", "", codes.OK, uuid.Nil},
+		{"valid token", "Bearer good", codes.OK, testUser},
+		{"invalid token", "Bearer forged", codes.Unauthenticated, uuid.Nil},
+		{"not bearer", "ApiKey good", codes.Unauthenticated, uuid.Nil},
+		{"status from validate", "Bearer suspended", codes.PermissionDenied, uuid.Nil},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.authorization != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.authorization))
+		}
+
+		var user uuid.UUID
+		_, err := unary(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+			user, _ = UserFrom(ctx)
+			return nil, nil
+		})
+		if status.Code(err) != tt.code {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.code)
+		}
+		if user != tt.user {
+			t.Errorf("%s: got user %v, want %v", tt.name, user, tt.user)
+		}
+	}
+}
+
+func TestRequireUser(t *testing.T) {
+	if _, err := RequireUser(context.Background()); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated without a user, got %v", err)
+	}
+	ctx := context.WithValue(context.Background(), userKey{}, testUser)
+	if id, err := RequireUser(ctx); err != nil || id != testUser {
+		t.Fatalf("got %v %v, want %v", id, err, testUser)
+	}
+}
+
+// TestServerOptions runs the chained interceptors in front of the health
+// service over an in-memory connection.
+func TestServerOptions(t *testing.T) {
+	metrics := &Metrics{}
+	srv := grpc.NewServer(ServerOptions(testValidate, metrics)...)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	client := healthpb.NewHealthClient(conn)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "req-1", "authorization", "Bearer good")
+	var header metadata.MD
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Header(&header)); err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] != "req-1" {
+		t.Fatalf("expected the request ID to be echoed, got %v", got)
+	}
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer forged")
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated, got %v", err)
+	}
+
+	want := []MethodStats{
+		{Method: "/grpc.health.v1.Health/Check", Code: codes.OK, Calls: 1},
+		{Method: "/grpc.health.v1.Health/Check", Code: codes.Unauthenticated, Calls: 1},
+	}
+	got := metrics.Snapshot()
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].Method != want[i].Method || got[i].Code != want[i].Code || got[i].Calls != want[i].Calls {
+			t.Fatalf("got %+v, want %+v", got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package unfurl
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	maxTitleLength       = 200
+	maxDescriptionLength = 300
+)
+
+// Parse reads the Open Graph tags of a page, falling back to <title> and the
+// description meta tag. Relative image URLs are resolved against page.URL.
+func Parse(page *Page) (*Preview, error) {
+	var (
+		title, ogTitle       string
+		description, ogDesc  string
+		image, siteName      string
+		inTitle, titleParsed bool
+	)
+
+	z := html.NewTokenizer(bytes.NewReader(page.Body))
+loop:
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			// io.EOF or a body cut off at the size limit
+			break loop
+		case html.StartTagToken, html.SelfClosingTagToken:
+			t := z.Token()
+			switch t.DataAtom {
+			case atom.Body:
+				break loop
+			case atom.Title:
+				inTitle = !titleParsed
+			case atom.Meta:
+				key, content := metaAttrs(t)
+				switch key {
+				case "og:title":
+					ogTitle = content
+				case "og:description":
+					ogDesc = content
+				case "description":
+					description = content
+				case "og:image", "og:image:url":
+					if image == "" {
+						image = content
+					}
+				case "og:site_name":
+					siteName = content
+				}
+			}
+		case html.TextToken:
+			if inTitle {
+				title += string(z.Text())
+			}
+		case html.EndTagToken:
+			t := z.Token()
+			if t.DataAtom == atom.Head {
+				break loop
+			}
+			if t.DataAtom == atom.Title && inTitle {
+				inTitle = false
+				titleParsed = true
+			}
+		}
+	}
+
+	p := &Preview{
+		URL:         page.URL,
+		Title:       clip(firstNonEmpty(ogTitle, title), maxTitleLength),
+		Description: clip(firstNonEmpty(ogDesc, description), maxDescriptionLength),
+		ImageURL:    resolveImage(page.URL, image),
+		SiteName:    clip(siteName, maxTitleLength),
+	}
+	if p.Title == "" {
+		return nil, ErrNoPreview
+	}
+	return p, nil
+}
+
+// metaAttrs returns the property (or name) of a meta tag and its content.
+func metaAttrs(t html.Token) (key, content string) {
+	for _, a := range t.Attr {
+		switch a.Key {
+		case "property", "name":
+			if key == "" {
+				key = strings.ToLower(strings.TrimSpace(a.Val))
+			}
+		case "content":
+			content = a.Val
+		}
+	}
+	return key, content
+}
+
+func resolveImage(pageURL, image string) string {
+	if image == "" {
+		return ""
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	u, err := base.Parse(strings.TrimSpace(image))
+	if err != nil || checkScheme(u) != nil {
+		return ""
+	}
+	return u.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// clip collapses whitespace and shortens s to at most n bytes without
+// splitting a rune.
+func clip(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) <= n {
+		return s
+	}
+	s = s[:n]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
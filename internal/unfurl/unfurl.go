@@ -0,0 +1,124 @@
+// Package unfurl builds link preview cards from the Open Graph and HTML
+// metadata of web pages.
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrNoPreview means the page has nothing to build a card from, or an
+	// earlier attempt failed and that result is still cached.
+	ErrNoPreview = errors.New("unfurl: no preview available")
+)
+
+// Preview is a link preview card.
+type Preview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+// Page is a fetched HTML document.
+type Page struct {
+	// URL is the final URL after redirects.
+	URL  string
+	Body []byte
+}
+
+// Fetcher downloads a page. HTTPFetcher is the production implementation.
+type Fetcher interface {
+	Fetch(ctx context.Context, rawURL string) (*Page, error)
+}
+
+// Cache stores unfurl results, including failures so broken links aren't
+// fetched over and over. Implementations decide how long entries live.
+type Cache interface {
+	// Get reports whether rawURL is cached. A nil preview for a cached URL
+	// means the earlier attempt failed.
+	Get(ctx context.Context, rawURL string) (p *Preview, found bool, err error)
+	Set(ctx context.Context, rawURL string, p *Preview) error
+}
+
+type Unfurler struct {
+	fetcher Fetcher
+	cache   Cache
+}
+
+func New(fetcher Fetcher, cache Cache) *Unfurler {
+	return &Unfurler{
+		fetcher: fetcher,
+		cache:   cache,
+	}
+}
+
+// Unfurl returns the preview for rawURL, from the cache if possible.
+func (u *Unfurler) Unfurl(ctx context.Context, rawURL string) (*Preview, error) {
+	p, found, err := u.cache.Get(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		if p == nil {
+			return nil, ErrNoPreview
+		}
+		return p, nil
+	}
+
+	p, err = u.fetch(ctx, rawURL)
+	if err != nil {
+		if ctx.Err() != nil {
+			// we were cancelled, that says nothing about the link
+			return nil, err
+		}
+		if cacheErr := u.cache.Set(ctx, rawURL, nil); cacheErr != nil {
+			return nil, cacheErr
+		}
+		return nil, err
+	}
+
+	if err := u.cache.Set(ctx, rawURL, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (u *Unfurler) fetch(ctx context.Context, rawURL string) (*Preview, error) {
+	page, err := u.fetcher.Fetch(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := Parse(page)
+	if err != nil {
+		return nil, err
+	}
+	// cards always link to what the user wrote, not where it redirected to
+	p.URL = rawURL
+	return p, nil
+}
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// FindURLs returns the distinct http(s) URLs in text, in order, up to max.
+func FindURLs(text string, max int) []string {
+	var urls []string
+	seen := make(map[string]struct{})
+	for _, u := range urlPattern.FindAllString(text, -1) {
+		u = strings.TrimRight(u, ".,;:!?)]}'")
+		if _, ok := seen[u]; ok {
+			continue
+		}
+		seen[u] = struct{}{}
+		urls = append(urls, u)
+		if len(urls) == max {
+			break
+		}
+	}
+	return urls
+}
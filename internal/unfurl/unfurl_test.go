@@ -0,0 +1,176 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testPage = `<!doctype html>
+<html><head>
+<title>Fallback title</title>
+<meta property="og:title" content="Chirpy launches">
+<meta name="description" content="A small Twitter-like API">
+<meta property="og:image" content="/img/card.png">
+<meta property="og:site_name" content="Chirpy Blog">
+</head><body><p>hello</p></body></html>`
+
+// testFetcher lets tests talk to httptest servers on the loopback interface.
+func testFetcher(timeout time.Duration, maxBytes int64) *HTTPFetcher {
+	return newHTTPFetcher(timeout, maxBytes, func(netip.Addr) error { return nil })
+}
+
+type mapCache struct {
+	mu      sync.Mutex
+	entries map[string]*Preview
+}
+
+func (c *mapCache) Get(_ context.Context, rawURL string) (*Preview, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.entries[rawURL]
+	return p, ok, nil
+}
+
+func (c *mapCache) Set(_ context.Context, rawURL string, p *Preview) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[rawURL] = p
+	return nil
+}
+
+func newMapCache() *mapCache {
+	return &mapCache{entries: make(map[string]*Preview)}
+}
+
+func TestUnfurlOpenGraph(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(testPage))
+	}))
+	defer srv.Close()
+
+	u := New(testFetcher(time.Second, 64<<10), newMapCache())
+	p, err := u.Unfurl(context.Background(), srv.URL+"/post")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.Title != "Chirpy launches" {
+		t.Errorf("expected og:title, got %q", p.Title)
+	}
+	if p.Description != "A small Twitter-like API" {
+		t.Errorf("expected description fallback, got %q", p.Description)
+	}
+	if p.ImageURL != srv.URL+"/img/card.png" {
+		t.Errorf("expected resolved image URL, got %q", p.ImageURL)
+	}
+	if p.SiteName != "Chirpy Blog" {
+		t.Errorf("expected site name, got %q", p.SiteName)
+	}
+	if p.URL != srv.URL+"/post" {
+		t.Errorf("expected requested URL, got %q", p.URL)
+	}
+}
+
+func TestUnfurlCachesResults(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(testPage))
+	}))
+	defer srv.Close()
+
+	u := New(testFetcher(time.Second, 64<<10), newMapCache())
+	for i := 0; i < 3; i++ {
+		if _, err := u.Unfurl(context.Background(), srv.URL+"/post"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("expected 1 request for a cached page, got %d", n)
+	}
+
+	u.Unfurl(context.Background(), srv.URL+"/missing")
+	_, err := u.Unfurl(context.Background(), srv.URL+"/missing")
+	if !errors.Is(err, ErrNoPreview) {
+		t.Fatalf("expected cached failure, got %v", err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Fatalf("expected failures to be cached, got %d requests", n)
+	}
+}
+
+func TestHTTPFetcherBlocksPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached a loopback server")
+	}))
+	defer srv.Close()
+
+	f := NewHTTPFetcher(time.Second, 64<<10)
+	for _, target := range []string{srv.URL, "http://[::1]:1/", "http://169.254.169.254/latest/meta-data/"} {
+		if _, err := f.Fetch(context.Background(), target); !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("%s: expected ErrForbiddenAddress, got %v", target, err)
+		}
+	}
+
+	if _, err := f.Fetch(context.Background(), "file:///etc/passwd"); !errors.Is(err, ErrUnsupportedURL) {
+		t.Errorf("expected ErrUnsupportedURL, got %v", err)
+	}
+}
+
+func TestHTTPFetcherLimits(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+		case "/huge":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(testPage[:strings.Index(testPage, "</head>")]))
+			w.Write([]byte(strings.Repeat("x", 1<<20)))
+		}
+	}))
+	defer srv.Close()
+
+	f := testFetcher(100*time.Millisecond, 1024)
+
+	if _, err := f.Fetch(context.Background(), srv.URL+"/slow"); err == nil {
+		t.Error("expected a timeout")
+	}
+	if _, err := f.Fetch(context.Background(), srv.URL+"/image"); !errors.Is(err, ErrNotHTML) {
+		t.Errorf("expected ErrNotHTML, got %v", err)
+	}
+
+	page, err := f.Fetch(context.Background(), srv.URL+"/huge")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Body) != 1024 {
+		t.Fatalf("expected body cut at 1024 bytes, got %d", len(page.Body))
+	}
+	if p, err := Parse(page); err != nil || p.Title != "Chirpy launches" {
+		t.Fatalf("expected truncated page to parse, got %v, %v", p, err)
+	}
+}
+
+func TestFindURLs(t *testing.T) {
+	got := FindURLs("see https://a.example/x, and (http://b.example/y) or https://a.example/x again https://c.example", 2)
+	want := []string{"https://a.example/x", "http://b.example/y"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
@@ -1,25 +1,54 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"sync/atomic"
+	"time"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	httpSwagger "github.com/swaggo/http-swagger"
-	_ "github.com/tsironi93/WebServer/docs"
 	"github.com/tsironi93/WebServer/internal/database"
+	"github.com/tsironi93/WebServer/internal/graphql"
+	"github.com/tsironi93/WebServer/internal/openapi"
+	"github.com/tsironi93/WebServer/internal/pubsub"
+	"github.com/tsironi93/WebServer/internal/ratelimit"
+	"github.com/tsironi93/WebServer/internal/rpc"
+	"github.com/tsironi93/WebServer/internal/unfurl"
 )
 
 type apiConf struct {
 	fileserverHits atomic.Int32
 	db             *database.Queries
+	dbConn         *sql.DB
 	platform       string
 	JWTSecret      string
 	PolkaKey       string
+	broker         *pubsub.Broker
+	events         pubsub.Publisher
+	sockets        *socketRegistry
+	notifier       *notifier
+	previews       *linkPreviewer
+	suspensions    *suspensionCache
+	rateLimits     ratelimit.Store
+	plans          *planCache
+	spec           *openapi.Validator
+	graphql        *graphql.Schema
+	// persistedQueries are the parsed GraphQL queries by hash
+	persistedQueries *persistedQueries
+	grpcMetrics      *rpc.Metrics
+	// mux and dispatch (the middleware without the request ID) serve the
+	// sub-requests of /api/batch
+	mux      *http.ServeMux
+	dispatch http.Handler
+	// validateResponses checks every response against the spec, for tests
+	validateResponses bool
 }
 
 func loadEnvAndConnect() apiConf {
@@ -42,6 +71,26 @@ func loadEnvAndConnect() apiConf {
 		log.Fatal("POLKA_KEY must be set")
 	}
 
+	// memory is fine for a single instance, several instances behind a load
+	// balancer need the shared postgres store to enforce one limit
+	rateLimitStore := os.Getenv("RATE_LIMIT_STORE")
+	if rateLimitStore == "" {
+		rateLimitStore = "memory"
+	}
+	if rateLimitStore != "memory" && rateLimitStore != "postgres" {
+		log.Fatal("RATE_LIMIT_STORE must be memory or postgres")
+	}
+
+	spec, err := loadOpenAPI()
+	if err != nil {
+		log.Fatal("couldn't load openapi.yaml: ", err)
+	}
+
+	graphQL, err := newGraphQLSchema()
+	if err != nil {
+		log.Fatal("couldn't load schema.graphql: ", err)
+	}
+
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
 		log.Fatal(err)
@@ -52,51 +101,195 @@ func loadEnvAndConnect() apiConf {
 	}
 	log.Println("Successfully connected to DB!")
 
+	// background workers get queries that never join a batch transaction,
+	// handlers get cfg.db, which does
 	dbQueries := database.New(db)
+
+	broker := pubsub.NewBroker(256)
+	pgEvents := pubsub.NewPostgresNotifier(db, "chirp_events")
+	go func() {
+		if err := pgEvents.Listen(context.Background(), dbURL, broker); err != nil {
+			log.Println("chirp event listener stopped:", err)
+		}
+	}()
+
+	notifications := newNotifier(dbQueries, pgEvents, 1024)
+	notifications.run(context.Background(), 2)
+
+	previews := newLinkPreviewer(unfurl.New(
+		unfurl.NewHTTPFetcher(5*time.Second, 512<<10),
+		previewCache{db: dbQueries},
+	), 256)
+	previews.run(context.Background(), 4)
+
+	suspensions := newSuspensionCache()
+	if err := suspensions.reload(context.Background(), dbQueries); err != nil {
+		log.Println("couldn't load suspensions:", err)
+	}
+
+	var rateLimits ratelimit.Store = ratelimit.NewMemoryStore()
+	if rateLimitStore == "postgres" {
+		rateLimits = postgresRateStore{db: dbQueries}
+	}
+
 	return apiConf{
-		db:        dbQueries,
-		platform:  platform,
-		JWTSecret: secret,
-		PolkaKey:  polkaKey,
+		db:          database.New(txDB{db: db}),
+		dbConn:      db,
+		platform:    platform,
+		JWTSecret:   secret,
+		PolkaKey:    polkaKey,
+		broker:      broker,
+		events:      pgEvents,
+		sockets:     newSocketRegistry(),
+		notifier:    notifications,
+		previews:    previews,
+		suspensions: suspensions,
+		rateLimits:  rateLimits,
+		plans:       newPlanCache(),
+		spec:        spec,
+		graphql:     graphQL,
+
+		persistedQueries: newPersistedQueries(),
+		grpcMetrics:      &rpc.Metrics{},
+
+		validateResponses: os.Getenv("OPENAPI_VALIDATE_RESPONSES") == "true",
 	}
 }
 
 func main() {
 	const filepathRoot = "."
 	const port = "8080"
+	// gRPC gets its own port, internal services reach it without going
+	// through the HTTP middleware
+	const grpcPort = "9090"
+
+	adminEmail := flag.String("bootstrap-admin", "", "make the user with this email the first admin and exit")
+	flag.Parse()
 
 	cfg := loadEnvAndConnect()
+	if *adminEmail != "" {
+		if err := bootstrapAdmin(context.Background(), cfg.db, *adminEmail); err != nil {
+			log.Fatal("couldn't bootstrap admin: ", err)
+		}
+		log.Printf("%s is now an admin", *adminEmail)
+		return
+	}
+
+	go cfg.runPurgeJob(context.Background(), time.Hour)
+	go cfg.runScheduler(context.Background(), 15*time.Second)
+	go cfg.runPollFreezer(context.Background(), time.Minute)
+	go cfg.runSuspensionRefresher(context.Background(), 30*time.Second)
+	go cfg.runRateLimitCleanup(context.Background(), time.Minute)
+
 	mux := http.NewServeMux()
 
 	fsHandler := cfg.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot))))
 	mux.Handle("/app/", fsHandler)
 
-	mux.HandleFunc("GET /admin/metrics", cfg.HandlerMetrics)
-	mux.HandleFunc("POST /admin/reset", cfg.HandlerResetHits)
+	mux.HandleFunc("GET /admin/metrics", cfg.requirePermission(permViewMetrics, cfg.HandlerMetrics))
+	mux.HandleFunc("POST /admin/reset", cfg.requirePermission(permResetData, cfg.HandlerResetHits))
+	mux.HandleFunc("GET /admin/reports", cfg.requirePermission(permModerate, cfg.HandlerAdminReportsList))
+	mux.HandleFunc("POST /admin/reports/{reportID}/resolve", cfg.requirePermission(permModerate, cfg.HandlerAdminReportsResolve))
+	mux.HandleFunc("GET /admin/moderation-log", cfg.requirePermission(permViewModLog, cfg.HandlerAdminModerationLog))
+	mux.HandleFunc("PUT /admin/users/{userID}/role", cfg.requirePermission(permManageRoles, cfg.HandlerAdminUserSetRole))
+	mux.HandleFunc("POST /admin/users/{userID}/suspend", cfg.requirePermission(permSuspendUsers, cfg.HandlerAdminUserSuspend))
+	mux.HandleFunc("POST /admin/users/{userID}/unsuspend", cfg.requirePermission(permSuspendUsers, cfg.HandlerAdminUserUnsuspend))
+	mux.HandleFunc("GET /admin/users/{userID}/suspensions", cfg.requirePermission(permSuspendUsers, cfg.HandlerAdminUserSuspensions))
+	mux.HandleFunc("POST /admin/users/{userID}/unlock", cfg.requirePermission(permUnlockLogins, cfg.HandlerAdminUserUnlock))
+	mux.HandleFunc("GET /admin/audit", cfg.requirePermission(permViewAudit, cfg.HandlerAdminAudit))
 
 	mux.HandleFunc("GET /api/healthz", HandlerReadiness)
+	mux.HandleFunc("GET /api/openapi.yaml", HandlerOpenAPISpec)
 
 	mux.HandleFunc("GET /api/chirps", cfg.HandlerChirpsGetAll)
-	mux.HandleFunc("POST /api/chirps", cfg.HandlerChirpsCreate)
+	mux.HandleFunc("POST /api/chirps", cfg.idempotent(cfg.HandlerChirpsCreate))
 
 	mux.HandleFunc("POST /api/users", cfg.HandlerUserCreate)
+	mux.HandleFunc("POST /api/v2/users", cfg.idempotent(cfg.HandlerUserCreateV2))
 	mux.HandleFunc("PUT /api/users", cfg.HandlerUserUpdate)
 
+	mux.HandleFunc("POST /api/users/{userID}/block", cfg.HandlerUserBlock)
+	mux.HandleFunc("DELETE /api/users/{userID}/block", cfg.HandlerUserUnblock)
+	mux.HandleFunc("POST /api/users/{userID}/mute", cfg.HandlerUserMute)
+	mux.HandleFunc("DELETE /api/users/{userID}/mute", cfg.HandlerUserUnmute)
+	mux.HandleFunc("POST /api/users/{userID}/follow", cfg.HandlerUserFollow)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", cfg.HandlerUserUnfollow)
+	mux.HandleFunc("POST /api/users/{userID}/report", cfg.HandlerUserReport)
+	mux.HandleFunc("GET /api/users/me/blocks", cfg.HandlerUserBlocksList)
+	mux.HandleFunc("GET /api/users/me/mutes", cfg.HandlerUserMutesList)
+	mux.HandleFunc("GET /api/users/me/followers", cfg.HandlerUserFollowersList)
+	mux.HandleFunc("GET /api/users/me/following", cfg.HandlerUserFollowingList)
+
 	mux.HandleFunc("POST /api/login", cfg.HandlerUserLogin)
+	mux.HandleFunc("POST /api/v2/login", cfg.HandlerUserLoginV2)
 	mux.HandleFunc("POST /api/refresh", cfg.HandlerTokenRefresh)
 	mux.HandleFunc("POST /api/revoke", cfg.HandlerTokenRevoke)
 
+	mux.HandleFunc("GET /api/chirps/stream", cfg.HandlerChirpsStream)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", cfg.HandlerChirpsGetSingle)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", cfg.HandlerChirpsThread)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", cfg.HandlerChirpsDelete)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/restore", cfg.HandlerChirpsRestore)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/poll/vote", cfg.HandlerChirpsPollVote)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", cfg.HandlerChirpsBookmark)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", cfg.HandlerChirpsUnbookmark)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/pin", cfg.HandlerChirpsPin)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/pin", cfg.HandlerChirpsUnpin)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/report", cfg.HandlerChirpsReport)
+	mux.HandleFunc("GET /api/users/me/trash", cfg.HandlerChirpsTrash)
+	mux.HandleFunc("GET /api/users/me/bookmarks", cfg.HandlerUserBookmarksList)
+	mux.HandleFunc("GET /api/users/me/security-log", cfg.HandlerUserSecurityLog)
+
+	mux.HandleFunc("GET /api/drafts", cfg.HandlerChirpDraftsList)
+	mux.HandleFunc("GET /api/drafts/{draftID}", cfg.HandlerChirpDraftsGet)
+	mux.HandleFunc("PUT /api/drafts/{draftID}", cfg.HandlerChirpDraftsUpdate)
+	mux.HandleFunc("DELETE /api/drafts/{draftID}", cfg.HandlerChirpDraftsDelete)
+	mux.HandleFunc("POST /api/drafts/{draftID}/publish", cfg.HandlerChirpDraftsPublish)
+
+	mux.HandleFunc("POST /api/polka/webhooks", cfg.idempotent(cfg.HandlerUserUpgradeToRed))
 
-	mux.HandleFunc("POST /api/polka/webhooks", cfg.HandlerUserUpgradeToRed)
+	mux.HandleFunc("GET /api/ws", cfg.HandlerWebSocket)
 
-	mux.Handle("/swagger/", httpSwagger.WrapHandler)
+	mux.HandleFunc("POST /api/conversations", cfg.HandlerConversationsCreate)
+	mux.HandleFunc("GET /api/conversations", cfg.HandlerConversationsList)
+	mux.HandleFunc("GET /api/conversations/{conversationID}", cfg.HandlerConversationsGet)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/messages", cfg.HandlerMessagesSend)
+	mux.HandleFunc("GET /api/conversations/{conversationID}/messages", cfg.HandlerMessagesList)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/read", cfg.HandlerConversationsMarkRead)
+
+	mux.HandleFunc("GET /api/notifications", cfg.HandlerNotificationsList)
+	mux.HandleFunc("POST /api/notifications/read", cfg.HandlerNotificationsMarkAllRead)
+	mux.HandleFunc("POST /api/notifications/{notificationID}/read", cfg.HandlerNotificationsMarkRead)
+	mux.HandleFunc("GET /api/notifications/preferences", cfg.HandlerNotificationPreferencesGet)
+	mux.HandleFunc("PUT /api/notifications/preferences", cfg.HandlerNotificationPreferencesUpdate)
+
+	mux.HandleFunc("POST /api/batch", cfg.HandlerBatch)
+
+	mux.HandleFunc("POST /graphql", cfg.HandlerGraphQL)
+	mux.HandleFunc("GET /graphql", cfg.HandlerGraphQLGet)
+
+	mux.Handle("/swagger/", httpSwagger.Handler(httpSwagger.URL("/api/openapi.yaml")))
+
+	var handler http.Handler = mux
+	handler = cfg.middlewareOpenAPI(mux, handler)
+	handler = cfg.middlewareRateLimit(mux, handler)
+	handler = middlewareAPIVersion(mux, handler)
+	cfg.mux, cfg.dispatch = mux, handler
+	handler = middlewareRequestID(handler)
 
 	srv := &http.Server{
 		Addr:    ":" + port,
-		Handler: mux,
+		Handler: handler,
+	}
+
+	lis, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		log.Fatal("couldn't listen for gRPC: ", err)
 	}
+	go func() {
+		log.Printf("Serving gRPC on port: %s\n", grpcPort)
+		log.Fatal(cfg.newGRPCServer().Serve(lis))
+	}()
 
 	log.Printf("Serving files from %s on port: %s\n", filepathRoot, port)
 	log.Fatal(srv.ListenAndServe())
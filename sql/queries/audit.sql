@@ -0,0 +1,32 @@
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (id, created_at, event_type, actor_id, subject_id, target_type, target_id, ip, user_agent, request_id, details)
+VALUES (
+  gen_random_uuid(),
+  NOW(),
+  @event_type,
+  @actor_id,
+  @subject_id,
+  @target_type,
+  @target_id,
+  @ip,
+  @user_agent,
+  @request_id,
+  @details
+);
+
+-- name: ListAuditEvents :many
+-- Empty strings and uuid.Nil disable the matching filter.
+SELECT * FROM audit_events
+WHERE (@event_type::text = '' OR event_type = @event_type::text)
+  AND (@actor_id::uuid = '00000000-0000-0000-0000-000000000000'::uuid OR actor_id = @actor_id::uuid)
+  AND (@subject_id::uuid = '00000000-0000-0000-0000-000000000000'::uuid OR subject_id = @subject_id::uuid)
+  AND created_at >= @since::timestamp
+  AND created_at < @until::timestamp
+ORDER BY created_at DESC
+LIMIT @row_limit OFFSET @row_offset;
+
+-- name: ListUserSecurityEvents :many
+SELECT * FROM audit_events
+WHERE subject_id = @subject_id::uuid
+ORDER BY created_at DESC
+LIMIT @row_limit OFFSET @row_offset;
@@ -0,0 +1,45 @@
+-- name: BlockUser :exec
+INSERT INTO user_blocks (blocker_id, blocked_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :exec
+DELETE FROM user_blocks
+WHERE blocker_id = $1
+  AND blocked_id = $2;
+
+-- name: ListBlockedUsers :many
+SELECT * FROM user_blocks
+WHERE blocker_id = $1
+ORDER BY created_at DESC;
+
+-- name: MuteUser :exec
+INSERT INTO user_mutes (muter_id, muted_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteUser :exec
+DELETE FROM user_mutes
+WHERE muter_id = $1
+  AND muted_id = $2;
+
+-- name: ListMutedUsers :many
+SELECT * FROM user_mutes
+WHERE muter_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListBlockRelations :many
+-- Users the user blocked or was blocked by, hidden both ways in live
+-- streams.
+SELECT CASE WHEN b.blocker_id = @user_id THEN b.blocked_id ELSE b.blocker_id END::uuid AS other_id
+FROM user_blocks b
+WHERE b.blocker_id = @user_id
+   OR b.blocked_id = @user_id;
+
+-- name: HasBlockWith :one
+-- Whether the user blocked, or was blocked by, any of the others.
+SELECT EXISTS (
+  SELECT 1 FROM user_blocks b
+  WHERE (b.blocker_id = @user_id AND b.blocked_id = ANY(@other_ids::uuid[]))
+     OR (b.blocked_id = @user_id AND b.blocker_id = ANY(@other_ids::uuid[]))
+);
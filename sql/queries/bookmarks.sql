@@ -0,0 +1,33 @@
+-- name: CreateBookmark :exec
+INSERT INTO bookmarks (user_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBookmark :exec
+DELETE FROM bookmarks
+WHERE user_id = $1
+  AND chirp_id = $2;
+
+-- name: ListBookmarkedChirps :many
+-- Bookmarks of deleted or hidden chirps and of authors that blocked the user are
+-- skipped but kept, so they come back if the chirp is restored.
+SELECT c.*, b.created_at AS bookmarked_at
+FROM bookmarks b
+JOIN chirps c ON c.id = b.chirp_id
+WHERE b.user_id = @user_id
+  AND c.deleted_at IS NULL
+  AND c.hidden_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM user_suspensions s
+    WHERE s.user_id = c.user_id
+      AND s.hide_chirps
+      AND s.lifted_at IS NULL
+      AND (s.expires_at IS NULL OR s.expires_at > NOW())
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM user_blocks ub
+    WHERE ub.blocker_id = c.user_id
+      AND ub.blocked_id = @user_id
+  )
+ORDER BY b.created_at DESC
+LIMIT @row_limit OFFSET @row_offset;
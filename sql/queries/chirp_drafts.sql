@@ -0,0 +1,65 @@
+-- name: CreateChirpDraft :one
+-- publish_in_secs is NULL for drafts. The schedule is computed from NOW(),
+-- like the scheduler's check, so it doesn't depend on the time zone.
+INSERT INTO chirp_drafts (id, created_at, updated_at, user_id, body, publish_at)
+VALUES (
+  gen_random_uuid(),
+  NOW(),
+  NOW(),
+  @user_id,
+  @body,
+  NOW() + make_interval(secs => sqlc.narg(publish_in_secs)::float8)
+)
+RETURNING *;
+
+-- name: ListChirpDrafts :many
+-- status is empty for everything, 'draft' for unscheduled drafts or 'scheduled'.
+SELECT * FROM chirp_drafts
+WHERE user_id = @user_id
+  AND (
+    @status::text = ''
+    OR (@status::text = 'draft' AND publish_at IS NULL)
+    OR (@status::text = 'scheduled' AND publish_at IS NOT NULL)
+  )
+ORDER BY publish_at NULLS LAST, updated_at DESC;
+
+-- name: GetChirpDraft :one
+SELECT * FROM chirp_drafts
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: GetChirpDraftForUpdate :one
+SELECT * FROM chirp_drafts
+WHERE id = $1
+  AND user_id = $2
+FOR UPDATE;
+
+-- name: UpdateChirpDraft :one
+UPDATE chirp_drafts
+SET body = @body,
+    publish_at = NOW() + make_interval(secs => sqlc.narg(publish_in_secs)::float8),
+    updated_at = NOW()
+WHERE id = @id
+  AND user_id = @user_id
+RETURNING *;
+
+-- name: DeleteChirpDraft :one
+-- Removes a draft and returns it, so publishing and cancelling can't race
+-- with the scheduler.
+DELETE FROM chirp_drafts
+WHERE id = $1
+  AND user_id = $2
+RETURNING *;
+
+-- name: ClaimDueChirpDrafts :many
+-- Locks due drafts for the current transaction. Other instances skip the
+-- locked rows, so every draft is published exactly once.
+SELECT * FROM chirp_drafts
+WHERE publish_at <= NOW()
+ORDER BY publish_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: DeleteClaimedChirpDraft :exec
+DELETE FROM chirp_drafts
+WHERE id = $1;
@@ -1,11 +1,14 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, quoted_chirp_id, reply_to_id, thread_id)
 VALUES (
   gen_random_uuid(),
   NOW(),
   NOW(),
   $1,
-  $2
+  $2,
+  $3,
+  $4,
+  $5
 )
 RETURNING *;
 
@@ -13,16 +16,268 @@ RETURNING *;
 DELETE FROM chirps;
 
 -- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id
-FROM chirps
-WHERE ($1 = '00000000-0000-0000-0000-000000000000'::uuid OR user_id = $1)
+-- viewer_id is uuid.Nil for anonymous requests. Chirps are hidden when either
+-- side blocked the other or the viewer muted the author.
+SELECT * FROM chirps
+WHERE (@author_id::uuid = '00000000-0000-0000-0000-000000000000'::uuid OR user_id = @author_id::uuid)
+  AND deleted_at IS NULL
+  AND hidden_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM user_suspensions s
+    WHERE s.user_id = chirps.user_id
+      AND s.hide_chirps
+      AND s.lifted_at IS NULL
+      AND (s.expires_at IS NULL OR s.expires_at > NOW())
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM user_blocks b
+    WHERE (b.blocker_id = chirps.user_id AND b.blocked_id = @viewer_id::uuid)
+       OR (b.blocker_id = @viewer_id::uuid AND b.blocked_id = chirps.user_id)
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM user_mutes m
+    WHERE m.muter_id = @viewer_id::uuid
+      AND m.muted_id = chirps.user_id
+  )
 ORDER BY created_at;
 
+-- name: GetChirpsByAuthors :many
+-- Batch version of GetChirps for a set of authors, with the same filters.
+-- Each author's chirps are paged on their own: pinned chirps first, most
+-- recently pinned first, then the rest by created_at.
+SELECT id, created_at, updated_at, body, user_id, deleted_at, quoted_chirp_id, hidden_at, reply_to_id, thread_id
+FROM (
+  SELECT chirps.*, ROW_NUMBER() OVER (
+    PARTITION BY chirps.user_id
+    ORDER BY p.created_at DESC NULLS LAST,
+      CASE WHEN @descending::bool THEN chirps.created_at END DESC,
+      chirps.created_at
+  ) AS row_num
+  FROM chirps
+  LEFT JOIN chirp_pins p ON p.chirp_id = chirps.id AND p.user_id = chirps.user_id
+  WHERE chirps.user_id = ANY(@author_ids::uuid[])
+    AND chirps.deleted_at IS NULL
+    AND chirps.hidden_at IS NULL
+    AND NOT EXISTS (
+      SELECT 1 FROM user_suspensions s
+      WHERE s.user_id = chirps.user_id
+        AND s.hide_chirps
+        AND s.lifted_at IS NULL
+        AND (s.expires_at IS NULL OR s.expires_at > NOW())
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM user_blocks b
+      WHERE (b.blocker_id = chirps.user_id AND b.blocked_id = @viewer_id::uuid)
+         OR (b.blocker_id = @viewer_id::uuid AND b.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM user_mutes m
+      WHERE m.muter_id = @viewer_id::uuid
+        AND m.muted_id = chirps.user_id
+    )
+) ranked
+WHERE row_num > sqlc.arg(row_offset)::bigint
+  AND row_num <= sqlc.arg(row_offset)::bigint + sqlc.arg(row_limit)::bigint
+ORDER BY user_id, row_num;
+
+-- name: GetChirpsPage :many
+-- A page of GetChirps over all authors.
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+  AND hidden_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM user_suspensions s
+    WHERE s.user_id = chirps.user_id
+      AND s.hide_chirps
+      AND s.lifted_at IS NULL
+      AND (s.expires_at IS NULL OR s.expires_at > NOW())
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM user_blocks b
+    WHERE (b.blocker_id = chirps.user_id AND b.blocked_id = @viewer_id::uuid)
+       OR (b.blocker_id = @viewer_id::uuid AND b.blocked_id = chirps.user_id)
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM user_mutes m
+    WHERE m.muter_id = @viewer_id::uuid
+      AND m.muted_id = chirps.user_id
+  )
+ORDER BY CASE WHEN @descending::bool THEN created_at END DESC, created_at
+LIMIT @row_limit OFFSET @row_offset;
+
+-- name: GetChirpsLastModified :one
+-- Latest change to the chirps GetChirps could return, including ones that
+-- were deleted or hidden since: the chirps themselves (votes and pins bump
+-- updated_at), polls that closed, quoted chirps and the link previews of
+-- urls. Blocks, mutes and suspensions aren't tracked.
+SELECT GREATEST(
+  (SELECT MAX(c.updated_at) FROM chirps c
+    WHERE @author_id::uuid = '00000000-0000-0000-0000-000000000000'::uuid OR c.user_id = @author_id::uuid),
+  (SELECT MAX(p.expires_at) FROM polls p
+    JOIN chirps c ON c.id = p.chirp_id
+    WHERE (@author_id::uuid = '00000000-0000-0000-0000-000000000000'::uuid OR c.user_id = @author_id::uuid)
+      AND p.expires_at <= NOW()),
+  (SELECT MAX(q.updated_at) FROM chirps c
+    JOIN chirps q ON q.id = c.quoted_chirp_id
+    WHERE @author_id::uuid = '00000000-0000-0000-0000-000000000000'::uuid OR c.user_id = @author_id::uuid),
+  (SELECT MAX(fetched_at) FROM link_previews
+    WHERE url = ANY(@urls::text[])),
+  '0001-01-01'::timestamp
+)::timestamp AS last_modified;
+
 -- name: GetSingleChirp :one
-SELECT id, created_at, updated_at, body, user_id
+SELECT * FROM chirps
+  WHERE id = $1
+    AND deleted_at IS NULL;
+
+-- name: GetChirpLastModified :one
+-- GetChirpsLastModified for a single chirp.
+SELECT GREATEST(
+  c.updated_at,
+  (SELECT p.expires_at FROM polls p
+    WHERE p.chirp_id = c.id
+      AND p.expires_at <= NOW()),
+  (SELECT q.updated_at FROM chirps q
+    WHERE q.id = c.quoted_chirp_id),
+  (SELECT MAX(fetched_at) FROM link_previews
+    WHERE url = ANY(@urls::text[]))
+)::timestamp AS last_modified
+FROM chirps c
+WHERE c.id = @chirp_id;
+
+-- name: GetThread :many
+-- The first chirp of a thread and its replies, oldest first, with the
+-- filters of GetChirps. A NULL row_limit returns the whole thread.
+SELECT * FROM chirps
+WHERE (id = @thread_id::uuid OR thread_id = @thread_id::uuid)
+  AND deleted_at IS NULL
+  AND hidden_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM user_suspensions s
+    WHERE s.user_id = chirps.user_id
+      AND s.hide_chirps
+      AND s.lifted_at IS NULL
+      AND (s.expires_at IS NULL OR s.expires_at > NOW())
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM user_blocks b
+    WHERE (b.blocker_id = chirps.user_id AND b.blocked_id = @viewer_id::uuid)
+       OR (b.blocker_id = @viewer_id::uuid AND b.blocked_id = chirps.user_id)
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM user_mutes m
+    WHERE m.muter_id = @viewer_id::uuid
+      AND m.muted_id = chirps.user_id
+  )
+ORDER BY created_at
+LIMIT sqlc.narg(row_limit)::int OFFSET sqlc.arg(row_offset)::int;
+
+-- name: GetRepliesByChirps :many
+-- The direct replies to each of the chirps, oldest first, with the filters
+-- of GetChirps. Each chirp's replies are paged on their own.
+SELECT id, created_at, updated_at, body, user_id, deleted_at, quoted_chirp_id, hidden_at, reply_to_id, thread_id
+FROM (
+  SELECT chirps.*, ROW_NUMBER() OVER (
+    PARTITION BY chirps.reply_to_id
+    ORDER BY chirps.created_at
+  ) AS row_num
   FROM chirps
-  WHERE id = $1;
+  WHERE chirps.reply_to_id = ANY(@chirp_ids::uuid[])
+    AND chirps.deleted_at IS NULL
+    AND chirps.hidden_at IS NULL
+    AND NOT EXISTS (
+      SELECT 1 FROM user_suspensions s
+      WHERE s.user_id = chirps.user_id
+        AND s.hide_chirps
+        AND s.lifted_at IS NULL
+        AND (s.expires_at IS NULL OR s.expires_at > NOW())
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM user_blocks b
+      WHERE (b.blocker_id = chirps.user_id AND b.blocked_id = @viewer_id::uuid)
+         OR (b.blocker_id = @viewer_id::uuid AND b.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM user_mutes m
+      WHERE m.muter_id = @viewer_id::uuid
+        AND m.muted_id = chirps.user_id
+    )
+) ranked
+WHERE row_num > sqlc.arg(row_offset)::bigint
+  AND row_num <= sqlc.arg(row_offset)::bigint + sqlc.arg(row_limit)::bigint
+ORDER BY reply_to_id, row_num;
+
+-- name: GetVisibleChirp :one
+-- Like GetSingleChirp, but hides chirps of users that blocked the viewer,
+-- chirps hidden by a moderator and chirps of suspended users whose chirps
+-- were hidden with the suspension.
+SELECT * FROM chirps
+  WHERE chirps.id = @id
+    AND deleted_at IS NULL
+    AND hidden_at IS NULL
+    AND NOT EXISTS (
+      SELECT 1 FROM user_suspensions s
+      WHERE s.user_id = chirps.user_id
+        AND s.hide_chirps
+        AND s.lifted_at IS NULL
+        AND (s.expires_at IS NULL OR s.expires_at > NOW())
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM user_blocks b
+      WHERE b.blocker_id = chirps.user_id
+        AND b.blocked_id = @viewer_id::uuid
+    );
+
+-- name: GetVisibleChirpsByIDs :many
+-- Batch version of GetVisibleChirp, used to embed quoted chirps.
+SELECT * FROM chirps
+  WHERE id = ANY(@ids::uuid[])
+    AND deleted_at IS NULL
+    AND hidden_at IS NULL
+    AND NOT EXISTS (
+      SELECT 1 FROM user_suspensions s
+      WHERE s.user_id = chirps.user_id
+        AND s.hide_chirps
+        AND s.lifted_at IS NULL
+        AND (s.expires_at IS NULL OR s.expires_at > NOW())
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM user_blocks b
+      WHERE b.blocker_id = chirps.user_id
+        AND b.blocked_id = @viewer_id::uuid
+    );
+
+-- name: HideChirp :one
+UPDATE chirps
+SET hidden_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+  AND hidden_at IS NULL
+RETURNING *;
+
+-- name: SoftDeleteChirp :execrows
+UPDATE chirps
+SET deleted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+  AND deleted_at IS NULL;
+
+-- name: GetDeletedChirps :many
+-- Chirps of a user that are still restorable.
+SELECT * FROM chirps
+WHERE user_id = @user_id
+  AND deleted_at > NOW() - make_interval(secs => sqlc.arg(retention_secs)::float8)
+ORDER BY deleted_at DESC;
+
+-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL,
+    updated_at = NOW()
+WHERE id = @id
+  AND user_id = @user_id
+  AND deleted_at > NOW() - make_interval(secs => sqlc.arg(retention_secs)::float8)
+RETURNING *;
 
--- name: DeleteSingleChirp :exec
+-- name: PurgeDeletedChirps :execrows
 DELETE FROM chirps
-  WHERE id = $1;
+WHERE deleted_at < NOW() - make_interval(secs => sqlc.arg(retention_secs)::float8);
@@ -0,0 +1,114 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (id, created_at, updated_at, created_by)
+VALUES (
+  gen_random_uuid(),
+  NOW(),
+  NOW(),
+  $1
+)
+RETURNING *;
+
+-- name: AddConversationParticipant :exec
+INSERT INTO conversation_participants (conversation_id, user_id, joined_at, last_read_at)
+VALUES ($1, $2, NOW(), NULL);
+
+-- name: FindDirectConversation :one
+SELECT c.* FROM conversations c
+WHERE (
+    SELECT COUNT(*) FROM conversation_participants cp
+    WHERE cp.conversation_id = c.id
+  ) = 2
+  AND EXISTS (
+    SELECT 1 FROM conversation_participants cp
+    WHERE cp.conversation_id = c.id AND cp.user_id = @user_a
+  )
+  AND EXISTS (
+    SELECT 1 FROM conversation_participants cp
+    WHERE cp.conversation_id = c.id AND cp.user_id = @user_b
+  )
+LIMIT 1;
+
+-- name: LockDirectConversation :exec
+-- Serialises starting the one-to-one conversation of a pair of users, so
+-- concurrent requests can't both create it.
+SELECT pg_advisory_xact_lock(hashtextextended(
+  LEAST(sqlc.arg(user_a)::uuid, sqlc.arg(user_b)::uuid)::text || GREATEST(sqlc.arg(user_a)::uuid, sqlc.arg(user_b)::uuid)::text,
+  0
+));
+
+-- name: GetConversation :one
+SELECT c.* FROM conversations c
+JOIN conversation_participants cp
+  ON cp.conversation_id = c.id
+ AND cp.user_id = @viewer_id
+WHERE c.id = @id;
+
+-- name: ListConversations :many
+SELECT c.* FROM conversations c
+JOIN conversation_participants cp
+  ON cp.conversation_id = c.id
+ AND cp.user_id = @viewer_id
+ORDER BY c.updated_at DESC
+LIMIT @row_limit OFFSET @row_offset;
+
+-- name: GetConversationParticipants :many
+SELECT p.* FROM conversation_participants p
+WHERE p.conversation_id = @conversation_id
+  AND EXISTS (
+    SELECT 1 FROM conversation_participants me
+    WHERE me.conversation_id = @conversation_id
+      AND me.user_id = @viewer_id
+  )
+ORDER BY p.joined_at;
+
+-- name: ConversationHasBlock :one
+-- Whether another participant blocked the user or was blocked by them.
+SELECT EXISTS (
+  SELECT 1 FROM conversation_participants cp
+  JOIN user_blocks b
+    ON (b.blocker_id = cp.user_id AND b.blocked_id = @user_id)
+    OR (b.blocker_id = @user_id AND b.blocked_id = cp.user_id)
+  WHERE cp.conversation_id = @conversation_id
+    AND cp.user_id <> @user_id
+);
+
+-- name: CreateMessage :one
+INSERT INTO messages (id, created_at, conversation_id, sender_id, body)
+SELECT
+  gen_random_uuid(),
+  NOW(),
+  @conversation_id,
+  @sender_id,
+  @body
+WHERE EXISTS (
+  SELECT 1 FROM conversation_participants cp
+  WHERE cp.conversation_id = @conversation_id
+    AND cp.user_id = @sender_id
+)
+RETURNING *;
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET updated_at = NOW()
+WHERE id = $1;
+
+-- name: ListMessages :many
+SELECT m.* FROM messages m
+WHERE m.conversation_id = @conversation_id
+  AND EXISTS (
+    SELECT 1 FROM conversation_participants cp
+    WHERE cp.conversation_id = m.conversation_id
+      AND cp.user_id = @viewer_id
+  )
+  AND (
+    NOT @has_cursor::boolean
+    OR (m.created_at, m.id) < (@cursor_created_at::timestamp, @cursor_id::uuid)
+  )
+ORDER BY m.created_at DESC, m.id DESC
+LIMIT @row_limit;
+
+-- name: MarkConversationRead :execrows
+UPDATE conversation_participants
+SET last_read_at = NOW()
+WHERE conversation_id = $1
+  AND user_id = $2;
@@ -0,0 +1,57 @@
+-- name: FollowUser :execrows
+-- No row when the user already follows them or either blocked the other.
+INSERT INTO user_follows (follower_id, followed_id, created_at)
+SELECT @follower_id::uuid, @followed_id::uuid, NOW()
+WHERE NOT EXISTS (
+  SELECT 1 FROM user_blocks b
+  WHERE (b.blocker_id = @follower_id AND b.blocked_id = @followed_id)
+     OR (b.blocker_id = @followed_id AND b.blocked_id = @follower_id)
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :exec
+DELETE FROM user_follows
+WHERE follower_id = $1
+  AND followed_id = $2;
+
+-- name: ListFollowers :many
+SELECT * FROM user_follows
+WHERE followed_id = @user_id
+ORDER BY created_at DESC
+LIMIT @row_limit OFFSET @row_offset;
+
+-- name: ListFollowing :many
+SELECT * FROM user_follows
+WHERE follower_id = @user_id
+ORDER BY created_at DESC
+LIMIT @row_limit OFFSET @row_offset;
+
+-- name: GetFollowersByUsers :many
+-- Batch version of ListFollowers, each user's list is paged on its own.
+SELECT followed_id, follower_id
+FROM (
+  SELECT f.*, ROW_NUMBER() OVER (
+    PARTITION BY f.followed_id
+    ORDER BY f.created_at DESC
+  ) AS row_num
+  FROM user_follows f
+  WHERE f.followed_id = ANY(@user_ids::uuid[])
+) ranked
+WHERE row_num > sqlc.arg(row_offset)::bigint
+  AND row_num <= sqlc.arg(row_offset)::bigint + sqlc.arg(row_limit)::bigint
+ORDER BY followed_id, row_num;
+
+-- name: GetFollowingByUsers :many
+-- Batch version of ListFollowing, each user's list is paged on its own.
+SELECT follower_id, followed_id
+FROM (
+  SELECT f.*, ROW_NUMBER() OVER (
+    PARTITION BY f.follower_id
+    ORDER BY f.created_at DESC
+  ) AS row_num
+  FROM user_follows f
+  WHERE f.follower_id = ANY(@user_ids::uuid[])
+) ranked
+WHERE row_num > sqlc.arg(row_offset)::bigint
+  AND row_num <= sqlc.arg(row_offset)::bigint + sqlc.arg(row_limit)::bigint
+ORDER BY follower_id, row_num;
@@ -0,0 +1,34 @@
+-- name: ClaimIdempotencyKey :one
+-- Takes the key for a new request. An existing key is only taken over when
+-- it expired or its request never finished; otherwise there are no rows.
+INSERT INTO idempotency_keys (scope, key, fingerprint, created_at, expires_at)
+VALUES (@scope, @key, @fingerprint, NOW(), NOW() + INTERVAL '24 hours')
+ON CONFLICT (scope, key) DO UPDATE
+SET fingerprint = EXCLUDED.fingerprint,
+    created_at = EXCLUDED.created_at,
+    expires_at = EXCLUDED.expires_at,
+    response_status = NULL,
+    response_content_type = NULL,
+    response_body = NULL
+WHERE idempotency_keys.expires_at < NOW()
+   OR (idempotency_keys.response_status IS NULL AND idempotency_keys.created_at < NOW() - INTERVAL '1 minute')
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE scope = @scope AND key = @key;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET response_status = @response_status,
+    response_content_type = @response_content_type,
+    response_body = @response_body
+WHERE scope = @scope AND key = @key;
+
+-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE scope = @scope AND key = @key;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE expires_at < NOW();
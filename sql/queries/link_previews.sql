@@ -0,0 +1,34 @@
+-- name: GetLinkPreview :one
+-- No row once the preview is older than its TTL, failed fetches have their
+-- own.
+SELECT * FROM link_previews
+WHERE url = @url
+  AND fetched_at > NOW() - make_interval(secs => CASE
+    WHEN ok THEN sqlc.arg(max_age_secs)::float8
+    ELSE sqlc.arg(failed_max_age_secs)::float8
+  END);
+
+-- name: GetLinkPreviews :many
+-- Only successful previews, failed fetches are cached but never shown.
+SELECT * FROM link_previews
+WHERE url = ANY(@urls::text[])
+  AND ok = TRUE;
+
+-- name: UpsertLinkPreview :exec
+INSERT INTO link_previews (url, fetched_at, ok, title, description, image_url, site_name)
+VALUES (
+  @url,
+  NOW(),
+  @ok,
+  @title,
+  @description,
+  @image_url,
+  @site_name
+)
+ON CONFLICT (url) DO UPDATE
+SET fetched_at = EXCLUDED.fetched_at,
+    ok = EXCLUDED.ok,
+    title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    image_url = EXCLUDED.image_url,
+    site_name = EXCLUDED.site_name;
@@ -0,0 +1,33 @@
+-- name: GetLoginLockWait :one
+-- Returns how many seconds the email or the IP is still locked out, 0 when a
+-- login may be attempted.
+SELECT COALESCE(MAX(EXTRACT(EPOCH FROM locked_until - NOW())), 0)::float8 AS wait_secs
+FROM login_throttles
+WHERE ((scope = 'email' AND key = @email::text) OR (scope = 'ip' AND key = @ip::text))
+  AND locked_until > NOW();
+
+-- name: RecordLoginFailure :one
+-- The count starts over when the previous failure is older than the window.
+INSERT INTO login_throttles (scope, key, failures, last_failure_at)
+VALUES (@scope, @key, 1, NOW())
+ON CONFLICT (scope, key) DO UPDATE
+SET failures = CASE
+      WHEN login_throttles.last_failure_at < NOW() - make_interval(secs => sqlc.arg(window_secs)::float8) THEN 1
+      ELSE login_throttles.failures + 1
+    END,
+    last_failure_at = NOW()
+RETURNING failures;
+
+-- name: LockLogin :exec
+UPDATE login_throttles
+SET locked_until = NOW() + make_interval(secs => sqlc.arg(wait_secs)::float8)
+WHERE scope = @scope AND key = @key;
+
+-- name: ClearLoginFailures :execrows
+DELETE FROM login_throttles
+WHERE scope = @scope AND key = @key;
+
+-- name: DeleteStaleLoginThrottles :execrows
+DELETE FROM login_throttles
+WHERE last_failure_at < NOW() - make_interval(secs => sqlc.arg(window_secs)::float8)
+  AND (locked_until IS NULL OR locked_until < NOW());
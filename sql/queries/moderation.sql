@@ -0,0 +1,63 @@
+-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (id, created_at, moderator_id, report_id, action, target_type, target_id, note)
+VALUES (
+  gen_random_uuid(),
+  NOW(),
+  @moderator_id,
+  @report_id,
+  @action,
+  @target_type,
+  @target_id,
+  @note
+)
+RETURNING *;
+
+-- name: ListModerationActions :many
+SELECT * FROM moderation_actions
+ORDER BY created_at DESC
+LIMIT @row_limit OFFSET @row_offset;
+
+-- name: CreateUserSuspension :one
+-- expires_in_secs is NULL for permanent suspensions. The end is computed
+-- from NOW(), like the checks against it, so it doesn't depend on the time
+-- zone.
+INSERT INTO user_suspensions (id, created_at, user_id, suspended_by, reason, expires_at, hide_chirps)
+VALUES (
+  gen_random_uuid(),
+  NOW(),
+  @user_id,
+  @suspended_by,
+  @reason,
+  NOW() + make_interval(secs => sqlc.narg(expires_in_secs)::float8),
+  @hide_chirps
+)
+RETURNING *;
+
+-- name: GetActiveSuspension :one
+-- Permanent suspensions win over temporary ones, then the longest wins.
+SELECT * FROM user_suspensions
+WHERE user_id = $1
+  AND lifted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
+ORDER BY expires_at DESC NULLS FIRST
+LIMIT 1;
+
+-- name: GetSuspendedUserIDs :many
+-- Feeds the in-memory suspension cache used by JWT validation.
+SELECT user_id FROM user_suspensions
+WHERE lifted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
+GROUP BY user_id;
+
+-- name: ListUserSuspensions :many
+SELECT * FROM user_suspensions
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: LiftUserSuspensions :execrows
+UPDATE user_suspensions
+SET lifted_at = NOW(),
+    lifted_by = @lifted_by
+WHERE user_id = @user_id
+  AND lifted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW());
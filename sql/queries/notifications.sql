@@ -0,0 +1,57 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (id, created_at, user_id, actor_id, type, chirp_id, read_at)
+SELECT
+  gen_random_uuid(),
+  NOW(),
+  @user_id,
+  @actor_id,
+  @type,
+  @chirp_id,
+  NULL
+WHERE NOT EXISTS (
+  SELECT 1 FROM notification_preferences
+  WHERE notification_preferences.user_id = @user_id
+    AND notification_preferences.type = @type
+    AND enabled = FALSE
+)
+AND NOT EXISTS (
+  SELECT 1 FROM user_blocks
+  WHERE blocker_id = @user_id
+    AND blocked_id = @actor_id
+)
+RETURNING *;
+
+-- name: ListNotifications :many
+SELECT * FROM notifications
+WHERE user_id = @user_id
+  AND (@unread_only::boolean = FALSE OR read_at IS NULL)
+ORDER BY created_at DESC
+LIMIT @row_limit OFFSET @row_offset;
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = $1
+  AND read_at IS NULL;
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications
+SET read_at = COALESCE(read_at, NOW())
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: MarkAllNotificationsRead :exec
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1
+  AND read_at IS NULL;
+
+-- name: GetNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE user_id = $1;
+
+-- name: SetNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, type, enabled, updated_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (user_id, type) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+    updated_at = NOW();
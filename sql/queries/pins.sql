@@ -0,0 +1,54 @@
+-- name: LockUserPins :exec
+-- Serialises pin changes of one user so the pin limit holds under
+-- concurrent requests.
+SELECT pg_advisory_xact_lock(hashtextextended(@user_id::uuid::text, 0));
+
+-- name: CountPinnedChirps :one
+SELECT COUNT(*) FROM chirp_pins p
+JOIN chirps c ON c.id = p.chirp_id
+WHERE p.user_id = $1
+  AND c.deleted_at IS NULL;
+
+-- name: PinChirp :execrows
+-- Only the author can pin a chirp. Pinning bumps the chirp's updated_at,
+-- its Last-Modified.
+WITH pinned AS (
+  INSERT INTO chirp_pins (user_id, chirp_id, created_at)
+  SELECT @user_id, @chirp_id, NOW()
+  WHERE EXISTS (
+    SELECT 1 FROM chirps
+    WHERE chirps.id = @chirp_id
+      AND chirps.user_id = @user_id
+      AND chirps.deleted_at IS NULL
+  )
+  ON CONFLICT DO NOTHING
+  RETURNING chirp_id
+)
+UPDATE chirps
+SET updated_at = NOW()
+FROM pinned
+WHERE chirps.id = pinned.chirp_id;
+
+-- name: UnpinChirp :exec
+WITH unpinned AS (
+  DELETE FROM chirp_pins
+  WHERE chirp_pins.user_id = $1
+    AND chirp_pins.chirp_id = $2
+  RETURNING chirp_id
+)
+UPDATE chirps
+SET updated_at = NOW()
+FROM unpinned
+WHERE chirps.id = unpinned.chirp_id;
+
+-- name: GetPinnedChirpIDs :many
+-- Most recently pinned first.
+SELECT chirp_id FROM chirp_pins
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetPinsByUsers :many
+-- Batch version of GetPinnedChirpIDs, most recently pinned first.
+SELECT user_id, chirp_id FROM chirp_pins
+WHERE user_id = ANY(@user_ids::uuid[])
+ORDER BY created_at DESC;
@@ -0,0 +1,98 @@
+-- name: CreatePoll :one
+-- The expiry is computed from NOW(), like the checks against it, so it
+-- doesn't depend on the time zone.
+INSERT INTO polls (id, created_at, chirp_id, expires_at, frozen_at)
+VALUES (
+  gen_random_uuid(),
+  NOW(),
+  @chirp_id,
+  NOW() + make_interval(secs => sqlc.arg(expires_in_secs)::float8),
+  NULL
+)
+RETURNING *;
+
+-- name: CreatePollOption :one
+INSERT INTO poll_options (id, poll_id, position, label, final_votes)
+VALUES (
+  gen_random_uuid(),
+  $1,
+  $2,
+  $3,
+  NULL
+)
+RETURNING *;
+
+-- name: GetPollByChirpID :one
+SELECT * FROM polls
+WHERE chirp_id = $1;
+
+-- name: GetPollsByChirpIDs :many
+-- closed matches the expiry check of VoteInPoll.
+SELECT *, (frozen_at IS NOT NULL OR expires_at <= NOW())::boolean AS closed
+FROM polls
+WHERE chirp_id = ANY(@chirp_ids::uuid[]);
+
+-- name: GetPollTallies :many
+-- Frozen polls report their final counts, open ones are counted live.
+SELECT o.id, o.poll_id, o.position, o.label,
+  COALESCE(o.final_votes, (
+    SELECT COUNT(*) FROM poll_votes v
+    WHERE v.option_id = o.id
+  ))::bigint AS votes
+FROM poll_options o
+WHERE o.poll_id = ANY(@poll_ids::uuid[])
+ORDER BY o.poll_id, o.position;
+
+-- name: GetUserPollVotes :many
+SELECT * FROM poll_votes
+WHERE user_id = @user_id
+  AND poll_id = ANY(@poll_ids::uuid[]);
+
+-- name: VoteInPoll :execrows
+-- The primary key on (poll_id, user_id) makes a second vote a no-op, and
+-- votes on expired polls or options of another poll are never inserted.
+-- A vote bumps the chirp's updated_at, its Last-Modified.
+WITH vote AS (
+  INSERT INTO poll_votes (poll_id, user_id, option_id, created_at)
+  SELECT
+    @poll_id,
+    @user_id,
+    @option_id,
+    NOW()
+  WHERE EXISTS (
+    SELECT 1 FROM polls
+    WHERE polls.id = @poll_id
+      AND polls.expires_at > NOW()
+  )
+  AND EXISTS (
+    SELECT 1 FROM poll_options
+    WHERE poll_options.id = @option_id
+      AND poll_options.poll_id = @poll_id
+  )
+  ON CONFLICT (poll_id, user_id) DO NOTHING
+  RETURNING poll_id
+)
+UPDATE chirps
+SET updated_at = NOW()
+FROM polls, vote
+WHERE polls.id = vote.poll_id
+  AND chirps.id = polls.chirp_id;
+
+-- name: FreezeExpiredPolls :execrows
+-- Stores the final tally of every expired poll so the results no longer
+-- change, e.g. when a voter deletes their account. The grace period lets
+-- votes that were in flight at expiry commit before counting.
+WITH frozen AS (
+  UPDATE polls
+  SET frozen_at = NOW()
+  WHERE frozen_at IS NULL
+    AND expires_at <= NOW() - INTERVAL '1 minute'
+  RETURNING polls.id
+)
+UPDATE poll_options
+SET final_votes = (
+  SELECT COUNT(*) FROM poll_votes v
+  WHERE v.option_id = poll_options.id
+)
+FROM frozen
+WHERE poll_options.poll_id = frozen.id;
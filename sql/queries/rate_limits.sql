@@ -0,0 +1,18 @@
+-- name: TakeRateLimitToken :one
+-- Refills the bucket for the time since the last request, capped at burst,
+-- then takes a token if there is a whole one. allowed says whether it did.
+INSERT INTO rate_limit_buckets (key, tokens, updated_at, allowed)
+VALUES (@key, @burst::float8 - 1, NOW(), true)
+ON CONFLICT (key) DO UPDATE
+SET tokens = CASE
+      WHEN LEAST(@burst::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM NOW() - rate_limit_buckets.updated_at)::float8 * @rate::float8) >= 1
+      THEN LEAST(@burst::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM NOW() - rate_limit_buckets.updated_at)::float8 * @rate::float8) - 1
+      ELSE LEAST(@burst::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM NOW() - rate_limit_buckets.updated_at)::float8 * @rate::float8)
+    END,
+    allowed = LEAST(@burst::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM NOW() - rate_limit_buckets.updated_at)::float8 * @rate::float8) >= 1,
+    updated_at = NOW()
+RETURNING tokens, allowed;
+
+-- name: DeleteIdleRateLimitBuckets :execrows
+DELETE FROM rate_limit_buckets
+WHERE updated_at < NOW() - make_interval(secs => sqlc.arg(idle_secs)::float8);
@@ -17,10 +17,17 @@ WHERE token = $1
   AND revoked_at IS NULL
   AND expires_at > NOW();
 
--- name: Revoke :exec
+-- name: Revoke :one
 UPDATE refresh_tokens
 SET revoked_at = NOW(),
     updated_at = NOW()
 WHERE token = $1
-  AND revoked_at IS NULL;
+  AND revoked_at IS NULL
+RETURNING user_id;
 
+-- name: RevokeUserRefreshTokens :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = $1
+  AND revoked_at IS NULL;
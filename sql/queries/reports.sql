@@ -0,0 +1,38 @@
+-- name: CreateReport :execrows
+INSERT INTO reports (id, created_at, reporter_id, target_type, target_id, reported_user_id, reason, details, status)
+VALUES (
+  gen_random_uuid(),
+  NOW(),
+  @reporter_id,
+  @target_type,
+  @target_id,
+  @reported_user_id,
+  @reason,
+  @details,
+  'open'
+)
+ON CONFLICT (reporter_id, target_type, target_id) WHERE status = 'open' DO NOTHING;
+
+-- name: ListReports :many
+-- The queue is worked oldest first.
+SELECT * FROM reports
+WHERE status = @status
+ORDER BY created_at
+LIMIT @row_limit OFFSET @row_offset;
+
+-- name: GetOpenReportForUpdate :one
+SELECT * FROM reports
+WHERE id = $1
+  AND status = 'open'
+FOR UPDATE;
+
+-- name: ResolveReports :execrows
+-- Resolves every open report about the same target at once.
+UPDATE reports
+SET status = 'resolved',
+    resolved_at = NOW(),
+    resolved_by = @resolved_by::uuid,
+    resolution = @resolution::text
+WHERE target_type = @target_type
+  AND target_id = @target_id
+  AND status = 'open';
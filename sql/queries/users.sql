@@ -12,20 +12,58 @@ RETURNING *;
 -- name: DeleteAllUsers :exec
 DELETE FROM users;
 
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
 -- name: GetUserIDByEmail :one
 SELECT * FROM users
 WHERE email = $1;
 
--- name: UserUpdatePassword :exec
+-- name: GetUsersByIDs :many
+SELECT * FROM users
+WHERE id = ANY(@ids::uuid[]);
+
+-- name: UserUpdatePassword :execrows
 UPDATE users
 SET
   updated_at = NOW(),
   hashed_password = $2
 WHERE id = $1;
 
--- name: UserUpgradeToChirpRed :exec
+-- name: UserUpgradeToChirpRed :execrows
 UPDATE users
 SET
   updated_at = NOW(),
   is_chirpy_red = TRUE
 WHERE id = $1;
+
+-- name: SetUserRole :one
+UPDATE users
+SET
+  updated_at = NOW(),
+  role = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: BootstrapAdmin :execrows
+-- Promotes a user to admin, but only while there is no admin at all.
+UPDATE users
+SET
+  updated_at = NOW(),
+  role = 'admin'
+WHERE email = $1
+  AND NOT EXISTS (
+    SELECT 1 FROM users WHERE role = 'admin'
+  );
+
+-- name: CountAdmins :one
+SELECT COUNT(*) FROM users
+WHERE role = 'admin';
+
+-- name: LockAdmins :exec
+-- Locks the admin rows until the end of the transaction, so concurrent
+-- demotions can't both see another admin left.
+SELECT id FROM users
+WHERE role = 'admin'
+FOR UPDATE;
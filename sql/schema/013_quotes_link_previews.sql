@@ -0,0 +1,23 @@
+-- +goose Up
+-- No foreign key on purpose: a quote keeps pointing at its original after
+-- the original is purged, so clients can show it as unavailable.
+ALTER TABLE chirps
+ADD COLUMN quoted_chirp_id UUID;
+
+CREATE INDEX chirps_quoted_chirp_idx ON chirps (quoted_chirp_id) WHERE quoted_chirp_id IS NOT NULL;
+
+CREATE TABLE link_previews (
+  url TEXT PRIMARY KEY,
+  fetched_at TIMESTAMP NOT NULL,
+  ok BOOLEAN NOT NULL,
+  title TEXT NOT NULL,
+  description TEXT NOT NULL,
+  image_url TEXT NOT NULL,
+  site_name TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE link_previews;
+DROP INDEX chirps_quoted_chirp_idx;
+ALTER TABLE chirps
+DROP COLUMN quoted_chirp_id;
@@ -0,0 +1,71 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE chirps
+ADD COLUMN hidden_at TIMESTAMP;
+
+CREATE TABLE reports (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  target_type TEXT NOT NULL CHECK (target_type IN ('chirp', 'user')),
+  target_id UUID NOT NULL,
+  reported_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  reason TEXT NOT NULL,
+  details TEXT NOT NULL,
+  status TEXT NOT NULL CHECK (status IN ('open', 'resolved')),
+  resolved_at TIMESTAMP,
+  resolved_by UUID,
+  resolution TEXT
+);
+
+-- one open report per reporter and target, reporting again is a no-op
+CREATE UNIQUE INDEX reports_open_unique_idx ON reports (reporter_id, target_type, target_id) WHERE status = 'open';
+CREATE INDEX reports_queue_idx ON reports (created_at) WHERE status = 'open';
+
+CREATE TABLE user_suspensions (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  suspended_by UUID NOT NULL,
+  reason TEXT NOT NULL,
+  expires_at TIMESTAMP
+);
+
+CREATE INDEX user_suspensions_user_idx ON user_suspensions (user_id);
+
+-- No foreign keys: the log outlives the users, chirps and reports it
+-- mentions.
+CREATE TABLE moderation_actions (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  moderator_id UUID NOT NULL,
+  report_id UUID,
+  action TEXT NOT NULL,
+  target_type TEXT NOT NULL,
+  target_id UUID NOT NULL,
+  note TEXT NOT NULL
+);
+
+-- +goose StatementBegin
+CREATE FUNCTION moderation_actions_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'moderation_actions is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER moderation_actions_append_only
+BEFORE UPDATE OR DELETE ON moderation_actions
+FOR EACH ROW EXECUTE FUNCTION moderation_actions_append_only();
+
+-- +goose Down
+DROP TABLE moderation_actions;
+DROP FUNCTION moderation_actions_append_only;
+DROP TABLE user_suspensions;
+DROP TABLE reports;
+ALTER TABLE chirps
+DROP COLUMN hidden_at;
+ALTER TABLE users
+DROP COLUMN is_admin;
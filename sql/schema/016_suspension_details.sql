@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE user_suspensions
+ADD COLUMN hide_chirps BOOLEAN NOT NULL DEFAULT false,
+ADD COLUMN lifted_at TIMESTAMP,
+ADD COLUMN lifted_by UUID;
+
+-- +goose Down
+ALTER TABLE user_suspensions
+DROP COLUMN lifted_by,
+DROP COLUMN lifted_at,
+DROP COLUMN hide_chirps;
@@ -0,0 +1,37 @@
+-- +goose Up
+-- No foreign keys, like moderation_actions: the audit trail has to outlive
+-- the users it mentions. actor_id is who did it, subject_id the account it
+-- happened to; either is NULL when unknown.
+CREATE TABLE audit_events (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  event_type TEXT NOT NULL,
+  actor_id UUID,
+  subject_id UUID,
+  target_type TEXT,
+  target_id UUID,
+  ip TEXT NOT NULL,
+  user_agent TEXT NOT NULL,
+  request_id TEXT NOT NULL,
+  details JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX audit_events_created_idx ON audit_events (created_at DESC);
+CREATE INDEX audit_events_subject_idx ON audit_events (subject_id, created_at DESC);
+CREATE INDEX audit_events_actor_idx ON audit_events (actor_id, created_at DESC);
+
+-- +goose StatementBegin
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_events_append_only
+BEFORE UPDATE OR DELETE ON audit_events
+FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+-- +goose Down
+DROP TABLE audit_events;
+DROP FUNCTION audit_events_append_only;
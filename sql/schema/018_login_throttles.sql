@@ -0,0 +1,14 @@
+-- +goose Up
+-- Failed logins per email (known or not, so lockouts don't reveal which
+-- accounts exist) and per client IP.
+CREATE TABLE login_throttles (
+  scope TEXT NOT NULL CHECK (scope IN ('email', 'ip')),
+  key TEXT NOT NULL,
+  failures INTEGER NOT NULL,
+  last_failure_at TIMESTAMP NOT NULL,
+  locked_until TIMESTAMP,
+  PRIMARY KEY (scope, key)
+);
+
+-- +goose Down
+DROP TABLE login_throttles;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Token buckets for the shared rate limit store. Rows are tiny and
+-- rewritten on every request, idle ones are deleted by the purge job.
+CREATE UNLOGGED TABLE rate_limit_buckets (
+  key TEXT PRIMARY KEY,
+  tokens DOUBLE PRECISION NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  allowed BOOLEAN NOT NULL
+);
+
+-- +goose Down
+DROP TABLE rate_limit_buckets;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Live event IDs, shared by every server instance so a client can resume
+-- with Last-Event-ID on any of them.
+CREATE SEQUENCE event_ids;
+
+-- +goose Down
+DROP SEQUENCE event_ids;
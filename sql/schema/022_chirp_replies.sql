@@ -0,0 +1,18 @@
+-- +goose Up
+-- A reply points at the chirp it answers and at the first chirp of its
+-- thread. Like quotes there is no foreign key, so a thread stays together
+-- when one of its chirps is purged.
+ALTER TABLE chirps
+ADD COLUMN reply_to_id UUID;
+
+ALTER TABLE chirps
+ADD COLUMN thread_id UUID;
+
+CREATE INDEX chirps_thread_idx ON chirps (thread_id, created_at) WHERE thread_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_thread_idx;
+ALTER TABLE chirps
+DROP COLUMN thread_id;
+ALTER TABLE chirps
+DROP COLUMN reply_to_id;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX chirps_reply_to_idx ON chirps (reply_to_id, created_at) WHERE reply_to_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_reply_to_idx;